@@ -0,0 +1,104 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// States reported by the controller, besides the ones reported by the provider
+// https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/Overview.DBInstance.Status.html
+const (
+	// StatePending the database does not exist at the provider yet
+	StatePending = "pending"
+	// StateAvailable the database is up and accepting connections
+	StateAvailable = "available"
+	// StateModifying a modification was requested and is being applied
+	StateModifying = "modifying"
+	// StateDeleted the database and the kubernetes objects were removed
+	StateDeleted = "deleted"
+	// StateError the state could not be read from the provider
+	StateError = "error"
+	// StateSnapshotFailed the final snapshot did not complete, the deletion is blocked
+	StateSnapshotFailed = "snapshot-failed"
+	// StateConflict the instance at the provider is owned by someone else and is left untouched
+	StateConflict = "conflict"
+)
+
+// ConditionType is the type of a condition
+type ConditionType string
+
+const (
+	// ConditionReady the database is available and its service points to it
+	ConditionReady ConditionType = "Ready"
+	// ConditionProvisioning the database is being created or restored
+	ConditionProvisioning ConditionType = "Provisioning"
+	// ConditionModifying the database is being changed, rebooted or upgraded
+	ConditionModifying ConditionType = "Modifying"
+	// ConditionDeleting the database is being deleted
+	ConditionDeleting ConditionType = "Deleting"
+	// ConditionDegraded the database or the last reconciliation failed
+	ConditionDegraded ConditionType = "Degraded"
+	// ConditionConflict the instance at the provider is not owned by the object
+	ConditionConflict ConditionType = "Conflict"
+)
+
+// Condition follows the kubernetes conditions convention
+type Condition struct {
+	Type               ConditionType          `json:"type" description:"Type of the condition"`
+	Status             corev1.ConditionStatus `json:"status" description:"True, False or Unknown"`
+	ObservedGeneration int64                  `json:"observedGeneration,omitempty" description:"Generation the condition was set upon"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty" description:"Last time the status changed"`
+	Reason             string                 `json:"reason,omitempty" description:"Machine readable reason of the last transition"`
+	Message            string                 `json:"message,omitempty" description:"Human readable message of the last transition"`
+}
+
+// SetCondition adds or updates a condition, the transition time only changes along the status
+func SetCondition(conditions []Condition, condition Condition) []Condition {
+	for i, c := range conditions {
+		if c.Type != condition.Type {
+			continue
+		}
+		if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		} else if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		conditions[i] = condition
+		return conditions
+	}
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	return append(conditions, condition)
+}
+
+// FindCondition returns the condition of the given type, nil if not set
+func FindCondition(conditions []Condition, conditionType ConditionType) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue ...
+func IsConditionTrue(conditions []Condition, conditionType ConditionType) bool {
+	c := FindCondition(conditions, conditionType)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
@@ -0,0 +1,49 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditions := []Condition{{Type: ConditionReady, Status: corev1.ConditionFalse, LastTransitionTime: past}}
+
+	// Same status keeps the transition time
+	conditions = SetCondition(conditions, Condition{Type: ConditionReady, Status: corev1.ConditionFalse, Reason: "Creating"})
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, past, conditions[0].LastTransitionTime)
+	assert.Equal(t, "Creating", conditions[0].Reason)
+
+	// A new status moves the transition time
+	conditions = SetCondition(conditions, Condition{Type: ConditionReady, Status: corev1.ConditionTrue, Reason: "Available"})
+	assert.Len(t, conditions, 1)
+	assert.True(t, conditions[0].LastTransitionTime.After(past.Time))
+	assert.True(t, IsConditionTrue(conditions, ConditionReady))
+
+	// Unknown types are appended
+	conditions = SetCondition(conditions, Condition{Type: ConditionDegraded, Status: corev1.ConditionFalse})
+	assert.Len(t, conditions, 2)
+	assert.False(t, conditions[1].LastTransitionTime.IsZero())
+	assert.False(t, IsConditionTrue(conditions, ConditionDegraded))
+	assert.Nil(t, FindCondition(conditions, ConditionDeleting))
+}
@@ -0,0 +1,100 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AssumeRole is the IAM role assumed with STS to manage the databases of another account
+type AssumeRole struct {
+	ExternalID  string `json:"externalId,omitempty"`
+	RoleARN     string `json:"roleArn"`
+	SessionName string `json:"sessionName,omitempty"`
+}
+
+// CredentialsSecretRef selects the static access keys of a secret
+type CredentialsSecretRef struct {
+	AccessKeyIDKey     string `json:"accessKeyIdKey,omitempty"`
+	Name               string `json:"name"`
+	Namespace          string `json:"namespace"`
+	SecretAccessKeyKey string `json:"secretAccessKeyKey,omitempty"`
+}
+
+// ProviderConfigSpec defines the AWS account the databases referencing the config are managed in.
+// The static keys, when set, replace the credentials of the controller and are used to assume the role
+type ProviderConfigSpec struct {
+	AllowedNamespaces    []string              `json:"allowedNamespaces,omitempty"`
+	AssumeRole           *AssumeRole           `json:"assumeRole,omitempty"`
+	CredentialsSecretRef *CredentialsSecretRef `json:"credentialsSecretRef,omitempty"`
+	PublicSubnetIDs      []string              `json:"publicSubnetIds,omitempty"`
+	Region               string                `json:"region,omitempty"`
+	SecurityGroupIDs     []string              `json:"securityGroupIds,omitempty"`
+	SubnetIDs            []string              `json:"subnetIds,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=providerconfigs,scope=Cluster
+// +kubebuilder:printcolumn:name="Region",type="string",JSONPath=".spec.region"
+// +kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.assumeRole.roleArn"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ProviderConfig is the Schema for the providerconfigs API
+type ProviderConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ProviderConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ProviderConfigList contains a list of ProviderConfig
+type ProviderConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProviderConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProviderConfig{}, &ProviderConfigList{})
+}
+
+// Allows is true when the objects of the namespace may use the config, every namespace
+// when "*" is listed and none when the list is empty
+func (p *ProviderConfig) Allows(namespace string) bool {
+	for _, allowed := range p.Spec.AllowedNamespaces {
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessKeyID is the key of the access key id in the secret
+func (c *CredentialsSecretRef) AccessKeyID() string {
+	if c.AccessKeyIDKey == "" {
+		return "aws_access_key_id"
+	}
+	return c.AccessKeyIDKey
+}
+
+// SecretAccessKey is the key of the secret access key in the secret
+func (c *CredentialsSecretRef) SecretAccessKey() string {
+	if c.SecretAccessKeyKey == "" {
+		return "aws_secret_access_key"
+	}
+	return c.SecretAccessKeyKey
+}
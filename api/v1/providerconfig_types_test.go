@@ -0,0 +1,44 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderConfigAllows(t *testing.T) {
+	p := &ProviderConfig{}
+	assert.False(t, p.Allows("team-a"))
+
+	p.Spec.AllowedNamespaces = []string{"team-a", "team-b"}
+	assert.True(t, p.Allows("team-b"))
+	assert.False(t, p.Allows("team-c"))
+
+	p.Spec.AllowedNamespaces = []string{"*"}
+	assert.True(t, p.Allows("team-c"))
+}
+
+func TestCredentialsSecretRefKeys(t *testing.T) {
+	ref := &CredentialsSecretRef{Name: "aws", Namespace: "team-a"}
+	assert.Equal(t, "aws_access_key_id", ref.AccessKeyID())
+	assert.Equal(t, "aws_secret_access_key", ref.SecretAccessKey())
+
+	ref.AccessKeyIDKey, ref.SecretAccessKeyKey = "id", "secret"
+	assert.Equal(t, "id", ref.AccessKeyID())
+	assert.Equal(t, "secret", ref.SecretAccessKey())
+}
@@ -16,6 +16,8 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -23,37 +25,113 @@ import (
 // RdsFinalizer ...
 const RdsFinalizer = "rds.k8s.io"
 
+// DeletionPolicyAnnotation overrides the deletion policy of the spec
+const DeletionPolicyAnnotation = "databases.tks.sh/deletion-policy"
+
+// AdoptAnnotation binds the object to an existing instance, the value is its identifier
+const AdoptAnnotation = "databases.tks.sh/adopt"
+
+// DeletionPolicy tells what happens to the database when the object is deleted
+// +kubebuilder:validation:Enum=Delete;Snapshot;Retain;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the database without a final snapshot
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicySnapshot deletes the database after taking a final snapshot, the default
+	DeletionPolicySnapshot DeletionPolicy = "Snapshot"
+	// DeletionPolicyRetain leaves the database untouched at the provider
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan is an alias of Retain
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// RestoreSource restores the database from another instance at a point in time
+type RestoreSource struct {
+	// SourceIdentifier is the DBInstanceIdentifier of the source instance
+	SourceIdentifier string `json:"sourceIdentifier,omitempty"`
+	// SourceRef is the Rds of the source instance, when SourceIdentifier is not set
+	SourceRef *RdsReference `json:"sourceRef,omitempty"`
+	// RestoreTime is the point in time to restore, the latest restorable time when not set
+	RestoreTime *metav1.Time `json:"restoreTime,omitempty"`
+}
+
+// SnapshotSelector picks the newest available snapshot matching all its fields
+type SnapshotSelector struct {
+	// SourceIdentifier is the DBInstanceIdentifier the snapshots were taken from
+	SourceIdentifier string `json:"sourceIdentifier"`
+	// SnapshotType is manual or automated, any when not set
+	// +kubebuilder:validation:Enum=manual;automated
+	SnapshotType string `json:"snapshotType,omitempty"`
+	// Tags the snapshot must have
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
 // RdsSpec defines the desired state of Rds
 type RdsSpec struct {
-	AvailabilityZone      string               `json:"availabilityZone"`
-	BackupRetentionPeriod int64                `json:"backupRetentionPeriod,omitempty"`
-	Class                 string               `json:"class"`
-	CopyTagsToSnapshot    bool                 `json:"copyTagsToSnapshot,omitempty"`
-	DBName                string               `json:"dbname"`
-	DBParameterGroupName  string               `json:"parameterGroup,omitempty"`
-	DBSnapshotIdentifier  string               `json:"snapshotIdentifier"`
-	DBSubnetGroupName     string               `json:"subnetGroupName"`
-	Engine                string               `json:"engine"`
-	EngineVersion         string               `json:"engineVersion"`
-	Iops                  int64                `json:"iops,omitempty"`
-	MultiAZ               bool                 `json:"multiaz,omitempty"`
-	Password              v1.SecretKeySelector `json:"password"`
-	PubliclyAccessible    bool                 `json:"publicAccess,omitempty"`
-	Size                  int64                `json:"size"`
-	StorageEncrypted      bool                 `json:"encrypted,omitempty"`
-	StorageType           string               `json:"storageType,omitempty"`
-	Tags                  map[string]string    `json:"tags"`
-	Username              string               `json:"username"`
-	VpcSecurityGroupIds   string               `json:"vpcSecurityGroupIds,omitempty"`
+	ApplyImmediately         bool                     `json:"applyImmediately,omitempty"`
+	AvailabilityZone         string                   `json:"availabilityZone"`
+	BackupRetentionPeriod    int64                    `json:"backupRetentionPeriod,omitempty"`
+	Class                    string                   `json:"class"`
+	ConnectionSecretName     string                   `json:"connectionSecret,omitempty"`
+	CopyTagsToSnapshot       bool                     `json:"copyTagsToSnapshot,omitempty"`
+	DBName                   string                   `json:"dbname"`
+	DBParameterGroupName     string                   `json:"parameterGroup,omitempty"`
+	DBSnapshotIdentifier     string                   `json:"snapshotIdentifier"`
+	DBSubnetGroupName        string                   `json:"subnetGroupName"`
+	DeletionPolicy           DeletionPolicy           `json:"deletionPolicy,omitempty"`
+	Engine                   string                   `json:"engine"`
+	EngineVersion            string                   `json:"engineVersion"`
+	FinalSnapshotCopies      []SnapshotCopy           `json:"finalSnapshotCopies,omitempty"`
+	GeneratePassword         bool                     `json:"generatePassword,omitempty"`
+	Iops                     int64                    `json:"iops,omitempty"`
+	MultiAZ                  bool                     `json:"multiaz,omitempty"`
+	Password                 v1.SecretKeySelector     `json:"password"`
+	PasswordRotationInterval *metav1.Duration         `json:"passwordRotationInterval,omitempty"`
+	Provider                 string                   `json:"provider,omitempty"`
+	ProviderConfigRef        *v1.LocalObjectReference `json:"providerConfigRef,omitempty"`
+	PubliclyAccessible       bool                     `json:"publicAccess,omitempty"`
+	RestoreFrom              *RestoreSource           `json:"restoreFrom,omitempty"`
+	Size                     int64                    `json:"size"`
+	SnapshotRef              *v1.LocalObjectReference `json:"snapshotRef,omitempty"`
+	SnapshotSelector         *SnapshotSelector        `json:"snapshotSelector,omitempty"`
+	StorageEncrypted         bool                     `json:"encrypted,omitempty"`
+	StorageType              string                   `json:"storageType,omitempty"`
+	SubnetIDs                []string                 `json:"subnetIds,omitempty"`
+	Tags                     map[string]string        `json:"tags"`
+	Username                 string                   `json:"username"`
+	VpcSecurityGroupIds      string                   `json:"vpcSecurityGroupIds,omitempty"`
 }
 
 // RdsStatus defines the observed state of Rds
 type RdsStatus struct {
-	State   string `json:"state,omitempty" description:"State of the deploy"`
-	Message string `json:"message,omitempty" description:"Detailed message around the state"`
+	State                   string               `json:"state,omitempty" description:"State of the deploy"`
+	Message                 string               `json:"message,omitempty" description:"Detailed message around the state"`
+	Modifications           []string             `json:"modifications,omitempty" description:"Fields sent to AWS in the last modification"`
+	ObservedGeneration      int64                `json:"observedGeneration,omitempty" description:"Generation of the spec last reconciled"`
+	Conditions              []Condition          `json:"conditions,omitempty" description:"Latest observations of the database state"`
+	Address                 string               `json:"address,omitempty" description:"Endpoint address of the instance"`
+	Port                    int64                `json:"port,omitempty" description:"Endpoint port of the instance"`
+	Provider                string               `json:"provider,omitempty" description:"Provider managing the database, it never changes once set"`
+	InstanceIdentifier      string               `json:"instanceIdentifier,omitempty" description:"DBInstanceIdentifier of the instance at AWS"`
+	ProviderConfig          string               `json:"providerConfig,omitempty" description:"ProviderConfig of the account of the instance, pinned along the identifier, the account of the controller when empty"`
+	ARN                     string               `json:"arn,omitempty" description:"Amazon Resource Name of the instance"`
+	DbiResourceID           string               `json:"dbiResourceId,omitempty" description:"Region-unique immutable identifier of the instance"`
+	EngineVersion           string               `json:"engineVersion,omitempty" description:"Engine version running on the instance"`
+	AllocatedStorage        int64                `json:"allocatedStorage,omitempty" description:"Storage allocated to the instance in GB"`
+	PasswordHash            string               `json:"passwordHash,omitempty" description:"Hash of the master password last applied"`
+	PasswordRotatedAt       *metav1.Time         `json:"passwordRotatedAt,omitempty" description:"Last time the master password was applied"`
+	RestoredSnapshot        string               `json:"restoredSnapshot,omitempty" description:"Identifier of the snapshot the instance was restored from"`
+	FinalSnapshotIdentifier string               `json:"finalSnapshotIdentifier,omitempty" description:"Identifier of the snapshot taken on deletion"`
+	FinalSnapshotARN        string               `json:"finalSnapshotArn,omitempty" description:"ARN of the final snapshot, set once it is available"`
+	FinalSnapshotCopies     []SnapshotCopyStatus `json:"finalSnapshotCopies,omitempty" description:"Copies of the final snapshot in other regions"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".status.provider"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".status.address"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Rds is the Schema for the rds API
 type Rds struct {
@@ -77,6 +155,50 @@ func init() {
 	SchemeBuilder.Register(&Rds{}, &RdsList{})
 }
 
+// ConnectionSecret returns the name of the secret holding the connection details
+func (r *Rds) ConnectionSecret() string {
+	if r.Spec.ConnectionSecretName != "" {
+		return r.Spec.ConnectionSecretName
+	}
+	return r.Name + "-connection"
+}
+
+// GetDeletionPolicy returns the policy of the annotation, then the one of the spec, Snapshot by default
+func (r *Rds) GetDeletionPolicy() DeletionPolicy {
+	return deletionPolicy(r.Spec.DeletionPolicy, r.Annotations)
+}
+
+// deletionPolicy returns unknown policies as is, Validate refuses them so a typo never deletes a
+// database meant to be kept
+func deletionPolicy(policy DeletionPolicy, annotations map[string]string) DeletionPolicy {
+	if annotation, ok := annotations[DeletionPolicyAnnotation]; ok {
+		policy = DeletionPolicy(annotation)
+	}
+	switch policy {
+	case "":
+		return DeletionPolicySnapshot
+	case DeletionPolicyOrphan:
+		return DeletionPolicyRetain
+	default:
+		return policy
+	}
+}
+
+// Validate fails for the policies other than Delete, Snapshot, Retain and Orphan
+func (p DeletionPolicy) Validate() error {
+	switch p {
+	case DeletionPolicyDelete, DeletionPolicySnapshot, DeletionPolicyRetain, DeletionPolicyOrphan:
+		return nil
+	default:
+		return fmt.Errorf("deletion policy %q is not one of Delete, Snapshot, Retain or Orphan, the deletion is blocked", p)
+	}
+}
+
+// AdoptIdentifier returns the identifier of the instance to adopt, empty when there is none
+func (r *Rds) AdoptIdentifier() string {
+	return r.Annotations[AdoptAnnotation]
+}
+
 func (r *Rds) Is(state string) bool {
 	return r.Status.State == state
 }
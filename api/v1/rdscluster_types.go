@@ -0,0 +1,122 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RdsClusterSpec defines the desired state of RdsCluster
+type RdsClusterSpec struct {
+	BackupRetentionPeriod       int64                    `json:"backupRetentionPeriod,omitempty"`
+	Class                       string                   `json:"class"`
+	ConnectionSecretName        string                   `json:"connectionSecret,omitempty"`
+	DBClusterParameterGroupName string                   `json:"clusterParameterGroup,omitempty"`
+	DBName                      string                   `json:"dbname"`
+	DBSubnetGroupName           string                   `json:"subnetGroupName"`
+	DeletionPolicy              DeletionPolicy           `json:"deletionPolicy,omitempty"`
+	Engine                      string                   `json:"engine"`
+	EngineVersion               string                   `json:"engineVersion,omitempty"`
+	GeneratePassword            bool                     `json:"generatePassword,omitempty"`
+	Instances                   int64                    `json:"instances,omitempty"`
+	Password                    v1.SecretKeySelector     `json:"password"`
+	ProviderConfigRef           *v1.LocalObjectReference `json:"providerConfigRef,omitempty"`
+	PubliclyAccessible          bool                     `json:"publicAccess,omitempty"`
+	StorageEncrypted            bool                     `json:"encrypted,omitempty"`
+	SubnetIDs                   []string                 `json:"subnetIds,omitempty"`
+	Tags                        map[string]string        `json:"tags,omitempty"`
+	Username                    string                   `json:"username"`
+	VpcSecurityGroupIds         string                   `json:"vpcSecurityGroupIds,omitempty"`
+}
+
+// RdsClusterStatus defines the observed state of RdsCluster
+type RdsClusterStatus struct {
+	State                   string      `json:"state,omitempty" description:"State of the deploy"`
+	Message                 string      `json:"message,omitempty" description:"Detailed message around the state"`
+	ObservedGeneration      int64       `json:"observedGeneration,omitempty" description:"Generation of the spec last reconciled"`
+	Conditions              []Condition `json:"conditions,omitempty" description:"Latest observations of the cluster state"`
+	ClusterIdentifier       string      `json:"clusterIdentifier,omitempty" description:"DBClusterIdentifier of the cluster at AWS"`
+	ARN                     string      `json:"arn,omitempty" description:"Amazon Resource Name of the cluster"`
+	WriterEndpoint          string      `json:"writerEndpoint,omitempty" description:"Endpoint of the writer instance"`
+	ReaderEndpoint          string      `json:"readerEndpoint,omitempty" description:"Endpoint balancing the reader instances"`
+	Port                    int64       `json:"port,omitempty" description:"Port of the endpoints"`
+	EngineVersion           string      `json:"engineVersion,omitempty" description:"Engine version running on the cluster"`
+	Members                 []string    `json:"members,omitempty" description:"Identifiers of the instances of the cluster"`
+	FinalSnapshotIdentifier string      `json:"finalSnapshotIdentifier,omitempty" description:"Identifier of the cluster snapshot taken on deletion"`
+	FinalSnapshotARN        string      `json:"finalSnapshotArn,omitempty" description:"ARN of the cluster snapshot taken on deletion, once available"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Writer",type="string",JSONPath=".status.writerEndpoint"
+// +kubebuilder:printcolumn:name="Reader",type="string",JSONPath=".status.readerEndpoint"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RdsCluster is the Schema for the rdsclusters API
+type RdsCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RdsClusterSpec   `json:"spec,omitempty"`
+	Status RdsClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RdsClusterList contains a list of RdsCluster
+type RdsClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RdsCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RdsCluster{}, &RdsClusterList{})
+}
+
+// ConnectionSecret returns the name of the secret holding the connection details
+func (r *RdsCluster) ConnectionSecret() string {
+	if r.Spec.ConnectionSecretName != "" {
+		return r.Spec.ConnectionSecretName
+	}
+	return r.Name + "-connection"
+}
+
+// ReaderService returns the name of the service pointing to the reader endpoint
+func (r *RdsCluster) ReaderService() string {
+	return r.Name + "-ro"
+}
+
+// InstanceCount returns the number of instances of the cluster, the writer included
+func (r *RdsCluster) InstanceCount() int64 {
+	if r.Spec.Instances < 1 {
+		return 1
+	}
+	return r.Spec.Instances
+}
+
+// GetDeletionPolicy returns the policy of the annotation, then the one of the spec, Snapshot by default
+func (r *RdsCluster) GetDeletionPolicy() DeletionPolicy {
+	return deletionPolicy(r.Spec.DeletionPolicy, r.Annotations)
+}
+
+func NewClusterStatus(message string, state string) RdsClusterStatus {
+	return RdsClusterStatus{
+		Message: message,
+		State:   state,
+	}
+}
@@ -0,0 +1,128 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReplicaNamespacesAnnotation lists the namespaces allowed to replicate an Rds, * allows any
+const ReplicaNamespacesAnnotation = "databases.tks.sh/replica-namespaces"
+
+// RdsReference points to an Rds object, the namespace defaults to the one of the referrer
+type RdsReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RdsReadReplicaSpec defines the desired state of RdsReadReplica
+type RdsReadReplicaSpec struct {
+	AvailabilityZone   string            `json:"availabilityZone,omitempty"`
+	Class              string            `json:"class,omitempty"`
+	DeletionPolicy     DeletionPolicy    `json:"deletionPolicy,omitempty"`
+	MultiAZ            bool              `json:"multiaz,omitempty"`
+	Promote            bool              `json:"promote,omitempty"`
+	PubliclyAccessible bool              `json:"publicAccess,omitempty"`
+	Source             RdsReference      `json:"source"`
+	StorageType        string            `json:"storageType,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+}
+
+// RdsReadReplicaStatus defines the observed state of RdsReadReplica
+type RdsReadReplicaStatus struct {
+	State                   string      `json:"state,omitempty" description:"State of the deploy"`
+	Message                 string      `json:"message,omitempty" description:"Detailed message around the state"`
+	ObservedGeneration      int64       `json:"observedGeneration,omitempty" description:"Generation of the spec last reconciled"`
+	Conditions              []Condition `json:"conditions,omitempty" description:"Latest observations of the replica state"`
+	InstanceIdentifier      string      `json:"instanceIdentifier,omitempty" description:"DBInstanceIdentifier of the replica at AWS"`
+	SourceIdentifier        string      `json:"sourceIdentifier,omitempty" description:"DBInstanceIdentifier of the source instance"`
+	ProviderConfig          string      `json:"providerConfig,omitempty" description:"ProviderConfig of the account of the source instance, the account of the controller when empty"`
+	ARN                     string      `json:"arn,omitempty" description:"Amazon Resource Name of the replica"`
+	Address                 string      `json:"address,omitempty" description:"Endpoint address of the replica"`
+	Port                    int64       `json:"port,omitempty" description:"Endpoint port of the replica"`
+	ReplicaLag              *int64      `json:"replicaLag,omitempty" description:"Seconds the replica is behind the source, from CloudWatch"`
+	Promoted                bool        `json:"promoted,omitempty" description:"The replica was promoted to a standalone instance"`
+	FinalSnapshotIdentifier string      `json:"finalSnapshotIdentifier,omitempty" description:"Identifier of the snapshot taken on deletion of a promoted replica"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Source",type="string",JSONPath=".status.sourceIdentifier"
+// +kubebuilder:printcolumn:name="Lag",type="integer",JSONPath=".status.replicaLag"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RdsReadReplica is the Schema for the rdsreadreplicas API
+type RdsReadReplica struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RdsReadReplicaSpec   `json:"spec,omitempty"`
+	Status RdsReadReplicaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RdsReadReplicaList contains a list of RdsReadReplica
+type RdsReadReplicaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RdsReadReplica `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RdsReadReplica{}, &RdsReadReplicaList{})
+}
+
+// SourceNamespace returns the namespace of the source Rds
+func (r *RdsReadReplica) SourceNamespace() string {
+	if r.Spec.Source.Namespace != "" {
+		return r.Spec.Source.Namespace
+	}
+	return r.Namespace
+}
+
+// Service returns the name of the service pointing to the replica
+func (r *RdsReadReplica) Service() string {
+	return r.Name + "-ro"
+}
+
+// GetDeletionPolicy returns the policy of the annotation, then the one of the spec, Snapshot by default
+func (r *RdsReadReplica) GetDeletionPolicy() DeletionPolicy {
+	return deletionPolicy(r.Spec.DeletionPolicy, r.Annotations)
+}
+
+// AllowsReplicaIn tells if a replica in the namespace may replicate the Rds
+func (r *Rds) AllowsReplicaIn(namespace string) bool {
+	if namespace == r.Namespace {
+		return true
+	}
+	for _, ns := range strings.Split(r.Annotations[ReplicaNamespacesAnnotation], ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "*" || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func NewReplicaStatus(message string, state string) RdsReadReplicaStatus {
+	return RdsReadReplicaStatus{
+		Message: message,
+		State:   state,
+	}
+}
@@ -0,0 +1,41 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllowsReplicaIn(t *testing.T) {
+	db := &Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a"}}
+	assert.True(t, db.AllowsReplicaIn("team-a"))
+	assert.False(t, db.AllowsReplicaIn("reporting"))
+
+	db.Annotations = map[string]string{ReplicaNamespacesAnnotation: "analytics, reporting"}
+	assert.True(t, db.AllowsReplicaIn("reporting"))
+	assert.False(t, db.AllowsReplicaIn("team-b"))
+
+	db.Annotations[ReplicaNamespacesAnnotation] = "*"
+	assert.True(t, db.AllowsReplicaIn("team-b"))
+}
+
+func TestReplicaService(t *testing.T) {
+	replica := &RdsReadReplica{ObjectMeta: metav1.ObjectMeta{Name: "orders"}}
+	assert.Equal(t, "orders-ro", replica.Service())
+}
@@ -0,0 +1,98 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotCopy copies a snapshot to another region, re-encrypted with the KMS key when set, and
+// shares it with other AWS accounts. Without region nor key the snapshot itself is shared
+type SnapshotCopy struct {
+	AccountIDs []string `json:"accountIds,omitempty"`
+	KmsKeyID   string   `json:"kmsKeyId,omitempty"`
+	Region     string   `json:"region,omitempty"`
+}
+
+// SnapshotCopyStatus is the observed state of a SnapshotCopy
+type SnapshotCopyStatus struct {
+	Region             string `json:"region" description:"Region of the copy"`
+	SnapshotIdentifier string `json:"snapshotIdentifier" description:"DBSnapshotIdentifier of the copy"`
+	ARN                string `json:"arn,omitempty" description:"Amazon Resource Name of the copy"`
+	State              string `json:"state,omitempty" description:"State of the copy"`
+}
+
+// RdsSnapshotSpec defines the desired state of RdsSnapshot
+type RdsSnapshotSpec struct {
+	Copies []SnapshotCopy          `json:"copies,omitempty"`
+	RdsRef v1.LocalObjectReference `json:"rdsRef"`
+	Retain bool                    `json:"retain,omitempty"`
+	Tags   map[string]string       `json:"tags,omitempty"`
+}
+
+// RdsSnapshotStatus defines the observed state of RdsSnapshot
+type RdsSnapshotStatus struct {
+	State              string               `json:"state,omitempty" description:"State of the snapshot"`
+	Message            string               `json:"message,omitempty" description:"Detailed message around the state"`
+	ObservedGeneration int64                `json:"observedGeneration,omitempty" description:"Generation of the spec last reconciled"`
+	Conditions         []Condition          `json:"conditions,omitempty" description:"Latest observations of the snapshot state"`
+	SnapshotIdentifier string               `json:"snapshotIdentifier,omitempty" description:"DBSnapshotIdentifier of the snapshot at AWS"`
+	SourceIdentifier   string               `json:"sourceIdentifier,omitempty" description:"DBInstanceIdentifier of the snapshotted instance"`
+	ProviderConfig     string               `json:"providerConfig,omitempty" description:"ProviderConfig of the account of the snapshotted instance, the account of the controller when empty"`
+	ARN                string               `json:"arn,omitempty" description:"Amazon Resource Name of the snapshot"`
+	Progress           int64                `json:"progress,omitempty" description:"Percentage of the snapshot done"`
+	AllocatedStorage   int64                `json:"allocatedStorage,omitempty" description:"Size of the snapshot in GB"`
+	Engine             string               `json:"engine,omitempty" description:"Engine of the snapshotted instance"`
+	EngineVersion      string               `json:"engineVersion,omitempty" description:"Engine version of the snapshotted instance"`
+	CreatedAt          *metav1.Time         `json:"createdAt,omitempty" description:"Time the snapshot was taken"`
+	Copies             []SnapshotCopyStatus `json:"copies,omitempty" description:"Copies of the snapshot in other regions"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Progress",type="integer",JSONPath=".status.progress"
+// +kubebuilder:printcolumn:name="Snapshot",type="string",JSONPath=".status.snapshotIdentifier"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RdsSnapshot is the Schema for the rdssnapshots API
+type RdsSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RdsSnapshotSpec   `json:"spec,omitempty"`
+	Status RdsSnapshotStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RdsSnapshotList contains a list of RdsSnapshot
+type RdsSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RdsSnapshot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RdsSnapshot{}, &RdsSnapshotList{})
+}
+
+func NewSnapshotStatus(message string, state string) RdsSnapshotStatus {
+	return RdsSnapshotStatus{
+		Message: message,
+		State:   state,
+	}
+}
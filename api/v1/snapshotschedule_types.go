@@ -0,0 +1,92 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ScheduleLabel is set on the RdsSnapshot objects created by a SnapshotSchedule
+	ScheduleLabel = "databases.tks.sh/snapshot-schedule"
+	// RdsLabel is set on the RdsSnapshot objects created by a SnapshotSchedule, with the Rds name
+	RdsLabel = "databases.tks.sh/rds"
+)
+
+// SnapshotScheduleSpec defines the desired state of SnapshotSchedule
+type SnapshotScheduleSpec struct {
+	Copies     []SnapshotCopy       `json:"copies,omitempty"`
+	KeepDaily  int                  `json:"keepDaily,omitempty"`
+	KeepLast   int                  `json:"keepLast,omitempty"`
+	KeepWeekly int                  `json:"keepWeekly,omitempty"`
+	Schedule   string               `json:"schedule"`
+	Selector   metav1.LabelSelector `json:"selector"`
+	Suspend    bool                 `json:"suspend,omitempty"`
+	Tags       map[string]string    `json:"tags,omitempty"`
+}
+
+// SnapshotScheduleStatus defines the observed state of SnapshotSchedule
+type SnapshotScheduleStatus struct {
+	State              string       `json:"state,omitempty" description:"State of the schedule"`
+	Message            string       `json:"message,omitempty" description:"Detailed message around the state"`
+	ObservedGeneration int64        `json:"observedGeneration,omitempty" description:"Generation of the spec last reconciled"`
+	Conditions         []Condition  `json:"conditions,omitempty" description:"Latest observations of the schedule state"`
+	LastScheduleTime   *metav1.Time `json:"lastScheduleTime,omitempty" description:"Time of the last scheduled snapshots"`
+	NextScheduleTime   *metav1.Time `json:"nextScheduleTime,omitempty" description:"Time of the next scheduled snapshots"`
+	Snapshots          int          `json:"snapshots,omitempty" description:"Number of snapshots kept by the schedule"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule"
+// +kubebuilder:printcolumn:name="Last",type="date",JSONPath=".status.lastScheduleTime"
+// +kubebuilder:printcolumn:name="Snapshots",type="integer",JSONPath=".status.snapshots"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SnapshotSchedule is the Schema for the snapshotschedules API
+type SnapshotSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SnapshotScheduleSpec   `json:"spec,omitempty"`
+	Status SnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SnapshotScheduleList contains a list of SnapshotSchedule
+type SnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SnapshotSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SnapshotSchedule{}, &SnapshotScheduleList{})
+}
+
+// SnapshotName is the name of the RdsSnapshot taken at the scheduled time
+func (s *SnapshotSchedule) SnapshotName(rds string, scheduled metav1.Time) string {
+	return fmt.Sprintf("%v-%v-%v", s.Name, rds, scheduled.UTC().Format("20060102-1504"))
+}
+
+func NewScheduleStatus(message string, state string) SnapshotScheduleStatus {
+	return SnapshotScheduleStatus{
+		Message: message,
+		State:   state,
+	}
+}
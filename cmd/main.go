@@ -3,8 +3,12 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	"github.com/cloud104/kube-db/pkg/actuators"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -28,17 +32,86 @@ func main() {
 }
 
 func commandRoot(c *Config) *cobra.Command {
+	rootCmd.PersistentFlags().StringVar(&c.ConfigFile, "config", "", "Config file, its keys are the flag names")
 	rootCmd.PersistentFlags().StringVar(&c.MetricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
-	rootCmd.PersistentFlags().StringVar(&c.Provider, "provider", "aws", "Provider [aws, gcloud]")
+	rootCmd.PersistentFlags().StringVar(&c.Provider, "provider", "aws", "Provider of the Rds objects without spec.provider [aws, gcloud, azure, local]")
+	rootCmd.PersistentFlags().StringSliceVar(&c.Providers, "providers", nil, "Providers served by the controller, the default provider alone when empty")
 	rootCmd.MarkFlagRequired("Provider")
+	rootCmd.PersistentFlags().StringVar(&c.ClusterID, "cluster-id", "", "Identifies this cluster among the ones sharing the cloud account")
+	rootCmd.PersistentFlags().StringVar(&c.InstanceIdentifierTemplate, "instance-identifier-template", actuators.DefaultIdentifierTemplate, "Template of the instance identifiers, can use .ClusterID, .Namespace, .Name and .UID")
+	rootCmd.PersistentFlags().StringVar(&c.Region, "region", "", "AWS region, defaults to AWS_REGION, the profile or the region label of the nodes")
+	rootCmd.PersistentFlags().StringVar(&c.Profile, "profile", "", "Profile of the shared AWS configuration holding the credentials")
+	rootCmd.PersistentFlags().StringSliceVar(&c.SubnetIDs, "subnet-ids", nil, "Subnets of the instances, discovered from the VPC of a node by default")
+	rootCmd.PersistentFlags().StringSliceVar(&c.PublicSubnetIDs, "public-subnet-ids", nil, "Subnets of the publicly accessible instances")
+	rootCmd.PersistentFlags().StringToStringVar(&c.SubnetTags, "subnet-tags", nil, "Tags selecting the subnets when no IDs are given, like kubernetes.io/role/internal-elb=1")
+	rootCmd.PersistentFlags().StringSliceVar(&c.SecurityGroupIDs, "security-group-ids", nil, "Security groups of the instances, the ones of a node by default")
+	rootCmd.PersistentFlags().StringToStringVar(&c.SecurityGroupTags, "security-group-tags", nil, "Tags selecting the security groups when no IDs are given")
+	rootCmd.PersistentFlags().StringVar(&c.GCloudProject, "gcloud-project", "", "Project of the Cloud SQL instances, the one of the credentials by default")
+	rootCmd.PersistentFlags().StringVar(&c.GCloudRegion, "gcloud-region", "", "Region of the Cloud SQL instances")
+	rootCmd.PersistentFlags().StringVar(&c.GCloudCredentials, "gcloud-credentials", "", "Key file of the service account managing Cloud SQL, the metadata server by default")
+	rootCmd.PersistentFlags().StringVar(&c.GCloudNetwork, "gcloud-network", "", "VPC of the private IPs, like projects/PROJECT/global/networks/NAME, public IPs when empty")
+	rootCmd.PersistentFlags().StringVar(&c.GCloudBackupBucket, "gcloud-backup-bucket", "", "Cloud Storage bucket of the final snapshots of the Cloud SQL instances")
+	rootCmd.PersistentFlags().StringVar(&c.AzureSubscriptionID, "azure-subscription-id", "", "Subscription of the Azure Database servers")
+	rootCmd.PersistentFlags().StringVar(&c.AzureResourceGroup, "azure-resource-group", "", "Resource group of the Azure Database servers")
+	rootCmd.PersistentFlags().StringVar(&c.AzureLocation, "azure-location", "", "Location of the Azure Database servers, like westeurope")
+	rootCmd.PersistentFlags().StringVar(&c.AzureTenantID, "azure-tenant-id", "", "Tenant of the service principal managing the servers")
+	rootCmd.PersistentFlags().StringVar(&c.AzureClientID, "azure-client-id", "", "Client ID of the service principal, or of the managed identity when there is no secret")
+	rootCmd.PersistentFlags().StringVar(&c.AzureClientSecret, "azure-client-secret", "", "Secret of the service principal, the managed identity of the node when empty")
+	rootCmd.PersistentFlags().StringVar(&c.AzureSubnetID, "azure-subnet-id", "", "Resource ID of the subnet delegated to the servers, public access through the firewall when empty")
+	rootCmd.PersistentFlags().StringVar(&c.AzurePrivateDNSZone, "azure-private-dns-zone", "", "Resource ID of the private DNS zone of the servers in the subnet")
+	rootCmd.PersistentFlags().StringSliceVar(&c.AzureAllowedCIDRs, "azure-allowed-cidrs", nil, "Ranges allowed through the firewall of the servers with public access")
+	rootCmd.PersistentFlags().StringVar(&c.LocalStorageClass, "local-storage-class", "", "Storage class of the volumes of the databases run in the cluster, the default class when empty")
+	rootCmd.PersistentFlags().StringToStringVar(&c.LocalImages, "local-images", nil, "Image repository of each engine run in the cluster, like postgres=registry.local/postgres")
+	rootCmd.PersistentFlags().StringVar(&c.LocalDumpCredentials, "local-dump-credentials", "", "Secret with the environment of the containers downloading dumps from object stores")
 
 	rootCmd.AddCommand(commandServe(c))
 	return rootCmd
 }
 
+// initConfig fills the flags not given on the command line from the KUBE_DB_ environment variables,
+// like KUBE_DB_REGION, and then from the config file
 func initConfig(c *Config) {
-	err := viper.Unmarshal(c)
-	if err != nil {
-		panic(fmt.Errorf("Fatal error config file: %s \n", err))
+	viper.SetEnvPrefix("kube_db")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+
+	if c.ConfigFile == "" {
+		c.ConfigFile = viper.GetString("config")
+	}
+	if c.ConfigFile != "" {
+		viper.SetConfigFile(c.ConfigFile)
+		if err := viper.ReadInConfig(); err != nil {
+			panic(fmt.Errorf("Fatal error config file: %s \n", err))
+		}
+	}
+
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || !viper.IsSet(f.Name) {
+			return
+		}
+		if err := f.Value.Set(flagValue(viper.Get(f.Name))); err != nil {
+			panic(fmt.Errorf("Fatal error config %s: %s \n", f.Name, err))
+		}
+	})
+}
+
+// flagValue formats a config value like on the command line, lists and maps are comma separated
+func flagValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = fmt.Sprint(item)
+		}
+		return strings.Join(values, ",")
+	case map[string]interface{}:
+		var values []string
+		for k, item := range v {
+			values = append(values, fmt.Sprintf("%v=%v", k, item))
+		}
+		sort.Strings(values)
+		return strings.Join(values, ",")
+	default:
+		return fmt.Sprint(v)
 	}
 }
@@ -6,10 +6,17 @@ import (
 
 	databasesv1 "github.com/cloud104/kube-db/api/v1"
 	"github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/cloud104/kube-db/pkg/actuators/azure"
+	"github.com/cloud104/kube-db/pkg/actuators/cloudsql"
+	"github.com/cloud104/kube-db/pkg/actuators/local"
 	"github.com/cloud104/kube-db/pkg/actuators/rds"
+	"github.com/cloud104/kube-db/pkg/util"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -22,6 +29,7 @@ var (
 )
 
 func init() {
+	clientgoscheme.AddToScheme(scheme)
 	databasesv1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
@@ -33,8 +41,8 @@ func commandServe(c *Config) *cobra.Command {
 		Long:    ``,
 		Example: "kube-db server",
 		Run: func(cmd *cobra.Command, args []string) {
-			if c.Provider != "aws" && c.Provider != "gcloud" {
-				fmt.Fprintln(os.Stderr, fmt.Errorf("invalid provider: %s", c.Provider))
+			if err := validateProviders(c); err != nil {
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(2)
 			}
 			if err := serve(c); err != nil {
@@ -61,29 +69,25 @@ func serve(c *Config) (err error) {
 		return err
 	}
 
-	if c.Provider == "aws" {
-		// Initialize Actuator
-		actuator, err := rds.NewActuator(
-			ctrl.Log.WithName("controllers").WithName("databases").WithName("rds").WithName("actuator"),
-			cfg,
-		)
+	registry := actuators.NewRegistry(c.Provider)
+	for _, provider := range enabledProviders(c) {
+		actuator, err := providerSetups[provider](c, mgr, cfg)
 		if err != nil {
-			setupLog.Error(err, "unable to start actuator")
-			return err
-		}
-
-		err = (&controllers.RdsReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("databases").WithName("rds").WithName("reconciler"),
-			Actuator: actuator,
-		}).SetupWithManager(mgr)
-		if err != nil {
-			setupLog.Error(err, "unable to create controller", "controller", "Rds")
+			setupLog.Error(err, "unable to start actuator", "provider", provider)
 			return err
 		}
+		registry.Register(provider, actuator)
 	}
 
-	if c.Provider == "gcloud" {
+	err = (&controllers.RdsReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("databases").WithName("rds").WithName("reconciler"),
+		Recorder: mgr.GetEventRecorderFor("rds-controller"),
+		Actuator: registry,
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Rds")
+		return err
 	}
 
 	// +kubebuilder:scaffold:builder
@@ -96,3 +100,164 @@ func serve(c *Config) (err error) {
 
 	return nil
 }
+
+// providerSetups start the actuator of each provider, along the controllers of the kinds
+// only the provider has
+var providerSetups = map[string]func(c *Config, mgr ctrl.Manager, cfg *rest.Config) (controllers.Actuator, error){
+	"aws":    setupAWS,
+	"azure":  setupAzure,
+	"gcloud": setupGCloud,
+	"local":  setupLocal,
+}
+
+// enabledProviders returns the providers served by the process, the default one when none is listed
+func enabledProviders(c *Config) []string {
+	if len(c.Providers) == 0 {
+		return []string{c.Provider}
+	}
+	return c.Providers
+}
+
+// validateProviders fails on unknown providers and on a default provider that is not enabled
+func validateProviders(c *Config) error {
+	enabled := enabledProviders(c)
+	for _, provider := range enabled {
+		if _, ok := providerSetups[provider]; !ok {
+			return fmt.Errorf("invalid provider: %s", provider)
+		}
+	}
+	if !util.Contains(enabled, c.Provider) {
+		return fmt.Errorf("default provider %s is not enabled in %v", c.Provider, enabled)
+	}
+	return nil
+}
+
+// setupAWS starts the RDS actuator and the controllers of the RDS kinds
+func setupAWS(c *Config, mgr ctrl.Manager, cfg *rest.Config) (controllers.Actuator, error) {
+	// Initialize Actuator
+	actuator, err := rds.NewActuator(
+		ctrl.Log.WithName("controllers").WithName("databases").WithName("rds").WithName("actuator"),
+		cfg,
+		rds.Options{
+			ClusterID:          c.ClusterID,
+			IdentifierTemplate: c.InstanceIdentifierTemplate,
+			Region:             c.Region,
+			Profile:            c.Profile,
+			Network: rds.Network{
+				SubnetIDs:         c.SubnetIDs,
+				PublicSubnetIDs:   c.PublicSubnetIDs,
+				SubnetTags:        c.SubnetTags,
+				SecurityGroupIDs:  c.SecurityGroupIDs,
+				SecurityGroupTags: c.SecurityGroupTags,
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = (&controllers.RdsClusterReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("databases").WithName("rdscluster").WithName("reconciler"),
+		Recorder:        mgr.GetEventRecorderFor("rdscluster-controller"),
+		ClusterActuator: actuator.Cluster(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RdsCluster")
+		return nil, err
+	}
+
+	err = (&controllers.RdsReadReplicaReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("databases").WithName("rdsreadreplica").WithName("reconciler"),
+		Recorder:        mgr.GetEventRecorderFor("rdsreadreplica-controller"),
+		ReplicaActuator: actuator.Replica(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RdsReadReplica")
+		return nil, err
+	}
+
+	err = (&controllers.RdsSnapshotReconciler{
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("databases").WithName("rdssnapshot").WithName("reconciler"),
+		Recorder:         mgr.GetEventRecorderFor("rdssnapshot-controller"),
+		SnapshotActuator: actuator.Snapshot(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RdsSnapshot")
+		return nil, err
+	}
+
+	err = (&controllers.SnapshotScheduleReconciler{
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("databases").WithName("snapshotschedule").WithName("reconciler"),
+		Recorder:         mgr.GetEventRecorderFor("snapshotschedule-controller"),
+		ScheduleActuator: actuator.Schedule(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SnapshotSchedule")
+		return nil, err
+	}
+	return actuator, nil
+}
+
+// setupGCloud starts the Cloud SQL actuator, Cloud SQL has no kinds of its own
+func setupGCloud(c *Config, mgr ctrl.Manager, cfg *rest.Config) (controllers.Actuator, error) {
+	actuator, err := cloudsql.NewActuator(
+		ctrl.Log.WithName("controllers").WithName("databases").WithName("cloudsql").WithName("actuator"),
+		cloudsql.Options{
+			Project:            c.GCloudProject,
+			Region:             c.GCloudRegion,
+			Network:            c.GCloudNetwork,
+			BackupBucket:       c.GCloudBackupBucket,
+			CredentialsFile:    c.GCloudCredentials,
+			ClusterID:          c.ClusterID,
+			IdentifierTemplate: c.InstanceIdentifierTemplate,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return actuator, nil
+}
+
+// setupAzure starts the Azure Database actuator, Azure has no kinds of its own
+func setupAzure(c *Config, mgr ctrl.Manager, cfg *rest.Config) (controllers.Actuator, error) {
+	actuator, err := azure.NewActuator(
+		ctrl.Log.WithName("controllers").WithName("databases").WithName("azure").WithName("actuator"),
+		azure.Options{
+			SubscriptionID:     c.AzureSubscriptionID,
+			ResourceGroup:      c.AzureResourceGroup,
+			Location:           c.AzureLocation,
+			TenantID:           c.AzureTenantID,
+			ClientID:           c.AzureClientID,
+			ClientSecret:       c.AzureClientSecret,
+			Subnet:             c.AzureSubnetID,
+			PrivateDNSZone:     c.AzurePrivateDNSZone,
+			AllowedCIDRs:       c.AzureAllowedCIDRs,
+			ClusterID:          c.ClusterID,
+			IdentifierTemplate: c.InstanceIdentifierTemplate,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return actuator, nil
+}
+
+// setupLocal starts the actuator running the databases in the cluster
+func setupLocal(c *Config, mgr ctrl.Manager, cfg *rest.Config) (controllers.Actuator, error) {
+	actuator, err := local.NewActuator(
+		ctrl.Log.WithName("controllers").WithName("databases").WithName("local").WithName("actuator"),
+		local.Options{
+			StorageClass:    c.LocalStorageClass,
+			Images:          c.LocalImages,
+			DumpCredentials: c.LocalDumpCredentials,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return actuator, nil
+}
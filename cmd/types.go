@@ -1,6 +1,34 @@
 package main
 
 type Config struct {
-	MetricsAddr string
-	Provider    string
+	ConfigFile                 string
+	MetricsAddr                string
+	Provider                   string
+	Providers                  []string
+	ClusterID                  string
+	InstanceIdentifierTemplate string
+	Region                     string
+	Profile                    string
+	SubnetIDs                  []string
+	PublicSubnetIDs            []string
+	SubnetTags                 map[string]string
+	SecurityGroupIDs           []string
+	SecurityGroupTags          map[string]string
+	GCloudProject              string
+	GCloudRegion               string
+	GCloudCredentials          string
+	GCloudNetwork              string
+	GCloudBackupBucket         string
+	AzureSubscriptionID        string
+	AzureResourceGroup         string
+	AzureLocation              string
+	AzureTenantID              string
+	AzureClientID              string
+	AzureClientSecret          string
+	AzureSubnetID              string
+	AzurePrivateDNSZone        string
+	AzureAllowedCIDRs          []string
+	LocalStorageClass          string
+	LocalImages                map[string]string
+	LocalDumpCredentials       string
 }
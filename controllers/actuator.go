@@ -15,3 +15,36 @@ type Actuator interface {
 	//
 	Delete(*databasesv1.Rds, *RdsReconciler, context.Context, types.NamespacedName) (databasesv1.RdsStatus, error)
 }
+
+//go:generate mockgen -package=mocks -destination=mocks/cluster_actuator_mock.go -source=actuator.go ClusterActuator
+type ClusterActuator interface {
+	//
+	Reconcile(*databasesv1.RdsCluster, *RdsClusterReconciler, context.Context, types.NamespacedName) (databasesv1.RdsClusterStatus, error)
+
+	//
+	Delete(*databasesv1.RdsCluster, *RdsClusterReconciler, context.Context, types.NamespacedName) (databasesv1.RdsClusterStatus, error)
+}
+
+//go:generate mockgen -package=mocks -destination=mocks/replica_actuator_mock.go -source=actuator.go ReplicaActuator
+type ReplicaActuator interface {
+	//
+	Reconcile(*databasesv1.RdsReadReplica, *RdsReadReplicaReconciler, context.Context, types.NamespacedName) (databasesv1.RdsReadReplicaStatus, error)
+
+	//
+	Delete(*databasesv1.RdsReadReplica, *RdsReadReplicaReconciler, context.Context, types.NamespacedName) (databasesv1.RdsReadReplicaStatus, error)
+}
+
+//go:generate mockgen -package=mocks -destination=mocks/snapshot_actuator_mock.go -source=actuator.go SnapshotActuator
+type SnapshotActuator interface {
+	//
+	Reconcile(*databasesv1.RdsSnapshot, *RdsSnapshotReconciler, context.Context, types.NamespacedName) (databasesv1.RdsSnapshotStatus, error)
+
+	//
+	Delete(*databasesv1.RdsSnapshot, *RdsSnapshotReconciler, context.Context, types.NamespacedName) (databasesv1.RdsSnapshotStatus, error)
+}
+
+//go:generate mockgen -package=mocks -destination=mocks/schedule_actuator_mock.go -source=actuator.go ScheduleActuator
+type ScheduleActuator interface {
+	//
+	Reconcile(*databasesv1.SnapshotSchedule, *SnapshotScheduleReconciler, context.Context, types.NamespacedName) (databasesv1.SnapshotScheduleStatus, error)
+}
@@ -17,13 +17,18 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	databasesv1 "github.com/cloud104/kube-db/api/v1"
 	util "github.com/cloud104/kube-db/pkg/util"
@@ -32,16 +37,22 @@ import (
 // RdsReconciler reconciles a Rds object
 type RdsReconciler struct {
 	client.Client
-	Log logr.Logger
+	Log      logr.Logger
+	Recorder record.EventRecorder
 	Actuator
 }
 
 // +kubebuilder:rbac:groups=databases.tks.sh,resources=rds,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=databases.tks.sh,resources=rds/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=databases.tks.sh,resources=providerconfigs,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=list;watch
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
-// +kubebuilder:rbac:groups="",resources=services,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;delete
 func (r *RdsReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	log := r.Log.WithValues("namespacedName", req.NamespacedName)
@@ -82,18 +93,20 @@ func (r *RdsReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		// Call actuator delete
 		log.Info("reconciling rds object triggers delete")
 		status, err := r.Actuator.Delete(&instance, r, ctx, req.NamespacedName)
-		if err != nil {
-			log.Error(err, "Error deleting rds object")
-			return ctrl.Result{}, err
-		}
+		status.ObservedGeneration = instance.Generation
 
-		// Update Status
+		// Update Status, also on error so the conditions tell why the deletion is blocked
 		if err := r.updateStatus(&instance, status, context.Background(), req.NamespacedName); err != nil {
 			log.Info("Update Status Failed", "error", err)
 			return ctrl.Result{}, nil
 		}
 
-		if status.State != "pending" {
+		if err != nil {
+			log.Error(err, "Error deleting rds object")
+			return ctrl.Result{}, err
+		}
+
+		if status.State != databasesv1.StateDeleted {
 			log.Info("Deleting, requeueing", "status", status)
 			return ctrl.Result{Requeue: true, RequeueAfter: 100}, nil
 		}
@@ -112,6 +125,7 @@ func (r *RdsReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	// Reconcile
 	log.Info("reconciling rds object triggers idempotent reconcile")
 	status, err := r.Actuator.Reconcile(&instance, r, ctx, req.NamespacedName)
+	status.ObservedGeneration = instance.Generation
 
 	// Update Status
 	if err := r.updateStatus(&instance, status, context.Background(), req.NamespacedName); err != nil {
@@ -130,12 +144,21 @@ func (r *RdsReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
-	// If state is diferent (WAITING, ERROR) from CREATED requeue
-	if status.State != "available" {
+	// If state is diferent from available requeue
+	if status.State != databasesv1.StateAvailable {
 		log.Info("Creating, requeueing", "status", status)
 		return ctrl.Result{Requeue: true, RequeueAfter: 100}, nil
 	}
 
+	// If the password rotates, come back when it is due
+	if interval := instance.Spec.PasswordRotationInterval; interval != nil && status.PasswordRotatedAt != nil {
+		wait := time.Until(status.PasswordRotatedAt.Add(interval.Duration))
+		if wait < time.Second {
+			wait = time.Second
+		}
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
 	// If CREATED return done
 	return ctrl.Result{}, nil
 }
@@ -151,7 +174,27 @@ func (r *RdsReconciler) updateStatus(db *databasesv1.Rds, status databasesv1.Rds
 }
 
 func (r *RdsReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).For(&databasesv1.Rds{}).Complete(r)
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&databasesv1.Rds{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.rdsForSecret)}).
+		Complete(r)
+}
+
+// rdsForSecret maps a secret to the Rds objects using it as password
+func (r *RdsReconciler) rdsForSecret(o handler.MapObject) []ctrl.Request {
+	list := databasesv1.RdsList{}
+	if err := r.List(context.Background(), &list, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list rds for secret", "secret", o.Meta.GetName())
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, db := range list.Items {
+		if db.Spec.Password.Name == o.Meta.GetName() {
+			requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: db.Namespace, Name: db.Name}})
+		}
+	}
+	return requests
 }
 
 // func (r *RdsReconciler) addFinalizer(db *databasesv1.Rds) {
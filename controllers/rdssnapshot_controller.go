@@ -0,0 +1,142 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	util "github.com/cloud104/kube-db/pkg/util"
+)
+
+// RdsSnapshotReconciler reconciles a RdsSnapshot object
+type RdsSnapshotReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	SnapshotActuator
+}
+
+// +kubebuilder:rbac:groups=databases.tks.sh,resources=rdssnapshots,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=databases.tks.sh,resources=rdssnapshots/status,verbs=get;update;patch
+func (r *RdsSnapshotReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("namespacedName", req.NamespacedName)
+	instance := databasesv1.RdsSnapshot{}
+
+	log.Info("Running reconcile rds snapshot")
+
+	// Get record from kubernetes api
+	if err := r.Get(ctx, req.NamespacedName, &instance); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "No record found")
+		return ctrl.Result{}, err
+	}
+
+	// Add a finalizer to newly created objects
+	if instance.ObjectMeta.DeletionTimestamp.IsZero() && !util.Contains(instance.ObjectMeta.Finalizers, databasesv1.RdsFinalizer) {
+		instance.Finalizers = append(instance.Finalizers, databasesv1.RdsFinalizer)
+		if err := r.Update(ctx, &instance); err != nil {
+			log.Error(err, "failed to add finalizer to rds snapshot")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Delete
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !util.Contains(instance.ObjectMeta.Finalizers, databasesv1.RdsFinalizer) {
+			log.Info("reconciling rds snapshot object causes a no-op as there is no finalizer")
+			return ctrl.Result{}, nil
+		}
+
+		log.Info("reconciling rds snapshot object triggers delete")
+		status, err := r.SnapshotActuator.Delete(&instance, r, ctx, req.NamespacedName)
+		status.ObservedGeneration = instance.Generation
+
+		if err := r.updateStatus(&instance, status, ctx, req.NamespacedName); err != nil {
+			log.Info("Update Status Failed", "error", err)
+			return ctrl.Result{}, nil
+		}
+
+		if err != nil {
+			log.Error(err, "Error deleting rds snapshot object")
+			return ctrl.Result{}, err
+		}
+
+		if status.State != databasesv1.StateDeleted {
+			log.Info("Deleting, requeueing", "status", status)
+			return ctrl.Result{Requeue: true, RequeueAfter: 100}, nil
+		}
+
+		log.Info("rds snapshot object deletion successful, removing finalizer")
+		instance.ObjectMeta.Finalizers = util.Filter(instance.ObjectMeta.Finalizers, databasesv1.RdsFinalizer)
+		if err := r.Client.Update(ctx, &instance); err != nil {
+			log.Error(err, "Error removing finalizer from rds snapshot object")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	// Reconcile
+	log.Info("reconciling rds snapshot object triggers idempotent reconcile")
+	status, err := r.SnapshotActuator.Reconcile(&instance, r, ctx, req.NamespacedName)
+	status.ObservedGeneration = instance.Generation
+
+	if err := r.updateStatus(&instance, status, ctx, req.NamespacedName); err != nil {
+		log.Info("Update Status Failed", "error", err, "status", status)
+		return ctrl.Result{Requeue: true, RequeueAfter: 100}, nil
+	}
+
+	if err != nil {
+		log.Error(err, "Error reconciling rds snapshot object")
+		return ctrl.Result{}, err
+	}
+
+	// If state is diferent from available requeue
+	if status.State != databasesv1.StateAvailable {
+		log.Info("Snapshotting, requeueing", "status", status)
+		return ctrl.Result{Requeue: true, RequeueAfter: 100}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *RdsSnapshotReconciler) updateStatus(snapshot *databasesv1.RdsSnapshot, status databasesv1.RdsSnapshotStatus, ctx context.Context, namespacedName types.NamespacedName) (err error) {
+	err = r.Get(ctx, namespacedName, snapshot)
+	if err != nil {
+		return
+	}
+	snapshot.Status = status
+	err = r.Update(ctx, snapshot)
+	return
+}
+
+func (r *RdsSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&databasesv1.RdsSnapshot{}).
+		Complete(r)
+}
@@ -0,0 +1,104 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+)
+
+// SnapshotScheduleReconciler reconciles a SnapshotSchedule object
+type SnapshotScheduleReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	ScheduleActuator
+}
+
+// +kubebuilder:rbac:groups=databases.tks.sh,resources=snapshotschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=databases.tks.sh,resources=snapshotschedules/status,verbs=get;update;patch
+func (r *SnapshotScheduleReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("namespacedName", req.NamespacedName)
+	instance := databasesv1.SnapshotSchedule{}
+
+	log.Info("Running reconcile snapshot schedule")
+
+	// Get record from kubernetes api
+	if err := r.Get(ctx, req.NamespacedName, &instance); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "No record found")
+		return ctrl.Result{}, err
+	}
+
+	// The snapshots outlive their schedule, there is nothing to clean up
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Reconcile
+	status, err := r.ScheduleActuator.Reconcile(&instance, r, ctx, req.NamespacedName)
+	status.ObservedGeneration = instance.Generation
+
+	if err := r.updateStatus(&instance, status, ctx, req.NamespacedName); err != nil {
+		log.Info("Update Status Failed", "error", err, "status", status)
+		return ctrl.Result{Requeue: true, RequeueAfter: 100}, nil
+	}
+
+	if err != nil {
+		log.Error(err, "Error reconciling snapshot schedule object")
+		return ctrl.Result{}, err
+	}
+
+	// Wake up for the next run
+	if status.NextScheduleTime != nil {
+		wait := time.Until(status.NextScheduleTime.Time)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		log.Info("Waiting for the next run", "next", status.NextScheduleTime, "wait", wait)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *SnapshotScheduleReconciler) updateStatus(schedule *databasesv1.SnapshotSchedule, status databasesv1.SnapshotScheduleStatus, ctx context.Context, namespacedName types.NamespacedName) (err error) {
+	err = r.Get(ctx, namespacedName, schedule)
+	if err != nil {
+		return
+	}
+	schedule.Status = status
+	err = r.Update(ctx, schedule)
+	return
+}
+
+func (r *SnapshotScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&databasesv1.SnapshotSchedule{}).
+		Complete(r)
+}
@@ -0,0 +1,97 @@
+package actuators
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+)
+
+// Tags naming the object owning the resources the actuators other than AWS create
+const (
+	ClusterIDTag = "kube-db-cluster-id"
+	NamespaceTag = "kube-db-namespace"
+	NameTag      = "kube-db-name"
+	UIDTag       = "kube-db-uid"
+)
+
+// Event records a kubernetes event on the object, when the reconciler has a recorder
+func Event(client *controllers.RdsReconciler, db *databasesv1.Rds, eventType string, reason string, message string) {
+	if client == nil || client.Recorder == nil {
+		return
+	}
+	client.Recorder.Event(db, eventType, reason, message)
+}
+
+// AWSOnlyFields lists the fields set on the object that only the AWS actuator implements
+func AWSOnlyFields(db *databasesv1.Rds) []string {
+	var fields []string
+	if db.Spec.RestoreFrom != nil {
+		fields = append(fields, "restoreFrom")
+	}
+	if db.AdoptIdentifier() != "" {
+		fields = append(fields, databasesv1.AdoptAnnotation)
+	}
+	if db.Spec.ProviderConfigRef != nil {
+		fields = append(fields, "providerConfigRef")
+	}
+	return fields
+}
+
+// Unsupported fails naming the fields the provider does not implement, if any
+func Unsupported(provider string, fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%v not supported by the %v provider", strings.Join(fields, ", "), provider)
+}
+
+// Observe builds the status to persist from the one of the object and the outcome of the
+// reconciliation. describe fills in the details of the instance, unless it belongs to someone else
+func Observe(db *databasesv1.Rds, status databasesv1.RdsStatus, err error, hasService bool, describe func(observed *databasesv1.RdsStatus)) databasesv1.RdsStatus {
+	observed := *db.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+	if len(status.Modifications) > 0 {
+		observed.Modifications = status.Modifications
+	}
+
+	// The details of an instance owned by someone else are none of our business
+	if status.State != databasesv1.StateConflict {
+		describe(&observed)
+	}
+
+	observed.Conditions = DatabaseConditions(observed.Conditions, observed.State, observed.Message, db, err, hasService)
+	return observed
+}
+
+// OwnerTags returns the tags naming the object as the owner of a resource
+func OwnerTags(db *databasesv1.Rds, clusterID string) map[string]string {
+	tags := map[string]string{
+		NamespaceTag: db.Namespace,
+		NameTag:      db.Name,
+		UIDTag:       string(db.UID),
+	}
+	if clusterID != "" {
+		tags[ClusterIDTag] = clusterID
+	}
+	return tags
+}
+
+// VerifyOwnership fails when the tags of the resource do not match the owner ones
+func VerifyOwnership(resource string, tags map[string]string, owner map[string]string, db *databasesv1.Rds) error {
+	keys := make([]string, 0, len(owner))
+	for k := range owner {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if tags[k] != owner[k] {
+			return fmt.Errorf("%v is not owned by %v/%v: %v is %q", resource, db.Namespace, db.Name, k, tags[k])
+		}
+	}
+	return nil
+}
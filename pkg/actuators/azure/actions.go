@@ -0,0 +1,307 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	status, err = a.reconcile(db, client, ctx)
+	return a.observe(db, client, ctx, status, err), err
+}
+
+func (a *Actuator) reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (status databasesv1.RdsStatus, err error) {
+	log := a.log.WithValues("reconcilingDatabase", db.Name)
+	kube := &actuators.Kube{Client: client.Client}
+
+	if err := unsupported(db); err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+	kind, err := kindOf(db.Spec.Engine)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// Persist the name before anything gets created, so it never changes afterwards
+	if db.Status.InstanceIdentifier == "" {
+		db.Status.InstanceIdentifier = a.serverName(db)
+	}
+	name := db.Status.InstanceIdentifier
+
+	desired, err := a.server(db, kind, name)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	server, err := a.arm.GetServer(ctx, kind, name)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// CREATE
+	if server == nil {
+		password, err := kube.MasterPassword(ctx, db)
+		if err != nil {
+			return databasesv1.NewStatus("Failing Geting Secret", databasesv1.StatePending), err
+		}
+		log.Info("creating", "server", name, "sku", desired.SKU.Name, "version", desired.Properties.Version)
+		desired.Properties.AdministratorLogin = db.Spec.Username
+		desired.Properties.AdministratorLoginPassword = password
+		if err := a.arm.PutServer(ctx, kind, desired); err != nil {
+			return databasesv1.NewStatus(err.Error(), databasesv1.StatePending), err
+		}
+		now := metav1.Now()
+		db.Status.PasswordHash = actuators.PasswordHash(db, password)
+		db.Status.PasswordRotatedAt = &now
+		return databasesv1.NewStatus("Creating Database", "creating"), nil
+	}
+
+	// OWNERSHIP
+	// Never touch a server created by someone else that happens to share the name
+	if err := a.verifyOwnership(db, server); err != nil {
+		actuators.Event(client, db, corev1.EventTypeWarning, "Conflict", err.Error())
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateConflict), err
+	}
+
+	currentStatus := state(server)
+	if currentStatus != databasesv1.StateAvailable {
+		return databasesv1.NewStatus("Database not in a reconcilable state, will wait", currentStatus), nil
+	}
+
+	// DATABASE
+	if db.Spec.DBName != "" {
+		database, err := a.arm.GetDatabase(ctx, kind, name, db.Spec.DBName)
+		if err != nil {
+			return databasesv1.NewStatus(err.Error(), currentStatus), err
+		}
+		if database == nil {
+			log.Info("creating database", "dbname", db.Spec.DBName)
+			if err := a.arm.PutDatabase(ctx, kind, name, newDatabase(kind, db.Spec.DBName)); err != nil {
+				return databasesv1.NewStatus(err.Error(), currentStatus), err
+			}
+			return databasesv1.NewStatus(fmt.Sprintf("Creating database %v", db.Spec.DBName), databasesv1.StateModifying), nil
+		}
+	}
+
+	// FIREWALL
+	// Replaces the security groups of RDS, only for servers outside of a subnet
+	changed, err := a.reconcileFirewall(ctx, db, kind, name)
+	if err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Firewall Rules", currentStatus), err
+	}
+	if changed {
+		return databasesv1.NewStatus("Updating firewall rules", databasesv1.StateModifying), nil
+	}
+
+	// PASSWORD
+	password, err := kube.MasterPassword(ctx, db)
+	if err != nil {
+		return databasesv1.NewStatus("Failing Geting Secret", currentStatus), err
+	}
+	if hash := actuators.PasswordHash(db, password); hash != db.Status.PasswordHash {
+		log.Info("applying password", "username", db.Spec.Username)
+		err := a.arm.PatchServer(ctx, kind, name, &Server{Properties: &ServerProperties{AdministratorLoginPassword: password}})
+		if err != nil {
+			return databasesv1.NewStatus("Failing Reconciled Password", currentStatus), err
+		}
+		now := metav1.Now()
+		db.Status.PasswordHash = hash
+		db.Status.PasswordRotatedAt = &now
+		return databasesv1.NewStatus("Applying new master password", databasesv1.StateModifying), nil
+	}
+
+	// MODIFY
+	patch, changes := modifications(server, desired)
+	if len(changes) > 0 {
+		log.Info("Modifying database", "changes", changes)
+		if err := a.arm.PatchServer(ctx, kind, name, patch); err != nil {
+			return databasesv1.NewStatus("Failed To Modify Database", currentStatus), err
+		}
+		status := databasesv1.NewStatus(fmt.Sprintf("Modifying %v", strings.Join(changes, ", ")), databasesv1.StateModifying)
+		status.Modifications = changes
+		return status, nil
+	}
+
+	// SERVICE
+	host := server.Properties.FullyQualifiedDomainName
+	if host == "" {
+		return databasesv1.NewStatus("Waiting for endpoint to be available", currentStatus), nil
+	}
+	if err := kube.ReconcileService(ctx, db, host, int32(port(kind))); err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Service", currentStatus), err
+	}
+
+	data := actuators.ConnectionData(db.Spec.Engine, db.Spec.Username, db.Spec.DBName, host, port(kind), password)
+	if err := kube.ReconcileSecret(ctx, db, db.ConnectionSecret(), data); err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Connection Secret", currentStatus), err
+	}
+
+	return databasesv1.NewStatus("Database reconciled", currentStatus), nil
+}
+
+func (a *Actuator) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	status, err = a.delete(db, client, ctx)
+	return a.observe(db, client, ctx, status, err), err
+}
+
+func (a *Actuator) delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (status databasesv1.RdsStatus, err error) {
+	log := a.log.WithValues("delete", db.Name)
+	kube := &actuators.Kube{Client: client.Client}
+	policy := db.GetDeletionPolicy()
+	if err := policy.Validate(); err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// RETAIN
+	// Leave the server at Azure, only the kubernetes objects go away. Snapshot is refused on
+	// reconciliation, a server that got it afterwards is retained rather than lost
+	if policy == databasesv1.DeletionPolicySnapshot {
+		actuators.Event(client, db, corev1.EventTypeWarning, "FinalSnapshotFailed", "Azure has no final snapshot, the server is retained")
+	}
+	if policy == databasesv1.DeletionPolicyRetain || policy == databasesv1.DeletionPolicySnapshot {
+		if err := kube.ReleasePassword(ctx, db); err != nil {
+			return databasesv1.NewStatus("ERROR Releasing password secret", db.Status.State), err
+		}
+		if err := kube.DeleteService(ctx, db); err != nil {
+			return databasesv1.NewStatus("ERROR Deleting svc", db.Status.State), err
+		}
+		return databasesv1.NewStatus("Database retained at Azure", databasesv1.StateDeleted), nil
+	}
+
+	kind, err := kindOf(db.Spec.Engine)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+	name := a.serverName(db)
+	server, err := a.arm.GetServer(ctx, kind, name)
+	if err != nil {
+		return databasesv1.NewStatus("Error Getting Status", db.Status.State), err
+	}
+
+	if server != nil {
+		// OWNERSHIP
+		// The deletion is blocked until the policy is changed to Retain
+		if err := a.verifyOwnership(db, server); err != nil {
+			actuators.Event(client, db, corev1.EventTypeWarning, "Conflict", err.Error())
+			return databasesv1.NewStatus(err.Error(), databasesv1.StateConflict), err
+		}
+
+		currentStatus := state(server)
+		if currentStatus == "creating" || currentStatus == "deleting" || currentStatus == databasesv1.StateModifying {
+			return databasesv1.NewStatus("Database not in a deletable state, will wait", currentStatus), nil
+		}
+
+		log.Info("deleting server", "server", name, "deletionPolicy", policy)
+		if err := a.arm.DeleteServer(ctx, kind, name); err != nil {
+			return databasesv1.NewStatus(err.Error(), currentStatus), err
+		}
+		return databasesv1.NewStatus("Deleting", "deleting"), nil
+	}
+
+	if err := kube.DeleteService(ctx, db); err != nil {
+		return databasesv1.NewStatus("ERROR Deleting svc", databasesv1.StatePending), err
+	}
+
+	log.Info("Deletion of database done")
+	return databasesv1.NewStatus("Deleted", databasesv1.StateDeleted), nil
+}
+
+// reconcileFirewall sets the firewall rules of the spec, deleting the other rules the actuator
+// created. Tells whether a rule changed
+func (a *Actuator) reconcileFirewall(ctx context.Context, db *databasesv1.Rds, kind Kind, name string) (bool, error) {
+	current, err := a.arm.ListFirewallRules(ctx, kind, name)
+	if err != nil {
+		return false, err
+	}
+	existing := map[string]FirewallRule{}
+	for _, rule := range current {
+		if strings.HasPrefix(rule.Name, firewallRulePrefix) {
+			existing[rule.Name] = rule
+		}
+	}
+
+	changed := false
+	for _, rule := range a.firewallRules(db) {
+		if e, ok := existing[rule.Name]; ok && e.Properties == rule.Properties {
+			delete(existing, rule.Name)
+			continue
+		}
+		delete(existing, rule.Name)
+		rule := rule
+		if err := a.arm.PutFirewallRule(ctx, kind, name, &rule); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	for stale := range existing {
+		if err := a.arm.DeleteFirewallRule(ctx, kind, name, stale); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// modifications returns the server patching the current one to the spec and the fields changed.
+// Storage only grows
+func modifications(current *Server, desired *Server) (*Server, []string) {
+	patch := &Server{Properties: &ServerProperties{}}
+	var changes []string
+	properties := current.Properties
+	if properties == nil {
+		properties = &ServerProperties{}
+	}
+
+	if current.SKU == nil || *current.SKU != *desired.SKU {
+		patch.SKU = desired.SKU
+		changes = append(changes, "class")
+	}
+	if properties.Storage == nil || properties.Storage.StorageSizeGB < desired.Properties.Storage.StorageSizeGB {
+		patch.Properties.Storage = desired.Properties.Storage
+		changes = append(changes, "size")
+	}
+	if properties.Backup == nil || properties.Backup.BackupRetentionDays != desired.Properties.Backup.BackupRetentionDays {
+		patch.Properties.Backup = desired.Properties.Backup
+		changes = append(changes, "backupRetentionPeriod")
+	}
+	if properties.HighAvailability == nil || properties.HighAvailability.Mode != desired.Properties.HighAvailability.Mode {
+		patch.Properties.HighAvailability = desired.Properties.HighAvailability
+		changes = append(changes, "multiaz")
+	}
+	if !reflect.DeepEqual(current.Tags, desired.Tags) {
+		patch.Tags = desired.Tags
+		changes = append(changes, "tags")
+	}
+	return patch, changes
+}
+
+// newDatabase returns a database in UTF-8
+func newDatabase(kind Kind, name string) *Database {
+	if kind == MySQL {
+		return &Database{Name: name, Properties: &DatabaseProperties{Charset: "utf8mb4", Collation: "utf8mb4_general_ci"}}
+	}
+	return &Database{Name: name, Properties: &DatabaseProperties{Charset: "UTF8", Collation: "en_US.utf8"}}
+}
+
+// unsupported fails for the fields of the spec only RDS implements
+func unsupported(db *databasesv1.Rds) error {
+	var fields []string
+	if db.Spec.DBSnapshotIdentifier != "" || db.Spec.SnapshotRef != nil || db.Spec.SnapshotSelector != nil {
+		fields = append(fields, "snapshots")
+	}
+	fields = append(fields, actuators.AWSOnlyFields(db)...)
+	// Azure deletes the backups along the server, there is no final snapshot to take
+	if db.GetDeletionPolicy() == databasesv1.DeletionPolicySnapshot {
+		fields = append(fields, "deletionPolicy Snapshot")
+	}
+	return actuators.Unsupported("azure", fields)
+}
@@ -0,0 +1,196 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func testReconciler(t *testing.T, objects ...runtime.Object) *controllers.RdsReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	return &controllers.RdsReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme, objects...),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func testDatabase() *databasesv1.Rds {
+	return &databasesv1.Rds{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default", UID: "8f9c0b0e"},
+		Spec: databasesv1.RdsSpec{
+			Class:            "db.m5.large",
+			DBName:           "app",
+			DeletionPolicy:   databasesv1.DeletionPolicyDelete,
+			Engine:           "postgres",
+			EngineVersion:    "13.4",
+			GeneratePassword: true,
+			Password:         corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "pgsql-password"}, Key: "password"},
+			Size:             50,
+			Username:         "app",
+		},
+	}
+}
+
+func TestReconcileAndDelete(t *testing.T) {
+	f, arm, stop := newFakeARM()
+	defer stop()
+	a := &Actuator{
+		log:     zap.Logger(true),
+		arm:     arm,
+		options: Options{Location: "westeurope", AllowedCIDRs: []string{"203.0.113.0/24"}, ClusterID: "cluster", IdentifierTemplate: "{{.Name}}"},
+	}
+	client := testReconciler(t)
+	ctx := context.Background()
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	reconcile := func() databasesv1.RdsStatus {
+		status, err := a.Reconcile(db, client, ctx, key)
+		assert.NoError(t, err)
+		db.Status = status
+		return status
+	}
+
+	// The server is created with the ownership tags and the generated password
+	assert.Equal(t, "creating", reconcile().State)
+	server := f.servers["pgsql"]
+	assert.Equal(t, "Standard_D2ds_v4", server.SKU.Name)
+	assert.Equal(t, "GeneralPurpose", server.SKU.Tier)
+	assert.Equal(t, "13", server.Properties.Version)
+	assert.Equal(t, int64(64), server.Properties.Storage.StorageSizeGB)
+	assert.Equal(t, "westeurope", server.Location)
+	assert.Equal(t, "8f9c0b0e", server.Tags[actuators.UIDTag])
+	assert.Equal(t, "app", server.Properties.AdministratorLogin)
+	secret := &corev1.Secret{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-password"}, secret))
+	assert.Equal(t, string(secret.Data["password"]), f.passwords["pgsql"])
+	assert.NotEmpty(t, db.Status.PasswordHash)
+
+	// Then the database and the firewall
+	assert.Equal(t, "creating", reconcile().State)
+	f.advance()
+	assert.Equal(t, databasesv1.StateModifying, reconcile().State)
+	assert.Equal(t, []string{"app"}, f.databases["pgsql"])
+	assert.Equal(t, databasesv1.StateModifying, reconcile().State)
+	assert.Equal(t, FirewallRuleProperties{StartIPAddress: "203.0.113.0", EndIPAddress: "203.0.113.255"}, f.firewall["pgsql"]["kube-db-0"].Properties)
+
+	// The host name is exposed as an ExternalName service
+	status := reconcile()
+	assert.Equal(t, databasesv1.StateAvailable, status.State)
+	assert.Equal(t, "pgsql.postgres.database.azure.com", status.Address)
+	assert.Equal(t, int64(5432), status.Port)
+	assert.Equal(t, server.ID, status.ARN)
+	service := &corev1.Service{}
+	assert.NoError(t, client.Get(ctx, key, service))
+	assert.Equal(t, corev1.ServiceTypeExternalName, service.Spec.Type)
+	assert.Equal(t, "pgsql.postgres.database.azure.com", service.Spec.ExternalName)
+	connection := &corev1.Secret{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-connection"}, connection))
+	assert.Equal(t, "pgsql.postgres.database.azure.com", string(connection.Data["host"]))
+
+	// Spec changes patch the server, the rules of the actuator follow the spec
+	db.Spec.Class = "db.r5.xlarge"
+	db.Spec.Size = 10
+	db.Spec.PubliclyAccessible = true
+	assert.Equal(t, databasesv1.StateModifying, reconcile().State)
+	assert.Contains(t, f.firewall["pgsql"], "kube-db-public")
+	status = reconcile()
+	assert.Equal(t, databasesv1.StateModifying, status.State)
+	assert.Equal(t, []string{"class"}, status.Modifications)
+	assert.Equal(t, &SKU{Name: "Standard_E4ds_v4", Tier: "MemoryOptimized"}, server.SKU)
+	assert.Equal(t, int64(64), server.Properties.Storage.StorageSizeGB, "storage never shrinks")
+	f.advance()
+	assert.Equal(t, databasesv1.StateAvailable, reconcile().State)
+
+	// A new password is applied
+	secret.Data["password"] = []byte("rotated")
+	assert.NoError(t, client.Update(ctx, secret))
+	assert.Equal(t, databasesv1.StateModifying, reconcile().State)
+	assert.Equal(t, "rotated", f.passwords["pgsql"])
+	f.advance()
+
+	// Another object with the same name is refused
+	other := testDatabase()
+	other.UID = "other"
+	status, err := a.Reconcile(other, client, ctx, key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+
+	status, err = a.Delete(db, client, ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "deleting", status.State)
+	db.Status = status
+	f.advance()
+
+	status, err = a.Delete(db, client, ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.Error(t, client.Get(ctx, key, &corev1.Service{}))
+}
+
+func TestSnapshotPolicy(t *testing.T) {
+	f, arm, stop := newFakeARM()
+	defer stop()
+	a := &Actuator{log: zap.Logger(true), arm: arm}
+	client := testReconciler(t)
+	db := testDatabase()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicySnapshot
+
+	// Azure has no final snapshot, the policy is refused before anything gets created
+	status, err := a.Reconcile(db, client, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+	assert.True(t, databasesv1.IsConditionTrue(status.Conditions, databasesv1.ConditionDegraded))
+	assert.Empty(t, f.servers)
+
+	// and a server that got it afterwards is retained instead of blocking the deletion
+	f.servers["pgsql"] = &Server{Name: "pgsql", Tags: actuators.OwnerTags(db, a.options.ClusterID), Properties: &ServerProperties{State: "Ready"}}
+	status, err = a.Delete(db, client, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.Equal(t, "Ready", f.servers["pgsql"].Properties.State)
+}
+
+func TestDeleteInvalidEngine(t *testing.T) {
+	_, arm, stop := newFakeARM()
+	defer stop()
+	a := &Actuator{log: zap.Logger(true), arm: arm}
+	db := testDatabase()
+	db.Spec.Engine = "oracle-se2"
+
+	status, err := a.Delete(db, testReconciler(t), context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+}
+
+func TestReconcileUnsupported(t *testing.T) {
+	_, arm, stop := newFakeARM()
+	defer stop()
+	a := &Actuator{log: zap.Logger(true), arm: arm}
+	client := testReconciler(t)
+
+	db := testDatabase()
+	db.Spec.RestoreFrom = &databasesv1.RestoreSource{SourceIdentifier: "source"}
+	status, err := a.Reconcile(db, client, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+
+	db = testDatabase()
+	db.Spec.EngineVersion = "9.6"
+	status, err = a.Reconcile(db, client, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+}
@@ -0,0 +1,260 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultEndpoint is the base URL of Azure Resource Manager
+const DefaultEndpoint = "https://management.azure.com/"
+
+// Kind is the resource provider of the flexible servers of an engine, along its API version
+type Kind struct {
+	Namespace  string
+	APIVersion string
+}
+
+var (
+	// PostgreSQL flexible servers
+	PostgreSQL = Kind{Namespace: "Microsoft.DBforPostgreSQL", APIVersion: "2022-12-01"}
+	// MySQL flexible servers
+	MySQL = Kind{Namespace: "Microsoft.DBforMySQL", APIVersion: "2021-05-01"}
+)
+
+// Server is a flexible server, only the fields the actuator uses
+// https://learn.microsoft.com/en-us/rest/api/postgresql/flexibleserver/servers
+type Server struct {
+	ID         string            `json:"id,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Location   string            `json:"location,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	SKU        *SKU              `json:"sku,omitempty"`
+	Properties *ServerProperties `json:"properties,omitempty"`
+}
+
+// SKU is the compute of the server, the tier must match the name
+type SKU struct {
+	Name string `json:"name"`
+	Tier string `json:"tier"`
+}
+
+// ServerProperties are the settings and the observed state of a server
+type ServerProperties struct {
+	AdministratorLogin         string            `json:"administratorLogin,omitempty"`
+	AdministratorLoginPassword string            `json:"administratorLoginPassword,omitempty"`
+	Version                    string            `json:"version,omitempty"`
+	State                      string            `json:"state,omitempty"`
+	FullyQualifiedDomainName   string            `json:"fullyQualifiedDomainName,omitempty"`
+	AvailabilityZone           string            `json:"availabilityZone,omitempty"`
+	Storage                    *Storage          `json:"storage,omitempty"`
+	Backup                     *Backup           `json:"backup,omitempty"`
+	HighAvailability           *HighAvailability `json:"highAvailability,omitempty"`
+	Network                    *Network          `json:"network,omitempty"`
+}
+
+// Storage of the server
+type Storage struct {
+	StorageSizeGB int64 `json:"storageSizeGB,omitempty"`
+}
+
+// Backup retention of the server, 7 to 35 days
+type Backup struct {
+	BackupRetentionDays int64 `json:"backupRetentionDays,omitempty"`
+}
+
+// HighAvailability mode of the server, ZoneRedundant or Disabled
+type HighAvailability struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+// Network puts the server in a delegated subnet, PostgreSQL and MySQL name the DNS zone differently
+type Network struct {
+	DelegatedSubnetResourceID   string `json:"delegatedSubnetResourceId,omitempty"`
+	PrivateDNSZoneArmResourceID string `json:"privateDnsZoneArmResourceId,omitempty"`
+	PrivateDNSZoneResourceID    string `json:"privateDnsZoneResourceId,omitempty"`
+	PublicNetworkAccess         string `json:"publicNetworkAccess,omitempty"`
+}
+
+// Database is a database of a server
+type Database struct {
+	Name       string              `json:"name,omitempty"`
+	Properties *DatabaseProperties `json:"properties,omitempty"`
+}
+
+// DatabaseProperties is the encoding of a database
+type DatabaseProperties struct {
+	Charset   string `json:"charset,omitempty"`
+	Collation string `json:"collation,omitempty"`
+}
+
+// FirewallRule lets a range of public IPs reach a server
+type FirewallRule struct {
+	Name       string                 `json:"name,omitempty"`
+	Properties FirewallRuleProperties `json:"properties"`
+}
+
+// FirewallRuleProperties is the range of IPs of a rule, both ends included
+type FirewallRuleProperties struct {
+	StartIPAddress string `json:"startIpAddress"`
+	EndIPAddress   string `json:"endIpAddress"`
+}
+
+// Error is an error answered by Resource Manager
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("arm: %v %v: %v", e.StatusCode, e.Code, e.Message)
+}
+
+// IsNotFound is true when Resource Manager answered the resource does not exist
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// ARM is a client of the flexible servers of a resource group. The changes are asynchronous,
+// their progress is followed through the state of the servers
+type ARM struct {
+	// Endpoint is the base URL of Resource Manager, with a trailing slash
+	Endpoint       string
+	SubscriptionID string
+	ResourceGroup  string
+	HTTP           *http.Client
+}
+
+// GetServer returns the server, nil when it does not exist
+func (a *ARM) GetServer(ctx context.Context, kind Kind, name string) (*Server, error) {
+	server := &Server{}
+	err := a.do(ctx, http.MethodGet, kind, a.serverPath(kind, name), nil, server)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get server %v", name))
+	}
+	return server, nil
+}
+
+// PutServer creates the server
+func (a *ARM) PutServer(ctx context.Context, kind Kind, server *Server) error {
+	err := a.do(ctx, http.MethodPut, kind, a.serverPath(kind, server.Name), server, nil)
+	return errors.Wrap(err, fmt.Sprintf("unable to create server %v", server.Name))
+}
+
+// PatchServer applies the fields set in the server, the others are left alone
+func (a *ARM) PatchServer(ctx context.Context, kind Kind, name string, server *Server) error {
+	err := a.do(ctx, http.MethodPatch, kind, a.serverPath(kind, name), server, nil)
+	return errors.Wrap(err, fmt.Sprintf("unable to update server %v", name))
+}
+
+// DeleteServer deletes the server along its backups, a missing server is not an error
+func (a *ARM) DeleteServer(ctx context.Context, kind Kind, name string) error {
+	err := a.do(ctx, http.MethodDelete, kind, a.serverPath(kind, name), nil, nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return errors.Wrap(err, fmt.Sprintf("unable to delete server %v", name))
+}
+
+// GetDatabase returns the database of the server, nil when it does not exist
+func (a *ARM) GetDatabase(ctx context.Context, kind Kind, server string, name string) (*Database, error) {
+	database := &Database{}
+	err := a.do(ctx, http.MethodGet, kind, a.serverPath(kind, server)+"/databases/"+url.PathEscape(name), nil, database)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get database %v of server %v", name, server))
+	}
+	return database, nil
+}
+
+// PutDatabase creates a database in the server
+func (a *ARM) PutDatabase(ctx context.Context, kind Kind, server string, database *Database) error {
+	err := a.do(ctx, http.MethodPut, kind, a.serverPath(kind, server)+"/databases/"+url.PathEscape(database.Name), database, nil)
+	return errors.Wrap(err, fmt.Sprintf("unable to create database %v in server %v", database.Name, server))
+}
+
+// ListFirewallRules returns the firewall rules of the server
+func (a *ARM) ListFirewallRules(ctx context.Context, kind Kind, server string) ([]FirewallRule, error) {
+	list := &struct {
+		Value []FirewallRule `json:"value"`
+	}{}
+	err := a.do(ctx, http.MethodGet, kind, a.serverPath(kind, server)+"/firewallRules", nil, list)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to list the firewall rules of server %v", server))
+	}
+	return list.Value, nil
+}
+
+// PutFirewallRule creates or updates a firewall rule of the server
+func (a *ARM) PutFirewallRule(ctx context.Context, kind Kind, server string, rule *FirewallRule) error {
+	err := a.do(ctx, http.MethodPut, kind, a.serverPath(kind, server)+"/firewallRules/"+url.PathEscape(rule.Name), rule, nil)
+	return errors.Wrap(err, fmt.Sprintf("unable to set firewall rule %v of server %v", rule.Name, server))
+}
+
+// DeleteFirewallRule deletes a firewall rule of the server, a missing rule is not an error
+func (a *ARM) DeleteFirewallRule(ctx context.Context, kind Kind, server string, name string) error {
+	err := a.do(ctx, http.MethodDelete, kind, a.serverPath(kind, server)+"/firewallRules/"+url.PathEscape(name), nil, nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return errors.Wrap(err, fmt.Sprintf("unable to delete firewall rule %v of server %v", name, server))
+}
+
+func (a *ARM) serverPath(kind Kind, name string) string {
+	return fmt.Sprintf("subscriptions/%v/resourceGroups/%v/providers/%v/flexibleServers/%v",
+		a.SubscriptionID, a.ResourceGroup, kind.Namespace, url.PathEscape(name))
+}
+
+// do sends the request, decoding the answer into out and the errors into an *Error
+func (a *ARM) do(ctx context.Context, method string, kind Kind, path string, in interface{}, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, a.Endpoint+path+"?api-version="+kind.APIVersion, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		answer := &struct {
+			Error *Error `json:"error"`
+		}{}
+		if json.Unmarshal(data, answer) != nil || answer.Error == nil {
+			return &Error{StatusCode: res.StatusCode, Message: string(data)}
+		}
+		answer.Error.StatusCode = res.StatusCode
+		return answer.Error
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
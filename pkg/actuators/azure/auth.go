@@ -0,0 +1,89 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/microsoft"
+)
+
+const (
+	resource         = "https://management.azure.com/"
+	identityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// httpClient returns a client authenticated as the service principal of the client ID and
+// secret, or with the managed identity of the node when there is no secret
+func httpClient(ctx context.Context, tenantID string, clientID string, clientSecret string) *http.Client {
+	if clientSecret != "" {
+		config := &clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			TokenURL:     microsoft.AzureADEndpoint(tenantID).TokenURL,
+			Scopes:       []string{resource + ".default"},
+		}
+		return config.Client(ctx)
+	}
+	source := oauth2.ReuseTokenSource(nil, identityTokenSource{ctx: ctx, clientID: clientID})
+	return oauth2.NewClient(ctx, source)
+}
+
+// identityTokenSource fetches the tokens of the managed identity from the instance metadata
+// service, the client ID picks one when the node has several
+type identityTokenSource struct {
+	ctx      context.Context
+	clientID string
+}
+
+func (i identityTokenSource) Token() (*oauth2.Token, error) {
+	query := url.Values{"api-version": {"2018-02-01"}, "resource": {resource}}
+	if i.clientID != "" {
+		query.Set("client_id", i.clientID)
+	}
+	req, err := http.NewRequest(http.MethodGet, identityEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Do(req.WithContext(i.ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "no client secret and no managed identity")
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instance metadata service answered %v: %s", res.StatusCode, data)
+	}
+
+	// The expiration is a number sent as a string
+	answer := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+		TokenType   string `json:"token_type"`
+	}{}
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the token of the managed identity")
+	}
+	expiresOn, err := strconv.ParseInt(answer.ExpiresOn, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the expiration of the managed identity token")
+	}
+	return &oauth2.Token{
+		AccessToken: answer.AccessToken,
+		TokenType:   answer.TokenType,
+		Expiry:      time.Unix(expiresOn, 0),
+	}, nil
+}
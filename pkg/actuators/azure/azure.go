@@ -0,0 +1,75 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+// Actuator manages the Rds objects as Azure Database flexible servers
+type Actuator struct {
+	log     logr.Logger
+	arm     *ARM
+	options Options
+}
+
+// Options configures the actuator
+type Options struct {
+	// SubscriptionID and ResourceGroup hold the servers
+	SubscriptionID string
+	ResourceGroup  string
+	// Location of the servers, like westeurope
+	Location string
+	// TenantID, ClientID and ClientSecret of the service principal, the managed identity of the
+	// node when there is no secret. The client ID then picks the identity
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// Subnet is the resource ID of the subnet delegated to the servers, they get public access
+	// without one
+	Subnet string
+	// PrivateDNSZone is the resource ID of the private DNS zone of the servers in the subnet
+	PrivateDNSZone string
+	// AllowedCIDRs are the ranges allowed through the firewall of the servers with public access
+	AllowedCIDRs []string
+	// Endpoint of Resource Manager, DefaultEndpoint when empty
+	Endpoint string
+	// ClusterID tells apart the clusters sharing a subscription
+	ClusterID string
+	// IdentifierTemplate renders the names of new servers
+	IdentifierTemplate string
+}
+
+// NewActuator returns an actuator authenticated with the credentials of the options
+func NewActuator(log logr.Logger, options Options) (*Actuator, error) {
+	_, err := actuators.RenderIdentifier(options.IdentifierTemplate, actuators.IdentifierData{})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid instance identifier template")
+	}
+	if options.SubscriptionID == "" || options.ResourceGroup == "" || options.Location == "" {
+		return nil, fmt.Errorf("the subscription, the resource group and the location of the Azure servers are required")
+	}
+	for _, cidr := range options.AllowedCIDRs {
+		if _, _, err := cidrRange(cidr); err != nil {
+			return nil, err
+		}
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = DefaultEndpoint
+	}
+	log.Info("azure", "subscription", options.SubscriptionID, "resourceGroup", options.ResourceGroup, "location", options.Location, "subnet", options.Subnet)
+
+	return &Actuator{
+		log: log,
+		arm: &ARM{
+			Endpoint:       options.Endpoint,
+			SubscriptionID: options.SubscriptionID,
+			ResourceGroup:  options.ResourceGroup,
+			HTTP:           httpClient(context.Background(), options.TenantID, options.ClientID, options.ClientSecret),
+		},
+		options: options,
+	}, nil
+}
@@ -0,0 +1,303 @@
+package azure
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// Prefix of the firewall rules managed by the actuator, the other rules are left alone
+const firewallRulePrefix = "kube-db-"
+
+// Storage sizes of the PostgreSQL servers in GB, MySQL takes any size from 20GB
+var postgresStorageSizes = []int64{32, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768}
+
+// RDS classes, like db.m5.2xlarge
+var rdsClass = regexp.MustCompile(`^db\.([a-z]+)[0-9]+[a-z]*\.([0-9]*)(micro|small|medium|large|xlarge)$`)
+
+// serverName returns the name persisted in the status, rendering the template for objects that
+// were never created
+func (a *Actuator) serverName(db *databasesv1.Rds) string {
+	if db.Status.InstanceIdentifier != "" {
+		return db.Status.InstanceIdentifier
+	}
+	name, err := actuators.RenderIdentifier(a.options.IdentifierTemplate, actuators.IdentifierData{
+		ClusterID: a.options.ClusterID,
+		Namespace: db.Namespace,
+		Name:      db.Name,
+		UID:       string(db.UID),
+	})
+	if err != nil {
+		// The template is validated on start up
+		return actuators.SanitizeIdentifier(db.Name)
+	}
+	return name
+}
+
+// kindOf returns the resource provider of the engine
+func kindOf(engine string) (Kind, error) {
+	switch strings.ToLower(engine) {
+	case "postgres", "postgresql":
+		return PostgreSQL, nil
+	case "mysql":
+		return MySQL, nil
+	default:
+		return Kind{}, fmt.Errorf("engine %v is not supported by Azure, use postgres or mysql", engine)
+	}
+}
+
+// serverVersion maps the version of the spec to a version of flexible server
+func serverVersion(kind Kind, version string) (string, error) {
+	major := strings.SplitN(version, ".", 2)[0]
+	if kind == MySQL {
+		if version == "" || major == "8" {
+			return "8.0.21", nil
+		}
+		if strings.HasPrefix(version, "5.7") {
+			return "5.7", nil
+		}
+		return "", fmt.Errorf("mysql %v is not available on Azure, use 5.7 or 8.0", version)
+	}
+
+	if version == "" {
+		return "14", nil
+	}
+	n, err := strconv.Atoi(major)
+	if err != nil || n < 11 {
+		return "", fmt.Errorf("postgres %v is not available on Azure, use 11 or later", version)
+	}
+	return major, nil
+}
+
+// sku maps the class to a SKU. Azure names like Standard_D2ds_v4 are kept, RDS classes get
+// the SKU with as many vCPUs
+func sku(class string) (*SKU, error) {
+	if strings.HasPrefix(class, "Standard_") {
+		return &SKU{Name: class, Tier: tier(class)}, nil
+	}
+
+	match := rdsClass.FindStringSubmatch(class)
+	if match == nil {
+		return nil, fmt.Errorf("class %q is not an Azure SKU like Standard_D2ds_v4 nor an RDS class", class)
+	}
+	family, multiplier, size := match[1], match[2], match[3]
+
+	if family == "t" {
+		burstable := map[string]string{"micro": "B1ms", "small": "B1ms", "medium": "B2s", "large": "B2ms", "xlarge": "B4ms"}
+		name, ok := burstable[size]
+		if multiplier == "2" && size == "xlarge" {
+			name, ok = "B8ms", true
+		}
+		if !ok || (multiplier != "" && multiplier != "2") {
+			return nil, fmt.Errorf("class %v has no Azure burstable SKU", class)
+		}
+		return &SKU{Name: "Standard_" + name, Tier: "Burstable"}, nil
+	}
+
+	var cpus int
+	switch size {
+	case "large":
+		cpus = 2
+	case "xlarge":
+		cpus = 4
+		if multiplier != "" {
+			n, _ := strconv.Atoi(multiplier)
+			cpus *= n
+		}
+	default:
+		return nil, fmt.Errorf("class %v has no Azure SKU", class)
+	}
+	switch family {
+	case "m":
+		name := fmt.Sprintf("Standard_D%vds_v4", cpus)
+		return &SKU{Name: name, Tier: tier(name)}, nil
+	case "r", "x", "z":
+		name := fmt.Sprintf("Standard_E%vds_v4", cpus)
+		return &SKU{Name: name, Tier: tier(name)}, nil
+	default:
+		return nil, fmt.Errorf("class %v has no Azure SKU", class)
+	}
+}
+
+// tier of a SKU name
+func tier(name string) string {
+	switch {
+	case strings.HasPrefix(name, "Standard_B"):
+		return "Burstable"
+	case strings.HasPrefix(name, "Standard_E"):
+		return "MemoryOptimized"
+	default:
+		return "GeneralPurpose"
+	}
+}
+
+// storageSize is the size of the spec rounded up to a size the server accepts
+func storageSize(kind Kind, size int64) int64 {
+	if kind == MySQL {
+		if size < 20 {
+			return 20
+		}
+		return size
+	}
+	for _, s := range postgresStorageSizes {
+		if s >= size {
+			return s
+		}
+	}
+	return postgresStorageSizes[len(postgresStorageSizes)-1]
+}
+
+// backupRetention is the retention of the spec within the 7 to 35 days Azure keeps backups
+func backupRetention(days int64) int64 {
+	switch {
+	case days < 7:
+		return 7
+	case days > 35:
+		return 35
+	default:
+		return days
+	}
+}
+
+// server builds the server of the spec, without the credentials
+func (a *Actuator) server(db *databasesv1.Rds, kind Kind, name string) (*Server, error) {
+	version, err := serverVersion(kind, db.Spec.EngineVersion)
+	if err != nil {
+		return nil, err
+	}
+	s, err := sku(db.Spec.Class)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		Name:     name,
+		Location: a.options.Location,
+		Tags:     a.tags(db),
+		SKU:      s,
+		Properties: &ServerProperties{
+			Version:          version,
+			AvailabilityZone: db.Spec.AvailabilityZone,
+			Storage:          &Storage{StorageSizeGB: storageSize(kind, db.Spec.Size)},
+			Backup:           &Backup{BackupRetentionDays: backupRetention(db.Spec.BackupRetentionPeriod)},
+			HighAvailability: &HighAvailability{Mode: "Disabled"},
+		},
+	}
+	if db.Spec.MultiAZ {
+		server.Properties.HighAvailability.Mode = "ZoneRedundant"
+	}
+	if subnet := a.subnet(db); subnet != "" {
+		server.Properties.Network = &Network{DelegatedSubnetResourceID: subnet}
+		if kind == MySQL {
+			server.Properties.Network.PrivateDNSZoneResourceID = a.options.PrivateDNSZone
+		} else {
+			server.Properties.Network.PrivateDNSZoneArmResourceID = a.options.PrivateDNSZone
+		}
+	}
+	return server, nil
+}
+
+// subnet returns the delegated subnet of the spec, then the one of the options
+func (a *Actuator) subnet(db *databasesv1.Rds) string {
+	if len(db.Spec.SubnetIDs) > 0 {
+		return db.Spec.SubnetIDs[0]
+	}
+	return a.options.Subnet
+}
+
+// firewallRules returns the rules of a server with public access: the allowed ranges, and every
+// address when the spec asks for public access. Servers in a subnet have no firewall
+func (a *Actuator) firewallRules(db *databasesv1.Rds) []FirewallRule {
+	if a.subnet(db) != "" {
+		return nil
+	}
+	var rules []FirewallRule
+	for i, cidr := range a.options.AllowedCIDRs {
+		start, end, _ := cidrRange(cidr)
+		rules = append(rules, FirewallRule{
+			Name:       fmt.Sprintf("%v%v", firewallRulePrefix, i),
+			Properties: FirewallRuleProperties{StartIPAddress: start, EndIPAddress: end},
+		})
+	}
+	if db.Spec.PubliclyAccessible {
+		rules = append(rules, FirewallRule{
+			Name:       firewallRulePrefix + "public",
+			Properties: FirewallRuleProperties{StartIPAddress: "0.0.0.0", EndIPAddress: "255.255.255.255"},
+		})
+	}
+	return rules
+}
+
+// cidrRange returns the first and the last IPv4 addresses of the range
+func cidrRange(cidr string) (string, string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil || network.IP.To4() == nil {
+		return "", "", fmt.Errorf("invalid IPv4 range %q", cidr)
+	}
+	start := binary.BigEndian.Uint32(network.IP.To4())
+	end := start | ^binary.BigEndian.Uint32(net.IP(network.Mask).To4())
+
+	first, last := make(net.IP, 4), make(net.IP, 4)
+	binary.BigEndian.PutUint32(first, start)
+	binary.BigEndian.PutUint32(last, end)
+	return first.String(), last.String(), nil
+}
+
+// tags returns the tags of the spec along the ownership tags
+func (a *Actuator) tags(db *databasesv1.Rds) map[string]string {
+	tags := map[string]string{}
+	for k, v := range db.Spec.Tags {
+		tags[k] = v
+	}
+	for k, v := range actuators.OwnerTags(db, a.options.ClusterID) {
+		tags[k] = v
+	}
+	return tags
+}
+
+// state maps the state of the server to the states of RDS the conditions know
+func state(server *Server) string {
+	if server.Properties == nil {
+		return "creating"
+	}
+	switch server.Properties.State {
+	case "Ready":
+		return databasesv1.StateAvailable
+	case "", "Provisioning":
+		return "creating"
+	case "Updating":
+		return databasesv1.StateModifying
+	case "Starting":
+		return "starting"
+	case "Stopping":
+		return "stopping"
+	case "Stopped":
+		return "stopped"
+	case "Dropping":
+		return "deleting"
+	case "Disabled":
+		return "failed"
+	default:
+		return databasesv1.StateError
+	}
+}
+
+// port of the engine
+func port(kind Kind) int64 {
+	if kind == MySQL {
+		return 3306
+	}
+	return 5432
+}
+
+// verifyOwnership fails when the tags of the server do not name the object as its owner
+func (a *Actuator) verifyOwnership(db *databasesv1.Rds, server *Server) error {
+	owner := actuators.OwnerTags(db, a.options.ClusterID)
+	return actuators.VerifyOwnership(fmt.Sprintf("server %v", server.Name), server.Tags, owner, db)
+}
@@ -0,0 +1,96 @@
+package azure
+
+import (
+	"testing"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSKU(t *testing.T) {
+	cases := map[string]SKU{
+		"Standard_D4ds_v4": {Name: "Standard_D4ds_v4", Tier: "GeneralPurpose"},
+		"Standard_B2s":     {Name: "Standard_B2s", Tier: "Burstable"},
+		"db.t3.micro":      {Name: "Standard_B1ms", Tier: "Burstable"},
+		"db.t4g.medium":    {Name: "Standard_B2s", Tier: "Burstable"},
+		"db.t3.2xlarge":    {Name: "Standard_B8ms", Tier: "Burstable"},
+		"db.m5.large":      {Name: "Standard_D2ds_v4", Tier: "GeneralPurpose"},
+		"db.m6g.4xlarge":   {Name: "Standard_D16ds_v4", Tier: "GeneralPurpose"},
+		"db.r5.2xlarge":    {Name: "Standard_E8ds_v4", Tier: "MemoryOptimized"},
+	}
+	for class, expected := range cases {
+		s, err := sku(class)
+		assert.NoError(t, err, class)
+		assert.Equal(t, &expected, s, class)
+	}
+
+	for _, class := range []string{"db-custom-1-3840", "db.m5.medium", "db.c5.large"} {
+		_, err := sku(class)
+		assert.Error(t, err, class)
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	version, err := serverVersion(PostgreSQL, "12.7")
+	assert.NoError(t, err)
+	assert.Equal(t, "12", version)
+	_, err = serverVersion(PostgreSQL, "10.17")
+	assert.Error(t, err)
+
+	version, err = serverVersion(MySQL, "8.0.28")
+	assert.NoError(t, err)
+	assert.Equal(t, "8.0.21", version)
+	version, err = serverVersion(MySQL, "5.7.38")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.7", version)
+}
+
+func TestStorageSize(t *testing.T) {
+	assert.Equal(t, int64(32), storageSize(PostgreSQL, 20))
+	assert.Equal(t, int64(128), storageSize(PostgreSQL, 100))
+	assert.Equal(t, int64(32768), storageSize(PostgreSQL, 40000))
+	assert.Equal(t, int64(20), storageSize(MySQL, 5))
+	assert.Equal(t, int64(100), storageSize(MySQL, 100))
+}
+
+func TestCIDRRange(t *testing.T) {
+	start, end, err := cidrRange("10.1.2.0/23")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.1.2.0", start)
+	assert.Equal(t, "10.1.3.255", end)
+
+	_, _, err = cidrRange("2001:db8::/32")
+	assert.Error(t, err)
+}
+
+func TestNetwork(t *testing.T) {
+	a := &Actuator{options: Options{AllowedCIDRs: []string{"203.0.113.7/32"}, PrivateDNSZone: "zone"}}
+	db := testDatabase()
+	db.Spec.PubliclyAccessible = true
+
+	// Public access goes through the firewall
+	kind, _ := kindOf(db.Spec.Engine)
+	server, err := a.server(db, kind, "pgsql")
+	assert.NoError(t, err)
+	assert.Nil(t, server.Properties.Network)
+	rules := a.firewallRules(db)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "203.0.113.7", rules[0].Properties.EndIPAddress)
+
+	// Servers in a delegated subnet have no firewall
+	db.Spec.SubnetIDs = []string{"/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/virtualNetworks/v/subnets/db"}
+	db.Spec.Engine, db.Spec.EngineVersion = "mysql", "8.0"
+	server, err = a.server(db, MySQL, "pgsql")
+	assert.NoError(t, err)
+	assert.Equal(t, db.Spec.SubnetIDs[0], server.Properties.Network.DelegatedSubnetResourceID)
+	assert.Equal(t, "zone", server.Properties.Network.PrivateDNSZoneResourceID)
+	assert.Empty(t, a.firewallRules(db))
+}
+
+func TestState(t *testing.T) {
+	assert.Equal(t, databasesv1.StateAvailable, state(&Server{Properties: &ServerProperties{State: "Ready"}}))
+	assert.Equal(t, "creating", state(&Server{Properties: &ServerProperties{State: "Provisioning"}}))
+	assert.Equal(t, "deleting", state(&Server{Properties: &ServerProperties{State: "Dropping"}}))
+	assert.Equal(t, "failed", state(&Server{Properties: &ServerProperties{State: "Disabled"}}))
+	assert.Equal(t, databasesv1.StateError, state(&Server{Properties: &ServerProperties{State: "Unknown"}}))
+}
@@ -0,0 +1,213 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// fakeARM serves the flexible servers of a resource group. The changes leave the servers in their
+// transient states until advance is called
+type fakeARM struct {
+	mu        sync.Mutex
+	servers   map[string]*Server
+	passwords map[string]string
+	databases map[string][]string
+	firewall  map[string]map[string]FirewallRule
+}
+
+// newFakeARM returns the fake, a client of it and the function stopping it
+func newFakeARM() (*fakeARM, *ARM, func()) {
+	f := &fakeARM{
+		servers:   map[string]*Server{},
+		passwords: map[string]string{},
+		databases: map[string][]string{},
+		firewall:  map[string]map[string]FirewallRule{},
+	}
+	server := httptest.NewServer(f)
+	arm := &ARM{Endpoint: server.URL + "/", SubscriptionID: "subscription", ResourceGroup: "group", HTTP: server.Client()}
+	return f, arm, server.Close
+}
+
+// advance completes the pending changes
+func (f *fakeARM) advance() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, server := range f.servers {
+		switch server.Properties.State {
+		case "Provisioning", "Updating":
+			server.Properties.State = "Ready"
+		case "Dropping":
+			delete(f.servers, name)
+			delete(f.databases, name)
+			delete(f.firewall, name)
+		}
+	}
+}
+
+func (f *fakeARM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// subscriptions/S/resourceGroups/G/providers/NAMESPACE/flexibleServers/NAME/...
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) < 8 || path[1] != "subscription" || path[3] != "group" || path[6] != "flexibleServers" {
+		f.error(w, http.StatusNotFound, "InvalidResourceType", "not found")
+		return
+	}
+	kind := Kind{Namespace: path[5], APIVersion: r.URL.Query().Get("api-version")}
+	if kind != PostgreSQL && kind != MySQL {
+		f.error(w, http.StatusBadRequest, "NoRegisteredProviderFound", "unknown api version")
+		return
+	}
+	name := path[7]
+	server := f.servers[name]
+
+	if len(path) == 8 && r.Method == http.MethodPut {
+		f.putServer(w, r, kind, name)
+		return
+	}
+	if server == nil {
+		f.error(w, http.StatusNotFound, "ResourceNotFound", fmt.Sprintf("The Resource '%v/flexibleServers/%v' was not found.", kind.Namespace, name))
+		return
+	}
+
+	switch {
+	case len(path) == 8 && r.Method == http.MethodGet:
+		f.answer(w, http.StatusOK, server)
+	case len(path) == 8 && r.Method == http.MethodPatch:
+		f.patchServer(w, r, server)
+	case len(path) == 8 && r.Method == http.MethodDelete:
+		server.Properties.State = "Dropping"
+		w.WriteHeader(http.StatusAccepted)
+	case len(path) == 10 && path[8] == "databases":
+		f.serveDatabase(w, r, name, path[9])
+	case len(path) == 9 && path[8] == "firewallRules" && r.Method == http.MethodGet:
+		var rules []FirewallRule
+		for _, rule := range f.firewall[name] {
+			rules = append(rules, rule)
+		}
+		f.answer(w, http.StatusOK, map[string][]FirewallRule{"value": rules})
+	case len(path) == 10 && path[8] == "firewallRules" && r.Method == http.MethodPut:
+		rule := FirewallRule{}
+		if !f.decode(w, r, &rule) {
+			return
+		}
+		rule.Name = path[9]
+		f.firewall[name][rule.Name] = rule
+		w.WriteHeader(http.StatusAccepted)
+	case len(path) == 10 && path[8] == "firewallRules" && r.Method == http.MethodDelete:
+		if _, ok := f.firewall[name][path[9]]; !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		delete(f.firewall[name], path[9])
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		f.error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "method not allowed")
+	}
+}
+
+func (f *fakeARM) putServer(w http.ResponseWriter, r *http.Request, kind Kind, name string) {
+	server := &Server{}
+	if !f.decode(w, r, server) {
+		return
+	}
+	if f.servers[name] != nil {
+		f.error(w, http.StatusConflict, "ServerAlreadyExists", "Specified server name is already used.")
+		return
+	}
+	if server.Properties.AdministratorLogin == "" || server.Properties.AdministratorLoginPassword == "" {
+		f.error(w, http.StatusBadRequest, "InvalidParameterValue", "The administrator login and password are required.")
+		return
+	}
+	domain := "postgres.database.azure.com"
+	if kind == MySQL {
+		domain = "mysql.database.azure.com"
+	}
+	server.ID = "/subscriptions/subscription/resourceGroups/group/providers/" + kind.Namespace + "/flexibleServers/" + name
+	server.Name = name
+	server.Properties.State = "Provisioning"
+	server.Properties.FullyQualifiedDomainName = name + "." + domain
+	f.passwords[name] = server.Properties.AdministratorLoginPassword
+	server.Properties.AdministratorLoginPassword = ""
+	f.servers[name] = server
+	f.firewall[name] = map[string]FirewallRule{}
+	f.answer(w, http.StatusCreated, server)
+}
+
+func (f *fakeARM) patchServer(w http.ResponseWriter, r *http.Request, server *Server) {
+	patch := &Server{}
+	if !f.decode(w, r, patch) {
+		return
+	}
+	if patch.SKU != nil {
+		server.SKU = patch.SKU
+	}
+	if patch.Tags != nil {
+		server.Tags = patch.Tags
+	}
+	if p := patch.Properties; p != nil {
+		if p.AdministratorLoginPassword != "" {
+			f.passwords[server.Name] = p.AdministratorLoginPassword
+		}
+		if p.Storage != nil {
+			if p.Storage.StorageSizeGB < server.Properties.Storage.StorageSizeGB {
+				f.error(w, http.StatusBadRequest, "InvalidStorageSize", "The storage can't shrink.")
+				return
+			}
+			server.Properties.Storage = p.Storage
+		}
+		if p.Backup != nil {
+			server.Properties.Backup = p.Backup
+		}
+		if p.HighAvailability != nil {
+			server.Properties.HighAvailability = p.HighAvailability
+		}
+	}
+	server.Properties.State = "Updating"
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (f *fakeARM) serveDatabase(w http.ResponseWriter, r *http.Request, server string, name string) {
+	switch r.Method {
+	case http.MethodGet:
+		for _, database := range f.databases[server] {
+			if database == name {
+				f.answer(w, http.StatusOK, &Database{Name: name})
+				return
+			}
+		}
+		f.error(w, http.StatusNotFound, "ResourceNotFound", "The database was not found.")
+	case http.MethodPut:
+		database := &Database{}
+		if !f.decode(w, r, database) {
+			return
+		}
+		f.databases[server] = append(f.databases[server], name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		f.error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "method not allowed")
+	}
+}
+
+func (f *fakeARM) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		f.error(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+		return false
+	}
+	return true
+}
+
+func (f *fakeARM) answer(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeARM) error(w http.ResponseWriter, code int, armCode string, message string) {
+	f.answer(w, code, map[string]*Error{"error": {Code: armCode, Message: message}})
+}
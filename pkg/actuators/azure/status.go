@@ -0,0 +1,36 @@
+package azure
+
+import (
+	"context"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// observe completes the status returned by the actions with the server details and the
+// conditions, carrying over the fields and transition times already stored in the object
+func (a *Actuator) observe(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, status databasesv1.RdsStatus, err error) databasesv1.RdsStatus {
+	hasService := (&actuators.Kube{Client: client.Client}).HasService(ctx, db)
+	return actuators.Observe(db, status, err, hasService, func(observed *databasesv1.RdsStatus) {
+		kind, kerr := kindOf(db.Spec.Engine)
+		if db.Status.InstanceIdentifier == "" || kerr != nil {
+			return
+		}
+		server, serr := a.arm.GetServer(ctx, kind, db.Status.InstanceIdentifier)
+		if serr != nil {
+			a.log.Info("unable to get server", "name", db.Name, "error", serr)
+		} else if server == nil || server.Properties == nil {
+			observed.Address = ""
+			observed.Port = 0
+		} else {
+			observed.Address = server.Properties.FullyQualifiedDomainName
+			observed.Port = port(kind)
+			observed.ARN = server.ID
+			observed.EngineVersion = server.Properties.Version
+			if server.Properties.Storage != nil {
+				observed.AllocatedStorage = server.Properties.Storage.StorageSizeGB
+			}
+		}
+	})
+}
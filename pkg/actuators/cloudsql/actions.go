@@ -0,0 +1,345 @@
+package cloudsql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	status, err = a.reconcile(db, client, ctx)
+	return a.observe(db, client, ctx, status, err), err
+}
+
+func (a *Actuator) reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (status databasesv1.RdsStatus, err error) {
+	log := a.log.WithValues("reconcilingDatabase", db.Name)
+	kube := &actuators.Kube{Client: client.Client}
+
+	if err := unsupported(db); err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+	desired, err := a.settings(db)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// Persist the name before anything gets created, so it never changes afterwards
+	if db.Status.InstanceIdentifier == "" {
+		db.Status.InstanceIdentifier = a.instanceName(db)
+	}
+	name := db.Status.InstanceIdentifier
+
+	instance, err := a.sql.GetInstance(ctx, name)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// CREATE
+	if instance == nil {
+		version, err := databaseVersion(db.Spec.Engine, db.Spec.EngineVersion)
+		if err != nil {
+			return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+		}
+		password, err := kube.MasterPassword(ctx, db)
+		if err != nil {
+			return databasesv1.NewStatus("Failing Geting Secret", databasesv1.StatePending), err
+		}
+		log.Info("creating", "instance", name, "databaseVersion", version)
+		err = a.sql.InsertInstance(ctx, &DatabaseInstance{
+			Name:            name,
+			Region:          a.options.Region,
+			DatabaseVersion: version,
+			RootPassword:    password,
+			Settings:        desired,
+		})
+		if err != nil {
+			return databasesv1.NewStatus(err.Error(), databasesv1.StatePending), err
+		}
+		return databasesv1.NewStatus("Creating Database", "creating"), nil
+	}
+
+	// OWNERSHIP
+	// Never touch an instance created by someone else that happens to share the name
+	if err := a.verifyOwnership(db, instance); err != nil {
+		actuators.Event(client, db, corev1.EventTypeWarning, "Conflict", err.Error())
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateConflict), err
+	}
+
+	currentStatus := state(instance)
+	if currentStatus != databasesv1.StateAvailable {
+		return databasesv1.NewStatus("Database not in a reconcilable state, will wait", currentStatus), nil
+	}
+
+	// Cloud SQL runs one operation at a time per instance
+	running, err := a.runningOperation(ctx, name)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), currentStatus), err
+	}
+	if running != nil {
+		return databasesv1.NewStatus(fmt.Sprintf("Waiting for operation %v", strings.ToLower(running.OperationType)), databasesv1.StateModifying), nil
+	}
+
+	// DATABASE
+	database, err := a.sql.GetDatabase(ctx, name, db.Spec.DBName)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), currentStatus), err
+	}
+	if database == nil && db.Spec.DBName != "" {
+		log.Info("creating database", "dbname", db.Spec.DBName)
+		if err := a.sql.InsertDatabase(ctx, name, db.Spec.DBName); err != nil {
+			return databasesv1.NewStatus(err.Error(), currentStatus), err
+		}
+		return databasesv1.NewStatus(fmt.Sprintf("Creating database %v", db.Spec.DBName), databasesv1.StateModifying), nil
+	}
+
+	// USER
+	password, err := kube.MasterPassword(ctx, db)
+	if err != nil {
+		return databasesv1.NewStatus("Failing Geting Secret", currentStatus), err
+	}
+	if hash := actuators.PasswordHash(db, password); hash != db.Status.PasswordHash {
+		log.Info("applying password", "username", db.Spec.Username)
+		if err := a.reconcileUser(ctx, db, name, password); err != nil {
+			return databasesv1.NewStatus("Failing Reconciled Password", currentStatus), err
+		}
+		now := metav1.Now()
+		db.Status.PasswordHash = hash
+		db.Status.PasswordRotatedAt = &now
+		return databasesv1.NewStatus("Applying new master password", databasesv1.StateModifying), nil
+	}
+
+	// MODIFY
+	patch, changes := modifications(instance.Settings, desired)
+	if len(changes) > 0 {
+		log.Info("Modifying database", "changes", changes)
+		if err := a.sql.PatchInstance(ctx, name, patch); err != nil {
+			return databasesv1.NewStatus("Failed To Modify Database", currentStatus), err
+		}
+		status := databasesv1.NewStatus(fmt.Sprintf("Modifying %v", strings.Join(changes, ", ")), databasesv1.StateModifying)
+		status.Modifications = changes
+		return status, nil
+	}
+
+	// SERVICE
+	host := address(instance, db.Spec.PubliclyAccessible)
+	if host == "" {
+		return databasesv1.NewStatus("Waiting for endpoint to be available", currentStatus), nil
+	}
+	if err := kube.ReconcileService(ctx, db, host, int32(port(db.Spec.Engine))); err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Service", currentStatus), err
+	}
+
+	data := actuators.ConnectionData(db.Spec.Engine, db.Spec.Username, db.Spec.DBName, host, port(db.Spec.Engine), password)
+	if err := kube.ReconcileSecret(ctx, db, db.ConnectionSecret(), data); err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Connection Secret", currentStatus), err
+	}
+
+	return databasesv1.NewStatus("Database reconciled", currentStatus), nil
+}
+
+func (a *Actuator) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	status, err = a.delete(db, client, ctx)
+	return a.observe(db, client, ctx, status, err), err
+}
+
+func (a *Actuator) delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (status databasesv1.RdsStatus, err error) {
+	log := a.log.WithValues("delete", db.Name)
+	kube := &actuators.Kube{Client: client.Client}
+	policy := db.GetDeletionPolicy()
+	if err := policy.Validate(); err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// RETAIN
+	// Leave the instance at Google, only the kubernetes objects go away
+	if policy == databasesv1.DeletionPolicyRetain {
+		if err := kube.ReleasePassword(ctx, db); err != nil {
+			return databasesv1.NewStatus("ERROR Releasing password secret", db.Status.State), err
+		}
+		if err := kube.DeleteService(ctx, db); err != nil {
+			return databasesv1.NewStatus("ERROR Deleting svc", db.Status.State), err
+		}
+		return databasesv1.NewStatus("Database retained at Google Cloud", databasesv1.StateDeleted), nil
+	}
+
+	name := a.instanceName(db)
+	instance, err := a.sql.GetInstance(ctx, name)
+	if err != nil {
+		return databasesv1.NewStatus("Error Getting Status", db.Status.State), err
+	}
+
+	if instance != nil {
+		// OWNERSHIP
+		// The deletion is blocked until the policy is changed to Retain
+		if err := a.verifyOwnership(db, instance); err != nil {
+			actuators.Event(client, db, corev1.EventTypeWarning, "Conflict", err.Error())
+			return databasesv1.NewStatus(err.Error(), databasesv1.StateConflict), err
+		}
+
+		currentStatus := state(instance)
+		if currentStatus == "creating" || currentStatus == "deleting" {
+			return databasesv1.NewStatus("Database not in a deletable state, will wait", currentStatus), nil
+		}
+
+		// FINAL SNAPSHOT
+		// Backups go away along the instance, the final snapshot is an export to Cloud Storage
+		if policy == databasesv1.DeletionPolicySnapshot && db.Status.FinalSnapshotARN == "" && currentStatus == databasesv1.StateAvailable {
+			status, err := a.finalExport(db, client, ctx, name)
+			if err != nil || db.Status.FinalSnapshotARN == "" {
+				return status, err
+			}
+		}
+
+		running, err := a.runningOperation(ctx, name)
+		if err != nil {
+			return databasesv1.NewStatus(err.Error(), currentStatus), err
+		}
+		if running != nil {
+			return databasesv1.NewStatus(fmt.Sprintf("Waiting for operation %v", strings.ToLower(running.OperationType)), currentStatus), nil
+		}
+
+		log.Info("deleting instance", "instance", name, "deletionPolicy", policy)
+		if err := a.sql.DeleteInstance(ctx, name); err != nil {
+			return databasesv1.NewStatus(err.Error(), currentStatus), err
+		}
+		return databasesv1.NewStatus("Deleting", "deleting"), nil
+	}
+
+	if err := kube.DeleteService(ctx, db); err != nil {
+		return databasesv1.NewStatus("ERROR Deleting svc", databasesv1.StatePending), err
+	}
+
+	log.Info("Deletion of database done")
+	return databasesv1.NewStatus("Deleted", databasesv1.StateDeleted), nil
+}
+
+// finalExport exports the databases to the backup bucket and records the URI of the export once
+// it is done, failing when it failed so the finalizer is kept
+func (a *Actuator) finalExport(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, name string) (databasesv1.RdsStatus, error) {
+	if a.options.BackupBucket == "" {
+		err := fmt.Errorf("no backup bucket for the final snapshot of %v, set one or change the deletion policy", name)
+		actuators.Event(client, db, corev1.EventTypeWarning, "FinalSnapshotFailed", err.Error())
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateSnapshotFailed), err
+	}
+
+	uri := db.Status.FinalSnapshotIdentifier
+	if uri == "" {
+		uri = fmt.Sprintf("gs://%v/%v-final-%v.sql.gz", a.options.BackupBucket, name, a.now().UTC().Format("20060102150405"))
+		export := &ExportContext{URI: uri, FileType: "SQL"}
+		if db.Spec.DBName != "" {
+			export.Databases = []string{db.Spec.DBName}
+		}
+		if err := a.sql.ExportInstance(ctx, name, export); err != nil {
+			return databasesv1.NewStatus("Error Exporting Final Snapshot", databasesv1.StateAvailable), err
+		}
+		db.Status.FinalSnapshotIdentifier = uri
+		return databasesv1.NewStatus(fmt.Sprintf("Exporting final snapshot to %v", uri), "backing-up"), nil
+	}
+
+	operations, err := a.sql.ListOperations(ctx, name)
+	if err != nil {
+		return databasesv1.NewStatus("Error Getting Final Snapshot", "backing-up"), err
+	}
+	for _, operation := range operations {
+		if operation.OperationType != "EXPORT" || operation.ExportContext == nil || operation.ExportContext.URI != uri {
+			continue
+		}
+		if operation.Status != "DONE" {
+			return databasesv1.NewStatus(fmt.Sprintf("Waiting for final snapshot %v", uri), "backing-up"), nil
+		}
+		if operation.Error != nil {
+			err = fmt.Errorf("final snapshot %v failed: %v, remove the finalizer %v by hand once the data is safe", uri, operation.Error, databasesv1.RdsFinalizer)
+			actuators.Event(client, db, corev1.EventTypeWarning, "FinalSnapshotFailed", err.Error())
+			return databasesv1.NewStatus(err.Error(), databasesv1.StateSnapshotFailed), err
+		}
+		db.Status.FinalSnapshotARN = uri
+		actuators.Event(client, db, corev1.EventTypeNormal, "FinalSnapshot", fmt.Sprintf("Final snapshot available: %v", uri))
+		return databasesv1.NewStatus("Final snapshot available", databasesv1.StateAvailable), nil
+	}
+
+	// The operation may not be listed yet
+	return databasesv1.NewStatus(fmt.Sprintf("Waiting for final snapshot %v", uri), "backing-up"), nil
+}
+
+// reconcileUser creates the user of the spec or applies its password, MySQL users connect from any host
+func (a *Actuator) reconcileUser(ctx context.Context, db *databasesv1.Rds, name string, password string) error {
+	user := &User{Name: db.Spec.Username, Password: password}
+	if strings.ToLower(db.Spec.Engine) == "mysql" {
+		user.Host = "%"
+	}
+
+	users, err := a.sql.ListUsers(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Name == user.Name && u.Host == user.Host {
+			return a.sql.UpdateUser(ctx, name, user)
+		}
+	}
+	return a.sql.InsertUser(ctx, name, user)
+}
+
+// runningOperation returns an operation on the instance not done yet, nil when there is none
+func (a *Actuator) runningOperation(ctx context.Context, name string) (*Operation, error) {
+	operations, err := a.sql.ListOperations(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range operations {
+		if operations[i].Status != "DONE" {
+			return &operations[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// modifications returns the settings patching the instance to the spec and the fields changed.
+// Disks only grow
+func modifications(current *Settings, desired *Settings) (*Settings, []string) {
+	if current == nil {
+		current = &Settings{}
+	}
+	patch := &Settings{SettingsVersion: current.SettingsVersion}
+	var changes []string
+
+	if current.Tier != desired.Tier {
+		patch.Tier = desired.Tier
+		changes = append(changes, "class")
+	}
+	if current.DataDiskSizeGb < desired.DataDiskSizeGb {
+		patch.DataDiskSizeGb = desired.DataDiskSizeGb
+		changes = append(changes, "size")
+	}
+	if current.AvailabilityType != desired.AvailabilityType {
+		patch.AvailabilityType = desired.AvailabilityType
+		changes = append(changes, "multiaz")
+	}
+	if current.BackupConfiguration == nil || current.BackupConfiguration.Enabled != desired.BackupConfiguration.Enabled {
+		patch.BackupConfiguration = desired.BackupConfiguration
+		changes = append(changes, "backupRetentionPeriod")
+	}
+	if !reflect.DeepEqual(current.UserLabels, desired.UserLabels) {
+		patch.UserLabels = desired.UserLabels
+		changes = append(changes, "tags")
+	}
+	return patch, changes
+}
+
+// unsupported fails for the fields of the spec only RDS implements
+func unsupported(db *databasesv1.Rds) error {
+	var fields []string
+	if db.Spec.DBSnapshotIdentifier != "" || db.Spec.SnapshotRef != nil || db.Spec.SnapshotSelector != nil {
+		fields = append(fields, "snapshots")
+	}
+	fields = append(fields, actuators.AWSOnlyFields(db)...)
+	return actuators.Unsupported("gcloud", fields)
+}
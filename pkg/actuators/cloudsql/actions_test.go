@@ -0,0 +1,196 @@
+package cloudsql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func testReconciler(t *testing.T, objects ...runtime.Object) *controllers.RdsReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	return &controllers.RdsReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme, objects...),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func testDatabase() *databasesv1.Rds {
+	return &databasesv1.Rds{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default", UID: "8f9c0b0e"},
+		Spec: databasesv1.RdsSpec{
+			Class:            "db-custom-1-3840",
+			DBName:           "app",
+			Engine:           "postgres",
+			EngineVersion:    "11.5",
+			GeneratePassword: true,
+			Password:         corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "pgsql-password"}, Key: "password"},
+			Size:             20,
+			Username:         "app",
+		},
+	}
+}
+
+func TestReconcileAndDelete(t *testing.T) {
+	f, sql, stop := newFakeSQLAdmin()
+	defer stop()
+	a := &Actuator{
+		log:     zap.Logger(true),
+		sql:     sql,
+		options: Options{Project: "project", Region: "europe-west1", Network: "projects/project/global/networks/default", ClusterID: "cluster", IdentifierTemplate: "{{.Name}}"},
+		now:     func() time.Time { return time.Date(2019, 9, 1, 12, 0, 0, 0, time.UTC) },
+	}
+	client := testReconciler(t)
+	ctx := context.Background()
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	reconcile := func() databasesv1.RdsStatus {
+		status, err := a.Reconcile(db, client, ctx, key)
+		assert.NoError(t, err)
+		db.Status = status
+		return status
+	}
+	remove := func() (databasesv1.RdsStatus, error) {
+		status, err := a.Delete(db, client, ctx, key)
+		db.Status = status
+		return status, err
+	}
+
+	// The instance is created with the ownership labels and the generated password
+	assert.Equal(t, "creating", reconcile().State)
+	instance := f.instances["pgsql"]
+	assert.Equal(t, "POSTGRES_11", instance.DatabaseVersion)
+	assert.Equal(t, "europe-west1", instance.Region)
+	assert.Equal(t, "PD_SSD", instance.Settings.DataDiskType)
+	assert.False(t, instance.Settings.IPConfiguration.Ipv4Enabled)
+	assert.Equal(t, "x8f9c0b0e", instance.Settings.UserLabels[actuators.UIDTag])
+	assert.Equal(t, "pgsql", db.Status.InstanceIdentifier)
+	secret := &corev1.Secret{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-password"}, secret))
+	assert.NotEmpty(t, secret.Data["password"])
+
+	// Then the database, then the user, one operation at a time
+	assert.Equal(t, "creating", reconcile().State)
+	f.advance()
+	assert.Equal(t, databasesv1.StateModifying, reconcile().State)
+	assert.Equal(t, []string{"app"}, f.databases["pgsql"])
+	assert.Equal(t, databasesv1.StateModifying, reconcile().State, "waits for the database")
+	f.advance()
+	assert.Equal(t, databasesv1.StateModifying, reconcile().State)
+	assert.Equal(t, string(secret.Data["password"]), f.users["pgsql"][0].Password)
+	assert.NotEmpty(t, db.Status.PasswordHash)
+	f.advance()
+
+	// The private IP is exposed as a ClusterIP service with its endpoints
+	status := reconcile()
+	assert.Equal(t, databasesv1.StateAvailable, status.State)
+	assert.Equal(t, "10.1.0.3", status.Address)
+	assert.Equal(t, int64(5432), status.Port)
+	assert.Equal(t, int64(20), status.AllocatedStorage)
+	assert.Equal(t, instance.SelfLink, status.ARN)
+	service := &corev1.Service{}
+	assert.NoError(t, client.Get(ctx, key, service))
+	assert.Equal(t, corev1.ServiceTypeClusterIP, service.Spec.Type)
+	endpoints := &corev1.Endpoints{}
+	assert.NoError(t, client.Get(ctx, key, endpoints))
+	assert.Equal(t, "10.1.0.3", endpoints.Subsets[0].Addresses[0].IP)
+	connection := &corev1.Secret{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-connection"}, connection))
+	assert.Equal(t, "10.1.0.3", string(connection.Data["host"]))
+
+	// Spec changes patch the settings
+	db.Spec.Class = "db-custom-2-7680"
+	db.Spec.Size = 10
+	status = reconcile()
+	assert.Equal(t, databasesv1.StateModifying, status.State)
+	assert.Equal(t, []string{"class"}, status.Modifications)
+	assert.Equal(t, "db-custom-2-7680", instance.Settings.Tier)
+	assert.Equal(t, int64(20), instance.Settings.DataDiskSizeGb, "disks never shrink")
+	assert.Equal(t, int64(2), instance.Settings.SettingsVersion)
+	f.advance()
+	assert.Equal(t, databasesv1.StateAvailable, reconcile().State)
+
+	// Another object with the same name is refused
+	other := testDatabase()
+	other.UID = "other"
+	status, err := a.Reconcile(other, client, ctx, key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+
+	// The final export needs a bucket
+	status, err = remove()
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateSnapshotFailed, status.State)
+
+	a.options.BackupBucket = "backups"
+	status, err = remove()
+	assert.NoError(t, err)
+	assert.Equal(t, "backing-up", status.State)
+	assert.Equal(t, "gs://backups/pgsql-final-20190901120000.sql.gz", status.FinalSnapshotIdentifier)
+	status, err = remove()
+	assert.NoError(t, err)
+	assert.Equal(t, "backing-up", status.State, "waits for the export")
+	f.advance()
+
+	status, err = remove()
+	assert.NoError(t, err)
+	assert.Equal(t, "deleting", status.State)
+	assert.Equal(t, status.FinalSnapshotIdentifier, status.FinalSnapshotARN)
+	f.advance()
+
+	status, err = remove()
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.Error(t, client.Get(ctx, key, &corev1.Service{}))
+	assert.Error(t, client.Get(ctx, key, &corev1.Endpoints{}))
+}
+
+func TestReconcileUnsupported(t *testing.T) {
+	_, sql, stop := newFakeSQLAdmin()
+	defer stop()
+	a := &Actuator{log: zap.Logger(true), sql: sql, now: time.Now}
+	client := testReconciler(t)
+
+	db := testDatabase()
+	db.Spec.DBSnapshotIdentifier = "snapshot"
+	status, err := a.Reconcile(db, client, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+
+	db = testDatabase()
+	db.Spec.Class = "db.t3.micro"
+	status, err = a.Reconcile(db, client, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Contains(t, status.Message, "db-custom-1-3840")
+}
+
+func TestRetain(t *testing.T) {
+	f, sql, stop := newFakeSQLAdmin()
+	defer stop()
+	a := &Actuator{log: zap.Logger(true), sql: sql, now: time.Now}
+	db := testDatabase()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicyRetain
+	f.instances["pgsql"] = &DatabaseInstance{Name: "pgsql", State: "RUNNABLE"}
+
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default"}}
+	client := testReconciler(t, service)
+	status, err := a.Delete(db, client, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.NotNil(t, f.instances["pgsql"], "the instance is left alone")
+	assert.Error(t, client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "pgsql"}, &corev1.Service{}))
+}
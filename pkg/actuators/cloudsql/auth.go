@@ -0,0 +1,114 @@
+package cloudsql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	scope          = "https://www.googleapis.com/auth/sqlservice.admin"
+	metadataServer = "http://metadata.google.internal/computeMetadata/v1/"
+)
+
+// serviceAccountKey is the JSON key file of a service account
+type serviceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// httpClient returns a client authenticated with the key file of a service account, or with the
+// service account of the node (or the workload identity on GKE) from the metadata server.
+// Also returns the project of the credentials
+func httpClient(ctx context.Context, credentialsFile string) (*http.Client, string, error) {
+	if credentialsFile == "" {
+		project, err := metadata(ctx, "project/project-id")
+		if err != nil {
+			return nil, "", errors.Wrap(err, "no credentials file and no metadata server")
+		}
+		source := oauth2.ReuseTokenSource(nil, metadataTokenSource{ctx: ctx})
+		return oauth2.NewClient(ctx, source), project, nil
+	}
+
+	data, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to read the credentials file")
+	}
+	key := serviceAccountKey{}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, "", errors.Wrap(err, "unable to parse the credentials file")
+	}
+	if key.Type != "service_account" {
+		return nil, "", fmt.Errorf("credentials of type %q are not supported, use a service account key", key.Type)
+	}
+	config := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{scope},
+		TokenURL:     key.TokenURI,
+	}
+	if config.TokenURL == "" {
+		config.TokenURL = "https://oauth2.googleapis.com/token"
+	}
+	return config.Client(ctx), key.ProjectID, nil
+}
+
+// metadataTokenSource fetches the tokens of the default service account from the metadata server
+type metadataTokenSource struct {
+	ctx context.Context
+}
+
+func (m metadataTokenSource) Token() (*oauth2.Token, error) {
+	data, err := metadata(m.ctx, "instance/service-accounts/default/token")
+	if err != nil {
+		return nil, err
+	}
+	answer := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}{}
+	if err := json.Unmarshal([]byte(data), &answer); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the token of the metadata server")
+	}
+	return &oauth2.Token{
+		AccessToken: answer.AccessToken,
+		TokenType:   answer.TokenType,
+		Expiry:      time.Now().Add(time.Duration(answer.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func metadata(ctx context.Context, path string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, metadataServer+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server answered %v for %v", res.StatusCode, path)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
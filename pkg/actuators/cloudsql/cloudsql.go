@@ -0,0 +1,73 @@
+package cloudsql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+// Actuator manages the Rds objects as Cloud SQL instances
+type Actuator struct {
+	log     logr.Logger
+	sql     *SQLAdmin
+	options Options
+	now     func() time.Time
+}
+
+// Options configures the actuator
+type Options struct {
+	// Project of the instances, the project of the credentials when empty
+	Project string
+	// Region of the instances
+	Region string
+	// Network is the VPC of the private IPs, as projects/PROJECT/global/networks/NAME. Instances
+	// without it get a public IP
+	Network string
+	// BackupBucket is the Cloud Storage bucket receiving the final exports
+	BackupBucket string
+	// CredentialsFile is the key file of a service account, the metadata server when empty
+	CredentialsFile string
+	// Endpoint of the Cloud SQL Admin API, DefaultEndpoint when empty
+	Endpoint string
+	// ClusterID tells apart the clusters sharing a project
+	ClusterID string
+	// IdentifierTemplate renders the names of new instances
+	IdentifierTemplate string
+}
+
+// NewActuator returns an actuator authenticated with the credentials of the options
+func NewActuator(log logr.Logger, options Options) (*Actuator, error) {
+	_, err := actuators.RenderIdentifier(options.IdentifierTemplate, actuators.IdentifierData{})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid instance identifier template")
+	}
+	if options.Region == "" {
+		return nil, fmt.Errorf("the region of the Cloud SQL instances is required")
+	}
+
+	client, project, err := httpClient(context.Background(), options.CredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	if options.Project == "" {
+		options.Project = project
+	}
+	if options.Project == "" {
+		return nil, fmt.Errorf("the project of the Cloud SQL instances is required")
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = DefaultEndpoint
+	}
+	log.Info("cloud sql", "project", options.Project, "region", options.Region, "network", options.Network)
+
+	return &Actuator{
+		log:     log,
+		sql:     &SQLAdmin{Endpoint: options.Endpoint, Project: options.Project, HTTP: client},
+		options: options,
+		now:     time.Now,
+	}, nil
+}
@@ -0,0 +1,214 @@
+package cloudsql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// Label keys and values: lowercase letters, digits, underscores and hyphens, up to 63 characters,
+// starting with a letter
+const maxLabelLength = 63
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// Database versions already in the Cloud SQL form, like POSTGRES_11
+var cloudSQLVersion = regexp.MustCompile(`^(POSTGRES|MYSQL)_[0-9_]+$`)
+
+// instanceName returns the name persisted in the status, rendering the template for objects that
+// were never created
+func (a *Actuator) instanceName(db *databasesv1.Rds) string {
+	if db.Status.InstanceIdentifier != "" {
+		return db.Status.InstanceIdentifier
+	}
+	name, err := actuators.RenderIdentifier(a.options.IdentifierTemplate, actuators.IdentifierData{
+		ClusterID: a.options.ClusterID,
+		Namespace: db.Namespace,
+		Name:      db.Name,
+		UID:       string(db.UID),
+	})
+	if err != nil {
+		// The template is validated on start up
+		return actuators.SanitizeIdentifier(db.Name)
+	}
+	return name
+}
+
+// Database versions used when the spec has no engine version
+var defaultVersions = map[string]string{
+	"postgres": "POSTGRES_9_6",
+	"mysql":    "MYSQL_5_7",
+}
+
+// databaseVersion maps the engine and its version to a Cloud SQL database version, failing for the
+// versions Cloud SQL does not offer
+func databaseVersion(engine string, version string) (string, error) {
+	engine = strings.ToLower(engine)
+	if engine == "postgresql" {
+		engine = "postgres"
+	}
+	if _, ok := defaultVersions[engine]; !ok {
+		return "", fmt.Errorf("engine %v is not supported by Cloud SQL, use postgres or mysql", engine)
+	}
+	if cloudSQLVersion.MatchString(version) {
+		return version, nil
+	}
+	if version == "" {
+		return defaultVersions[engine], nil
+	}
+
+	parts := strings.SplitN(version, ".", 3)
+	major, err := strconv.Atoi(parts[0])
+	minor := -1
+	if err == nil && len(parts) > 1 {
+		minor, err = strconv.Atoi(parts[1])
+	}
+	if err != nil {
+		return "", fmt.Errorf("engine version %q of %v is not a valid version", version, engine)
+	}
+
+	switch {
+	case engine == "postgres" && major >= 10:
+		return fmt.Sprintf("POSTGRES_%v", major), nil
+	case engine == "postgres" && major == 9 && minor == 6:
+		return "POSTGRES_9_6", nil
+	case engine == "mysql" && major == 8 && minor <= 0:
+		return "MYSQL_8_0", nil
+	case engine == "mysql" && major == 5 && minor == 7:
+		return "MYSQL_5_7", nil
+	}
+	return "", fmt.Errorf("engine version %v of %v is not available in Cloud SQL", version, engine)
+}
+
+// settings builds the settings of the instance from the spec
+func (a *Actuator) settings(db *databasesv1.Rds) (*Settings, error) {
+	if db.Spec.Class == "" || strings.HasPrefix(db.Spec.Class, "db.") {
+		return nil, fmt.Errorf("class %q is not a Cloud SQL tier, use one like db-custom-1-3840", db.Spec.Class)
+	}
+
+	settings := &Settings{
+		Tier:                db.Spec.Class,
+		DataDiskSizeGb:      diskSize(db),
+		DataDiskType:        "PD_SSD",
+		AvailabilityType:    "ZONAL",
+		UserLabels:          a.labels(db),
+		BackupConfiguration: &BackupConfiguration{Enabled: db.Spec.BackupRetentionPeriod > 0},
+		IPConfiguration: &IPConfiguration{
+			Ipv4Enabled:    db.Spec.PubliclyAccessible || a.options.Network == "",
+			PrivateNetwork: a.options.Network,
+		},
+	}
+	if db.Spec.StorageType == "standard" {
+		settings.DataDiskType = "PD_HDD"
+	}
+	if db.Spec.MultiAZ {
+		settings.AvailabilityType = "REGIONAL"
+	}
+	if db.Spec.AvailabilityZone != "" {
+		settings.LocationPreference = &LocationPreference{Zone: db.Spec.AvailabilityZone}
+	}
+	return settings, nil
+}
+
+// diskSize is the size of the spec, Cloud SQL disks have 10GB at least
+func diskSize(db *databasesv1.Rds) int64 {
+	if db.Spec.Size < 10 {
+		return 10
+	}
+	return db.Spec.Size
+}
+
+// labels returns the tags of the spec made valid labels, along the ownership labels
+func (a *Actuator) labels(db *databasesv1.Rds) map[string]string {
+	labels := map[string]string{}
+	for k, v := range db.Spec.Tags {
+		if key := sanitizeLabel(k); key != "" {
+			labels[key] = sanitizeLabel(v)
+		}
+	}
+	for k, v := range a.ownerLabels(db) {
+		labels[k] = v
+	}
+	return labels
+}
+
+func (a *Actuator) ownerLabels(db *databasesv1.Rds) map[string]string {
+	labels := map[string]string{}
+	for k, v := range actuators.OwnerTags(db, a.options.ClusterID) {
+		labels[k] = sanitizeLabel(v)
+	}
+	return labels
+}
+
+// verifyOwnership fails when the labels of the instance do not name the object as its owner
+func (a *Actuator) verifyOwnership(db *databasesv1.Rds, instance *DatabaseInstance) error {
+	var labels map[string]string
+	if instance.Settings != nil {
+		labels = instance.Settings.UserLabels
+	}
+	return actuators.VerifyOwnership(fmt.Sprintf("instance %v", instance.Name), labels, a.ownerLabels(db), db)
+}
+
+// sanitizeLabel makes the value a valid label key or value. Values not starting with a letter,
+// like most UIDs, get an "x" prefix
+func sanitizeLabel(s string) string {
+	s = invalidLabelChars.ReplaceAllString(strings.ToLower(s), "_")
+	if s != "" && (s[0] < 'a' || s[0] > 'z') {
+		s = "x" + s
+	}
+	if len(s) > maxLabelLength {
+		s = s[:maxLabelLength]
+	}
+	return s
+}
+
+// state maps the state of the instance to the states of RDS the conditions know
+func state(instance *DatabaseInstance) string {
+	switch instance.State {
+	case "RUNNABLE":
+		if instance.Settings != nil && instance.Settings.ActivationPolicy == "NEVER" {
+			return "stopped"
+		}
+		return databasesv1.StateAvailable
+	case "PENDING_CREATE":
+		return "creating"
+	case "MAINTENANCE":
+		return "maintenance"
+	case "FAILED", "SUSPENDED":
+		return "failed"
+	case "PENDING_DELETE":
+		return "deleting"
+	default:
+		return databasesv1.StateError
+	}
+}
+
+// port of the engine, Cloud SQL does not let it change
+func port(engine string) int64 {
+	if strings.ToLower(engine) == "mysql" {
+		return 3306
+	}
+	return 5432
+}
+
+// address returns the private IP of the instance, the public one when it is publicly accessible
+// or has no private IP
+func address(instance *DatabaseInstance, public bool) string {
+	var primary, private string
+	for _, ip := range instance.IPAddresses {
+		switch ip.Type {
+		case "PRIMARY":
+			primary = ip.IPAddress
+		case "PRIVATE":
+			private = ip.IPAddress
+		}
+	}
+	if public || private == "" {
+		return primary
+	}
+	return private
+}
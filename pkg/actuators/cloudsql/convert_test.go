@@ -0,0 +1,84 @@
+package cloudsql
+
+import (
+	"strings"
+	"testing"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseVersion(t *testing.T) {
+	cases := []struct {
+		engine, version, expected string
+	}{
+		{"postgres", "11.5", "POSTGRES_11"},
+		{"postgres", "12", "POSTGRES_12"},
+		{"postgres", "9.6.15", "POSTGRES_9_6"},
+		{"postgres", "", "POSTGRES_9_6"},
+		{"mysql", "5.7.26", "MYSQL_5_7"},
+		{"mysql", "8.0.16", "MYSQL_8_0"},
+		{"mysql", "", "MYSQL_5_7"},
+		{"postgres", "POSTGRES_10", "POSTGRES_10"},
+	}
+	for _, c := range cases {
+		version, err := databaseVersion(c.engine, c.version)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, version, c.engine+" "+c.version)
+	}
+
+	_, err := databaseVersion("oracle-ee", "12.1")
+	assert.Error(t, err)
+
+	// Versions Cloud SQL does not offer are refused instead of replaced
+	for _, c := range [][]string{{"mysql", "5.6.44"}, {"postgres", "9.5.19"}, {"postgres", "latest"}, {"mysql", "8.1"}} {
+		_, err := databaseVersion(c[0], c[1])
+		assert.Error(t, err, c[0]+" "+c[1])
+	}
+}
+
+func TestSanitizeLabel(t *testing.T) {
+	assert.Equal(t, "cost_center", sanitizeLabel("Cost Center"))
+	assert.Equal(t, "x8f9c0b0e", sanitizeLabel("8f9c0b0e"))
+	assert.Equal(t, "x_internal", sanitizeLabel("_internal"))
+	assert.Equal(t, "", sanitizeLabel(""))
+	assert.Len(t, sanitizeLabel("1"+strings.Repeat("a", 70)), 63)
+}
+
+func TestSettings(t *testing.T) {
+	a := &Actuator{options: Options{ClusterID: "Prod.EU"}}
+	db := testDatabase()
+	db.Spec.Size = 5
+	db.Spec.MultiAZ = true
+	db.Spec.StorageType = "standard"
+	db.Spec.BackupRetentionPeriod = 7
+	db.Spec.AvailabilityZone = "europe-west1-b"
+	db.Spec.Tags = map[string]string{"Cost Center": "R&D"}
+
+	settings, err := a.settings(db)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), settings.DataDiskSizeGb)
+	assert.Equal(t, "PD_HDD", settings.DataDiskType)
+	assert.Equal(t, "REGIONAL", settings.AvailabilityType)
+	assert.True(t, settings.BackupConfiguration.Enabled)
+	assert.True(t, settings.IPConfiguration.Ipv4Enabled, "public without network")
+	assert.Equal(t, "europe-west1-b", settings.LocationPreference.Zone)
+	assert.Equal(t, "r_d", settings.UserLabels["cost_center"])
+	assert.Equal(t, "prod_eu", settings.UserLabels[actuators.ClusterIDTag])
+}
+
+func TestState(t *testing.T) {
+	assert.Equal(t, databasesv1.StateAvailable, state(&DatabaseInstance{State: "RUNNABLE"}))
+	assert.Equal(t, "stopped", state(&DatabaseInstance{State: "RUNNABLE", Settings: &Settings{ActivationPolicy: "NEVER"}}))
+	assert.Equal(t, "creating", state(&DatabaseInstance{State: "PENDING_CREATE"}))
+	assert.Equal(t, "failed", state(&DatabaseInstance{State: "SUSPENDED"}))
+	assert.Equal(t, databasesv1.StateError, state(&DatabaseInstance{State: "UNKNOWN_STATE"}))
+}
+
+func TestAddress(t *testing.T) {
+	instance := &DatabaseInstance{IPAddresses: []IPMapping{{IPAddress: "34.1.0.3", Type: "PRIMARY"}, {IPAddress: "10.1.0.3", Type: "PRIVATE"}}}
+	assert.Equal(t, "10.1.0.3", address(instance, false))
+	assert.Equal(t, "34.1.0.3", address(instance, true))
+	assert.Equal(t, "34.1.0.3", address(&DatabaseInstance{IPAddresses: instance.IPAddresses[:1]}, false))
+}
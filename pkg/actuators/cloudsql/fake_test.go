@@ -0,0 +1,220 @@
+package cloudsql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// fakeSQLAdmin serves the parts of the Cloud SQL Admin API the actuator uses. The operations
+// keep running and the instances keep their transient states until advance is called
+type fakeSQLAdmin struct {
+	mu         sync.Mutex
+	project    string
+	instances  map[string]*DatabaseInstance
+	databases  map[string][]string
+	users      map[string][]User
+	operations map[string][]Operation
+	ops        int
+}
+
+// newFakeSQLAdmin returns the fake, a client of it and the function stopping it
+func newFakeSQLAdmin() (*fakeSQLAdmin, *SQLAdmin, func()) {
+	f := &fakeSQLAdmin{
+		project:    "project",
+		instances:  map[string]*DatabaseInstance{},
+		databases:  map[string][]string{},
+		users:      map[string][]User{},
+		operations: map[string][]Operation{},
+	}
+	server := httptest.NewServer(f)
+	return f, &SQLAdmin{Endpoint: server.URL + "/sql/v1beta4/", Project: f.project, HTTP: server.Client()}, server.Close
+}
+
+// advance completes the running operations and the pending states
+func (f *fakeSQLAdmin) advance() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, operations := range f.operations {
+		for i := range operations {
+			operations[i].Status = "DONE"
+		}
+		instance := f.instances[name]
+		switch {
+		case instance == nil:
+		case instance.State == "PENDING_CREATE":
+			instance.State = "RUNNABLE"
+		case instance.State == "PENDING_DELETE":
+			delete(f.instances, name)
+			delete(f.databases, name)
+			delete(f.users, name)
+		}
+	}
+}
+
+func (f *fakeSQLAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prefix := fmt.Sprintf("/sql/v1beta4/projects/%v/", f.project)
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		f.error(w, http.StatusForbidden, "wrong project")
+		return
+	}
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	switch {
+	case len(path) == 1 && path[0] == "operations" && r.Method == http.MethodGet:
+		operations := f.operations[r.URL.Query().Get("instance")]
+		f.answer(w, map[string][]Operation{"items": operations})
+	case len(path) == 1 && path[0] == "instances" && r.Method == http.MethodPost:
+		f.insertInstance(w, r)
+	case len(path) >= 2 && path[0] == "instances":
+		instance := f.instances[path[1]]
+		if instance == nil {
+			f.error(w, http.StatusNotFound, "The Cloud SQL instance does not exist.")
+			return
+		}
+		f.serveInstance(w, r, instance, path[2:])
+	default:
+		f.error(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (f *fakeSQLAdmin) insertInstance(w http.ResponseWriter, r *http.Request) {
+	instance := &DatabaseInstance{}
+	if !f.decode(w, r, instance) {
+		return
+	}
+	if f.instances[instance.Name] != nil {
+		f.error(w, http.StatusConflict, "The Cloud SQL instance already exists.")
+		return
+	}
+	instance.State = "PENDING_CREATE"
+	instance.Project = f.project
+	instance.SelfLink = fmt.Sprintf("https://sqladmin.googleapis.com/sql/v1beta4/projects/%v/instances/%v", f.project, instance.Name)
+	instance.IPAddresses = []IPMapping{{IPAddress: "10.1.0.3", Type: "PRIVATE"}}
+	if instance.Settings.IPConfiguration.Ipv4Enabled {
+		instance.IPAddresses = append(instance.IPAddresses, IPMapping{IPAddress: "34.1.0.3", Type: "PRIMARY"})
+	}
+	instance.Settings.SettingsVersion = 1
+	instance.RootPassword = ""
+	f.instances[instance.Name] = instance
+	f.operate(w, instance.Name, Operation{OperationType: "CREATE"})
+}
+
+func (f *fakeSQLAdmin) serveInstance(w http.ResponseWriter, r *http.Request, instance *DatabaseInstance, path []string) {
+	name := instance.Name
+	switch {
+	case len(path) == 0 && r.Method == http.MethodGet:
+		f.answer(w, instance)
+	case len(path) == 0 && r.Method == http.MethodDelete:
+		instance.State = "PENDING_DELETE"
+		f.operate(w, name, Operation{OperationType: "DELETE"})
+	case len(path) == 0 && r.Method == http.MethodPatch:
+		patch := &DatabaseInstance{}
+		if !f.decode(w, r, patch) {
+			return
+		}
+		if patch.Settings.SettingsVersion != instance.Settings.SettingsVersion {
+			f.error(w, http.StatusPreconditionFailed, "The settings version is stale.")
+			return
+		}
+		merged, _ := json.Marshal(patch.Settings)
+		_ = json.Unmarshal(merged, instance.Settings)
+		instance.Settings.SettingsVersion++
+		f.operate(w, name, Operation{OperationType: "UPDATE"})
+	case len(path) == 1 && path[0] == "export" && r.Method == http.MethodPost:
+		body := &struct {
+			ExportContext *ExportContext `json:"exportContext"`
+		}{}
+		if !f.decode(w, r, body) {
+			return
+		}
+		f.operate(w, name, Operation{OperationType: "EXPORT", ExportContext: body.ExportContext})
+	case len(path) == 1 && path[0] == "databases" && r.Method == http.MethodPost:
+		database := &Database{}
+		if !f.decode(w, r, database) {
+			return
+		}
+		f.databases[name] = append(f.databases[name], database.Name)
+		f.operate(w, name, Operation{OperationType: "CREATE_DATABASE"})
+	case len(path) == 2 && path[0] == "databases" && r.Method == http.MethodGet:
+		for _, database := range f.databases[name] {
+			if database == path[1] {
+				f.answer(w, &Database{Name: database, Instance: name})
+				return
+			}
+		}
+		f.error(w, http.StatusNotFound, "The database does not exist.")
+	case len(path) == 1 && path[0] == "users":
+		f.serveUsers(w, r, name)
+	default:
+		f.error(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (f *fakeSQLAdmin) serveUsers(w http.ResponseWriter, r *http.Request, name string) {
+	switch r.Method {
+	case http.MethodGet:
+		var users []User
+		for _, u := range f.users[name] {
+			users = append(users, User{Name: u.Name, Host: u.Host})
+		}
+		f.answer(w, map[string][]User{"items": users})
+	case http.MethodPost:
+		user := User{}
+		if !f.decode(w, r, &user) {
+			return
+		}
+		f.users[name] = append(f.users[name], user)
+		f.operate(w, name, Operation{OperationType: "CREATE_USER"})
+	case http.MethodPut:
+		user := User{}
+		if !f.decode(w, r, &user) {
+			return
+		}
+		for i, u := range f.users[name] {
+			if u.Name == r.URL.Query().Get("name") && u.Host == r.URL.Query().Get("host") {
+				f.users[name][i].Password = user.Password
+				f.operate(w, name, Operation{OperationType: "UPDATE_USER"})
+				return
+			}
+		}
+		f.error(w, http.StatusNotFound, "The user does not exist.")
+	default:
+		f.error(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// operate records a running operation on the instance and answers it
+func (f *fakeSQLAdmin) operate(w http.ResponseWriter, instance string, operation Operation) {
+	f.ops++
+	operation.Name = fmt.Sprintf("op-%v", f.ops)
+	operation.Status = "RUNNING"
+	operation.TargetID = instance
+	f.operations[instance] = append([]Operation{operation}, f.operations[instance]...)
+	f.answer(w, operation)
+}
+
+func (f *fakeSQLAdmin) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		f.error(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func (f *fakeSQLAdmin) answer(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeSQLAdmin) error(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]*Error{"error": {Code: code, Message: message}})
+}
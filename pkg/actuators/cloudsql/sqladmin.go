@@ -0,0 +1,279 @@
+package cloudsql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultEndpoint is the base URL of the Cloud SQL Admin API
+const DefaultEndpoint = "https://sqladmin.googleapis.com/sql/v1beta4/"
+
+// DatabaseInstance is a Cloud SQL instance, only the fields the actuator uses
+// https://cloud.google.com/sql/docs/postgres/admin-api/rest/v1beta4/instances
+type DatabaseInstance struct {
+	Name            string      `json:"name"`
+	Project         string      `json:"project,omitempty"`
+	Region          string      `json:"region,omitempty"`
+	DatabaseVersion string      `json:"databaseVersion,omitempty"`
+	State           string      `json:"state,omitempty"`
+	ConnectionName  string      `json:"connectionName,omitempty"`
+	SelfLink        string      `json:"selfLink,omitempty"`
+	RootPassword    string      `json:"rootPassword,omitempty"`
+	IPAddresses     []IPMapping `json:"ipAddresses,omitempty"`
+	Settings        *Settings   `json:"settings,omitempty"`
+}
+
+// Settings of an instance, sizes and versions are int64 sent as strings
+type Settings struct {
+	Tier                string               `json:"tier,omitempty"`
+	DataDiskSizeGb      int64                `json:"dataDiskSizeGb,string,omitempty"`
+	DataDiskType        string               `json:"dataDiskType,omitempty"`
+	AvailabilityType    string               `json:"availabilityType,omitempty"`
+	ActivationPolicy    string               `json:"activationPolicy,omitempty"`
+	SettingsVersion     int64                `json:"settingsVersion,string,omitempty"`
+	UserLabels          map[string]string    `json:"userLabels,omitempty"`
+	BackupConfiguration *BackupConfiguration `json:"backupConfiguration,omitempty"`
+	IPConfiguration     *IPConfiguration     `json:"ipConfiguration,omitempty"`
+	LocationPreference  *LocationPreference  `json:"locationPreference,omitempty"`
+}
+
+// BackupConfiguration enables the automated backups
+type BackupConfiguration struct {
+	Enabled bool `json:"enabled"`
+}
+
+// IPConfiguration tells whether the instance has a public IP and its private network
+type IPConfiguration struct {
+	Ipv4Enabled    bool   `json:"ipv4Enabled"`
+	PrivateNetwork string `json:"privateNetwork,omitempty"`
+}
+
+// LocationPreference places the instance in a zone
+type LocationPreference struct {
+	Zone string `json:"zone,omitempty"`
+}
+
+// IPMapping is an address of the instance, PRIMARY is the public one
+type IPMapping struct {
+	IPAddress string `json:"ipAddress"`
+	Type      string `json:"type"`
+}
+
+// Database is a database of an instance
+type Database struct {
+	Name     string `json:"name"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// User is a user of an instance, MySQL users have a host
+type User struct {
+	Name     string `json:"name"`
+	Host     string `json:"host,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// ExportContext describes an export of the instance to Cloud Storage
+type ExportContext struct {
+	URI       string   `json:"uri"`
+	FileType  string   `json:"fileType,omitempty"`
+	Databases []string `json:"databases,omitempty"`
+}
+
+// Operation is a long running operation on an instance, every change is one
+type Operation struct {
+	Name          string          `json:"name"`
+	OperationType string          `json:"operationType"`
+	Status        string          `json:"status"`
+	TargetID      string          `json:"targetId,omitempty"`
+	ExportContext *ExportContext  `json:"exportContext,omitempty"`
+	Error         *OperationError `json:"error,omitempty"`
+}
+
+// OperationError lists the errors of a failed operation
+type OperationError struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (e *OperationError) Error() string {
+	if len(e.Errors) == 0 {
+		return "operation failed"
+	}
+	return fmt.Sprintf("%v: %v", e.Errors[0].Code, e.Errors[0].Message)
+}
+
+// Error is an error answered by the API
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("sqladmin: %v %v", e.Code, e.Message)
+}
+
+// IsNotFound is true when the API answered the resource does not exist
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*Error)
+	return ok && e.Code == http.StatusNotFound
+}
+
+// SQLAdmin is a client of the Cloud SQL Admin REST API for a project
+type SQLAdmin struct {
+	// Endpoint is the base URL of the API, with a trailing slash
+	Endpoint string
+	Project  string
+	HTTP     *http.Client
+}
+
+// GetInstance returns the instance, nil when it does not exist
+func (s *SQLAdmin) GetInstance(ctx context.Context, name string) (*DatabaseInstance, error) {
+	instance := &DatabaseInstance{}
+	err := s.do(ctx, http.MethodGet, s.instancePath(name), nil, instance)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get instance %v", name))
+	}
+	return instance, nil
+}
+
+// InsertInstance creates the instance
+func (s *SQLAdmin) InsertInstance(ctx context.Context, instance *DatabaseInstance) error {
+	err := s.do(ctx, http.MethodPost, fmt.Sprintf("projects/%v/instances", s.Project), instance, &Operation{})
+	return errors.Wrap(err, fmt.Sprintf("unable to create instance %v", instance.Name))
+}
+
+// PatchInstance applies the settings to the instance, the other settings are left alone
+func (s *SQLAdmin) PatchInstance(ctx context.Context, name string, settings *Settings) error {
+	err := s.do(ctx, http.MethodPatch, s.instancePath(name), &DatabaseInstance{Settings: settings}, &Operation{})
+	return errors.Wrap(err, fmt.Sprintf("unable to patch instance %v", name))
+}
+
+// DeleteInstance deletes the instance along its backups, a missing instance is not an error
+func (s *SQLAdmin) DeleteInstance(ctx context.Context, name string) error {
+	err := s.do(ctx, http.MethodDelete, s.instancePath(name), nil, &Operation{})
+	if IsNotFound(err) {
+		return nil
+	}
+	return errors.Wrap(err, fmt.Sprintf("unable to delete instance %v", name))
+}
+
+// ExportInstance dumps the databases of the instance to a Cloud Storage object
+func (s *SQLAdmin) ExportInstance(ctx context.Context, name string, export *ExportContext) error {
+	body := map[string]*ExportContext{"exportContext": export}
+	err := s.do(ctx, http.MethodPost, s.instancePath(name)+"/export", body, &Operation{})
+	return errors.Wrap(err, fmt.Sprintf("unable to export instance %v to %v", name, export.URI))
+}
+
+// GetDatabase returns the database of the instance, nil when it does not exist
+func (s *SQLAdmin) GetDatabase(ctx context.Context, instance string, name string) (*Database, error) {
+	database := &Database{}
+	err := s.do(ctx, http.MethodGet, fmt.Sprintf("%v/databases/%v", s.instancePath(instance), url.PathEscape(name)), nil, database)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get database %v of instance %v", name, instance))
+	}
+	return database, nil
+}
+
+// InsertDatabase creates a database in the instance
+func (s *SQLAdmin) InsertDatabase(ctx context.Context, instance string, name string) error {
+	err := s.do(ctx, http.MethodPost, s.instancePath(instance)+"/databases", &Database{Name: name, Instance: instance}, &Operation{})
+	return errors.Wrap(err, fmt.Sprintf("unable to create database %v in instance %v", name, instance))
+}
+
+// ListUsers returns the users of the instance
+func (s *SQLAdmin) ListUsers(ctx context.Context, instance string) ([]User, error) {
+	list := &struct {
+		Items []User `json:"items"`
+	}{}
+	err := s.do(ctx, http.MethodGet, s.instancePath(instance)+"/users", nil, list)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to list the users of instance %v", instance))
+	}
+	return list.Items, nil
+}
+
+// InsertUser creates a user in the instance
+func (s *SQLAdmin) InsertUser(ctx context.Context, instance string, user *User) error {
+	err := s.do(ctx, http.MethodPost, s.instancePath(instance)+"/users", user, &Operation{})
+	return errors.Wrap(err, fmt.Sprintf("unable to create user %v in instance %v", user.Name, instance))
+}
+
+// UpdateUser changes the password of a user of the instance
+func (s *SQLAdmin) UpdateUser(ctx context.Context, instance string, user *User) error {
+	query := url.Values{"name": {user.Name}, "host": {user.Host}}
+	err := s.do(ctx, http.MethodPut, s.instancePath(instance)+"/users?"+query.Encode(), user, &Operation{})
+	return errors.Wrap(err, fmt.Sprintf("unable to update user %v of instance %v", user.Name, instance))
+}
+
+// ListOperations returns the recent operations on the instance, newest first
+func (s *SQLAdmin) ListOperations(ctx context.Context, instance string) ([]Operation, error) {
+	list := &struct {
+		Items []Operation `json:"items"`
+	}{}
+	query := url.Values{"instance": {instance}}
+	err := s.do(ctx, http.MethodGet, fmt.Sprintf("projects/%v/operations?%v", s.Project, query.Encode()), nil, list)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to list the operations of instance %v", instance))
+	}
+	return list.Items, nil
+}
+
+func (s *SQLAdmin) instancePath(name string) string {
+	return fmt.Sprintf("projects/%v/instances/%v", s.Project, url.PathEscape(name))
+}
+
+// do sends the request, decoding the answer into out and the errors into an *Error
+func (s *SQLAdmin) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, s.Endpoint+path, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		answer := &struct {
+			Error *Error `json:"error"`
+		}{}
+		if json.Unmarshal(data, answer) != nil || answer.Error == nil {
+			return &Error{Code: res.StatusCode, Message: string(data)}
+		}
+		answer.Error.Code = res.StatusCode
+		return answer.Error
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
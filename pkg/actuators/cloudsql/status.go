@@ -0,0 +1,35 @@
+package cloudsql
+
+import (
+	"context"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// observe completes the status returned by the actions with the instance details and the
+// conditions, carrying over the fields and transition times already stored in the object
+func (a *Actuator) observe(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, status databasesv1.RdsStatus, err error) databasesv1.RdsStatus {
+	hasService := (&actuators.Kube{Client: client.Client}).HasService(ctx, db)
+	return actuators.Observe(db, status, err, hasService, func(observed *databasesv1.RdsStatus) {
+		if db.Status.InstanceIdentifier == "" {
+			return
+		}
+		instance, ierr := a.sql.GetInstance(ctx, db.Status.InstanceIdentifier)
+		if ierr != nil {
+			a.log.Info("unable to get instance", "name", db.Name, "error", ierr)
+		} else if instance == nil {
+			observed.Address = ""
+			observed.Port = 0
+		} else {
+			observed.Address = address(instance, db.Spec.PubliclyAccessible)
+			observed.Port = port(db.Spec.Engine)
+			observed.ARN = instance.SelfLink
+			observed.EngineVersion = instance.DatabaseVersion
+			if instance.Settings != nil {
+				observed.AllocatedStorage = instance.Settings.DataDiskSizeGb
+			}
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package actuators
+
+import (
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// States where the endpoint keeps accepting connections
+var readyStates = []string{
+	databasesv1.StateAvailable,
+	databasesv1.StateModifying,
+	"backing-up",
+	"configuring-enhanced-monitoring",
+	"configuring-log-exports",
+	"storage-optimization",
+}
+
+var provisioningStates = []string{
+	databasesv1.StatePending,
+	"creating",
+}
+
+var modifyingStates = []string{
+	databasesv1.StateModifying,
+	"converting-to-vpc",
+	"maintenance",
+	"moving-to-vpc",
+	"rebooting",
+	"renaming",
+	"resetting-master-credentials",
+	"starting",
+	"stopping",
+	"storage-optimization",
+	"upgrading",
+}
+
+var degradedStates = []string{
+	databasesv1.StateError,
+	"failed",
+	"inaccessible-encryption-credentials",
+	"incompatible-credentials",
+	"incompatible-network",
+	"incompatible-option-group",
+	"incompatible-parameters",
+	"incompatible-restore",
+	"restore-error",
+	"storage-full",
+	databasesv1.StateSnapshotFailed,
+	databasesv1.StateConflict,
+}
+
+// Conditions updates the conditions of any object reporting the provider states, the providers
+// other than AWS report the states of RDS matching theirs
+func Conditions(conditions []databasesv1.Condition, state string, message string, o metav1.Object, err error) []databasesv1.Condition {
+	return DatabaseConditions(conditions, state, message, o, err, true)
+}
+
+// DatabaseConditions are the Conditions of a database, which is not Ready until the service
+// clients connect through exists
+func DatabaseConditions(conditions []databasesv1.Condition, state string, message string, o metav1.Object, err error, hasService bool) []databasesv1.Condition {
+	reason := conditionReason(state)
+	deleting := state == "deleting" || (o.GetDeletionTimestamp() != nil && state != databasesv1.StateDeleted)
+
+	set := func(conditionType databasesv1.ConditionType, value bool, reason string, message string) {
+		s := corev1.ConditionFalse
+		if value {
+			s = corev1.ConditionTrue
+		}
+		conditions = databasesv1.SetCondition(conditions, databasesv1.Condition{
+			Type:               conditionType,
+			Status:             s,
+			ObservedGeneration: o.GetGeneration(),
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	ready := util.Contains(readyStates, state) && !deleting
+	if ready && !hasService {
+		set(databasesv1.ConditionReady, false, "WaitingForService", "Waiting for the service of the database")
+	} else {
+		set(databasesv1.ConditionReady, ready, reason, message)
+	}
+	set(databasesv1.ConditionProvisioning, util.Contains(provisioningStates, state) && !deleting, reason, message)
+	set(databasesv1.ConditionModifying, util.Contains(modifyingStates, state), reason, message)
+	set(databasesv1.ConditionDeleting, deleting, reason, message)
+	set(databasesv1.ConditionConflict, state == databasesv1.StateConflict, reason, message)
+
+	if err != nil {
+		errReason := "ReconcileError"
+		if util.Contains(degradedStates, state) {
+			errReason = reason
+		}
+		set(databasesv1.ConditionDegraded, true, errReason, err.Error())
+	} else {
+		set(databasesv1.ConditionDegraded, util.Contains(degradedStates, state), reason, message)
+	}
+	return conditions
+}
+
+// conditionReason converts a state like backing-up to BackingUp
+func conditionReason(state string) string {
+	parts := strings.Split(state, "-")
+	for i, p := range parts {
+		parts[i] = strings.Title(p)
+	}
+	return strings.Join(parts, "")
+}
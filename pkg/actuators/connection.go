@@ -0,0 +1,50 @@
+package actuators
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ConnectionData builds the content of the connection secrets, with the engine specific
+// connection URIs
+func ConnectionData(engine string, username string, dbname string, host string, port int64, password string) map[string][]byte {
+	address := net.JoinHostPort(host, strconv.FormatInt(port, 10))
+	data := map[string]string{
+		"host":     host,
+		"port":     strconv.FormatInt(port, 10),
+		"username": username,
+		"password": password,
+		"dbname":   dbname,
+	}
+
+	uri := &url.URL{
+		User: url.UserPassword(username, password),
+		Host: address,
+		Path: "/" + dbname,
+	}
+
+	engine = strings.ToLower(engine)
+	switch {
+	case strings.Contains(engine, "postgres"):
+		uri.Scheme = "postgres"
+		data["uri"] = uri.String()
+		data["jdbcUrl"] = fmt.Sprintf("jdbc:postgresql://%v/%v", address, dbname)
+	case strings.Contains(engine, "mysql") || engine == "mariadb" || engine == "aurora":
+		uri.Scheme = "mysql"
+		data["uri"] = uri.String()
+		data["jdbcUrl"] = fmt.Sprintf("jdbc:mysql://%v/%v", address, dbname)
+	case strings.HasPrefix(engine, "oracle"):
+		data["jdbcUrl"] = fmt.Sprintf("jdbc:oracle:thin:@//%v/%v", address, dbname)
+	case strings.HasPrefix(engine, "sqlserver"):
+		data["jdbcUrl"] = fmt.Sprintf("jdbc:sqlserver://%v;databaseName=%v", address, dbname)
+	}
+
+	result := map[string][]byte{}
+	for k, v := range data {
+		result[k] = []byte(v)
+	}
+	return result
+}
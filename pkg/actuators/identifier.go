@@ -0,0 +1,66 @@
+package actuators
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultIdentifierTemplate names the instances after the cluster, the namespace and the object so
+// objects with the same name never collide
+const DefaultIdentifierTemplate = "{{.ClusterID}}-{{.Namespace}}-{{.Name}}"
+
+// Identifiers valid for every provider: 1 to 63 letters, digits or hyphens, starting with a letter,
+// without two consecutive hyphens nor a trailing one
+const maxIdentifierLength = 63
+
+var invalidIdentifierChars = regexp.MustCompile(`[^a-z0-9-]+`)
+var consecutiveHyphens = regexp.MustCompile(`-{2,}`)
+
+// IdentifierData is what the identifier template can use
+type IdentifierData struct {
+	ClusterID string
+	Namespace string
+	Name      string
+	UID       string
+}
+
+// RenderIdentifier renders the template and makes the result a valid identifier
+func RenderIdentifier(text string, data IdentifierData) (string, error) {
+	if text == "" {
+		text = DefaultIdentifierTemplate
+	}
+	t, err := template.New("identifier").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return SanitizeIdentifier(b.String()), nil
+}
+
+// SanitizeIdentifier replaces the invalid characters by hyphens and shortens long identifiers,
+// appending a hash of the full value so they stay unique
+func SanitizeIdentifier(value string) string {
+	identifier := strings.ToLower(value)
+	identifier = invalidIdentifierChars.ReplaceAllString(identifier, "-")
+	identifier = consecutiveHyphens.ReplaceAllString(identifier, "-")
+	identifier = strings.Trim(identifier, "-")
+	if identifier == "" || identifier[0] < 'a' || identifier[0] > 'z' {
+		identifier = "db-" + identifier
+	}
+
+	if len(identifier) > maxIdentifierLength {
+		sum := sha256.Sum256([]byte(value))
+		hash := hex.EncodeToString(sum[:])[:8]
+		identifier = strings.TrimRight(identifier[:maxIdentifierLength-len(hash)-1], "-") + "-" + hash
+	}
+
+	return strings.TrimRight(identifier, "-")
+}
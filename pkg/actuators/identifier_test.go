@@ -0,0 +1,38 @@
+package actuators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderIdentifier(t *testing.T) {
+	data := IdentifierData{ClusterID: "Prod_EU", Namespace: "team-a", Name: "orders.db"}
+
+	identifier, err := RenderIdentifier("", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-eu-team-a-orders-db", identifier)
+
+	identifier, err = RenderIdentifier("{{.Name}}", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "orders-db", identifier)
+
+	// Without a cluster id the default is the namespace and the name
+	identifier, err = RenderIdentifier("", IdentifierData{Namespace: "team-a", Name: "orders"})
+	assert.NoError(t, err)
+	assert.Equal(t, "team-a-orders", identifier)
+
+	_, err = RenderIdentifier("{{.Cluster}}", data)
+	assert.Error(t, err)
+}
+
+func TestSanitizeIdentifier(t *testing.T) {
+	assert.Equal(t, "db-1-orders", SanitizeIdentifier("1--orders-"))
+
+	long := strings.Repeat("namespace", 5) + "-" + strings.Repeat("name", 10)
+	identifier := SanitizeIdentifier(long)
+	assert.Len(t, identifier, 63)
+	assert.NotEqual(t, identifier, SanitizeIdentifier(long+"x"))
+	assert.Equal(t, identifier, SanitizeIdentifier(long))
+}
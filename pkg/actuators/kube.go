@@ -0,0 +1,199 @@
+package actuators
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Kube manages the kubernetes objects of the databases for the actuators other than AWS
+type Kube struct {
+	client.Client
+}
+
+// OwnerReference makes the Rds object the controller of the objects created for it
+func OwnerReference(db *databasesv1.Rds) metav1.OwnerReference {
+	return *metav1.NewControllerRef(db, databasesv1.GroupVersion.WithKind("Rds"))
+}
+
+// ReconcileService points the service named after the database to the address. Host names get
+// an ExternalName service, IPs a ClusterIP service without selector and its endpoints
+func (k *Kube) ReconcileService(ctx context.Context, db *databasesv1.Rds, address string, port int32) error {
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: db.Name, Namespace: db.Namespace}}
+	ip := net.ParseIP(address)
+
+	err := k.CreateOrUpdateControlled(ctx, service, db, func() {
+		service.Annotations = map[string]string{"origin": "rds"}
+		service.OwnerReferences = []metav1.OwnerReference{OwnerReference(db)}
+		if ip == nil {
+			service.Spec.Type = corev1.ServiceTypeExternalName
+			service.Spec.ExternalName = address
+			service.Spec.Ports = nil
+			return
+		}
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+		service.Spec.ExternalName = ""
+		service.Spec.Ports = []corev1.ServicePort{{Name: "db", Port: port, TargetPort: intstr.FromInt(int(port))}}
+	})
+	if err != nil || ip == nil {
+		return err
+	}
+
+	endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: db.Name, Namespace: db.Namespace}}
+	return k.CreateOrUpdateControlled(ctx, endpoints, db, func() {
+		endpoints.OwnerReferences = []metav1.OwnerReference{OwnerReference(db)}
+		endpoints.Subsets = []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: address}},
+			Ports:     []corev1.EndpointPort{{Name: "db", Port: port}},
+		}}
+	})
+}
+
+// DeleteService deletes the service of the database and its endpoints, missing ones are ignored
+func (k *Kube) DeleteService(ctx context.Context, db *databasesv1.Rds) error {
+	meta := metav1.ObjectMeta{Name: db.Name, Namespace: db.Namespace}
+	for _, o := range []Object{&corev1.Service{ObjectMeta: meta}, &corev1.Endpoints{ObjectMeta: meta}} {
+		if err := k.Delete(ctx, o); err != nil && !k8s_errors.IsNotFound(err) {
+			return errors.Wrap(err, fmt.Sprintf("delete of service %v failed in namespace %v", db.Name, db.Namespace))
+		}
+	}
+	return nil
+}
+
+// HasService is true once the service of the database exists
+func (k *Kube) HasService(ctx context.Context, db *databasesv1.Rds) bool {
+	err := k.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: db.Name}, &corev1.Service{})
+	return err == nil
+}
+
+// SecretValue returns the value of a key of a secret, a not found error if the secret or the key are missing
+func (k *Kube) SecretValue(ctx context.Context, namespace string, name string, key string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := k.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to fetch secret %v", name))
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", k8s_errors.NewNotFound(corev1.Resource("secrets"), fmt.Sprintf("%v/%v", name, key))
+	}
+	return string(value), nil
+}
+
+// MasterPassword returns the password referenced by the spec, generating it and storing it in
+// the secret when the secret or the key are missing and the spec asks for it
+func (k *Kube) MasterPassword(ctx context.Context, db *databasesv1.Rds) (string, error) {
+	name, key := db.Spec.Password.Name, db.Spec.Password.Key
+	password, err := k.SecretValue(ctx, db.Namespace, name, key)
+	if err == nil || !db.Spec.GeneratePassword || !k8s_errors.IsNotFound(errors.Cause(err)) {
+		return password, err
+	}
+
+	password, err = GeneratePassword(db.Spec.Engine)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to generate password")
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: db.Namespace}}
+	err = k.CreateOrUpdate(ctx, secret, func() {
+		if secret.CreationTimestamp.IsZero() {
+			secret.Annotations = map[string]string{"origin": "rds"}
+			secret.OwnerReferences = []metav1.OwnerReference{OwnerReference(db)}
+			secret.Type = corev1.SecretTypeOpaque
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[key] = []byte(password)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to store the password in secret %v", name))
+	}
+	return password, nil
+}
+
+// ReconcileSecret creates or updates a secret owned by the database
+func (k *Kube) ReconcileSecret(ctx context.Context, db *databasesv1.Rds, name string, data map[string][]byte) error {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: db.Namespace}}
+	err := k.CreateOrUpdateControlled(ctx, secret, db, func() {
+		if secret.CreationTimestamp.IsZero() {
+			secret.Annotations = map[string]string{"origin": "rds"}
+			secret.OwnerReferences = []metav1.OwnerReference{OwnerReference(db)}
+			secret.Type = corev1.SecretTypeOpaque
+		}
+		secret.Data = data
+	})
+	return errors.Wrap(err, fmt.Sprintf("unable to reconcile secret %v", name))
+}
+
+// ReleasePassword removes the database from the owners of its password secret, so it outlives the object
+func (k *Kube) ReleasePassword(ctx context.Context, db *databasesv1.Rds) error {
+	secret := &corev1.Secret{}
+	err := k.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: db.Spec.Password.Name}, secret)
+	if k8s_errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to fetch secret %v", db.Spec.Password.Name))
+	}
+
+	var owners []metav1.OwnerReference
+	for _, o := range secret.OwnerReferences {
+		if o.UID != db.UID {
+			owners = append(owners, o)
+		}
+	}
+	if len(owners) == len(secret.OwnerReferences) {
+		return nil
+	}
+	secret.OwnerReferences = owners
+	return errors.Wrap(k.Update(ctx, secret), fmt.Sprintf("unable to update secret %v", db.Spec.Password.Name))
+}
+
+// Object is a kubernetes object the actuators create
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// CreateOrUpdate gets the object, applies mutate and creates or updates it when it changed
+func (k *Kube) CreateOrUpdate(ctx context.Context, o Object, mutate func()) error {
+	return k.createOrUpdate(ctx, o, nil, mutate)
+}
+
+// CreateOrUpdateControlled is CreateOrUpdate for objects controlled by the owner, an existing object
+// controlled by anything else is left alone and reported
+func (k *Kube) CreateOrUpdateControlled(ctx context.Context, o Object, owner metav1.Object, mutate func()) error {
+	return k.createOrUpdate(ctx, o, owner, mutate)
+}
+
+func (k *Kube) createOrUpdate(ctx context.Context, o Object, owner metav1.Object, mutate func()) error {
+	key := types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}
+	err := k.Get(ctx, key, o)
+	if k8s_errors.IsNotFound(err) {
+		mutate()
+		return k.Create(ctx, o)
+	}
+	if err != nil {
+		return err
+	}
+	if owner != nil && !metav1.IsControlledBy(o, owner) {
+		return fmt.Errorf("%v %v already exists and is not controlled by %v", reflect.TypeOf(o).Elem().Name(), key, owner.GetName())
+	}
+
+	before := o.DeepCopyObject()
+	mutate()
+	if reflect.DeepEqual(before, o) {
+		return nil
+	}
+	return k.Update(ctx, o)
+}
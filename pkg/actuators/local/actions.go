@@ -0,0 +1,260 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	status, err = a.reconcile(db, client, ctx)
+	return a.observe(db, client, ctx, status, err), err
+}
+
+func (a *Actuator) reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (status databasesv1.RdsStatus, err error) {
+	log := a.log.WithValues("reconcilingDatabase", db.Name)
+	kube := &actuators.Kube{Client: client.Client}
+
+	if err := unsupported(db); err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+	e, err := engineOf(db)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+	spec, err := a.statefulSetSpec(db, e)
+	if err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+	db.Status.InstanceIdentifier = db.Name
+
+	// The pod reads the password from the secret, which must exist first
+	password, err := kube.MasterPassword(ctx, db)
+	if err != nil {
+		return databasesv1.NewStatus("Failing Geting Secret", databasesv1.StatePending), err
+	}
+
+	var changes []string
+
+	// VOLUME
+	// Volumes only grow, when their storage class allows it
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: claimName(db), Namespace: db.Namespace}}
+	err = kube.CreateOrUpdateControlled(ctx, claim, db, func() {
+		if claim.Spec.Resources.Requests == nil {
+			claim.OwnerReferences = []metav1.OwnerReference{actuators.OwnerReference(db)}
+			claim.Spec = a.claimSpec(db)
+			return
+		}
+		requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		if size := storage(db); requested.Cmp(size) < 0 {
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = size
+			changes = append(changes, "size")
+		}
+	})
+	if err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Volume", databasesv1.StatePending), errors.Wrap(err, fmt.Sprintf("unable to reconcile volume %v", claim.Name))
+	}
+
+	// STATEFULSET
+	// Only the image changes once created, the other fields are defaulted by the API server
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: db.Name, Namespace: db.Namespace}}
+	created := false
+	err = kube.CreateOrUpdateControlled(ctx, statefulSet, db, func() {
+		if len(statefulSet.Spec.Template.Spec.Containers) == 0 {
+			statefulSet.Annotations = map[string]string{"origin": "rds"}
+			statefulSet.OwnerReferences = []metav1.OwnerReference{actuators.OwnerReference(db)}
+			statefulSet.Spec = spec
+			created = true
+			return
+		}
+		container := &statefulSet.Spec.Template.Spec.Containers[0]
+		if image := spec.Template.Spec.Containers[0].Image; container.Image != image {
+			container.Image = image
+			changes = append(changes, "engineVersion")
+		}
+	})
+	if err != nil {
+		return databasesv1.NewStatus("Failing Reconciled StatefulSet", databasesv1.StatePending), errors.Wrap(err, fmt.Sprintf("unable to reconcile statefulset %v", db.Name))
+	}
+	if created {
+		log.Info("creating", "image", spec.Template.Spec.Containers[0].Image, "snapshot", db.Spec.DBSnapshotIdentifier)
+		now := metav1.Now()
+		db.Status.PasswordHash = actuators.PasswordHash(db, password)
+		db.Status.PasswordRotatedAt = &now
+		db.Status.RestoredSnapshot = db.Spec.DBSnapshotIdentifier
+	}
+
+	// SERVICE
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: db.Name, Namespace: db.Namespace}}
+	if err := kube.CreateOrUpdateControlled(ctx, service, db, func() { setService(service, db, e) }); err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Service", databasesv1.StatePending), errors.Wrap(err, fmt.Sprintf("unable to reconcile service %v", db.Name))
+	}
+
+	if len(changes) > 0 {
+		log.Info("Modifying database", "changes", changes)
+		status := databasesv1.NewStatus(fmt.Sprintf("Modifying %v", strings.Join(changes, ", ")), databasesv1.StateModifying)
+		status.Modifications = changes
+		return status, nil
+	}
+
+	currentStatus := state(db, statefulSet)
+	if currentStatus != databasesv1.StateAvailable {
+		return databasesv1.NewStatus("Waiting for the database to be ready", currentStatus), nil
+	}
+
+	// PASSWORD
+	// The images only read the password on the first start, a changed secret is refused instead
+	// of being published in the connection secret
+	if hash := actuators.PasswordHash(db, password); db.Status.PasswordHash != "" && hash != db.Status.PasswordHash {
+		err := fmt.Errorf("the local provider can't change the password of %v, restore the previous password in secret %v", db.Name, db.Spec.Password.Name)
+		actuators.Event(client, db, corev1.EventTypeWarning, "PasswordNotApplied", err.Error())
+		return databasesv1.NewStatus(err.Error(), currentStatus), err
+	}
+
+	host := fmt.Sprintf("%v.%v.svc", db.Name, db.Namespace)
+	data := actuators.ConnectionData(db.Spec.Engine, db.Spec.Username, db.Spec.DBName, host, int64(e.port), password)
+	if err := kube.ReconcileSecret(ctx, db, db.ConnectionSecret(), data); err != nil {
+		return databasesv1.NewStatus("Failing Reconciled Connection Secret", currentStatus), err
+	}
+
+	return databasesv1.NewStatus("Database reconciled", currentStatus), nil
+}
+
+func (a *Actuator) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	status, err = a.delete(db, client, ctx)
+	return a.observe(db, client, ctx, status, err), err
+}
+
+// delete removes the database. The volume is kept unless the policy is Delete, the policy
+// Retain also leaves the database running
+func (a *Actuator) delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (status databasesv1.RdsStatus, err error) {
+	log := a.log.WithValues("delete", db.Name)
+	kube := &actuators.Kube{Client: client.Client}
+	policy := db.GetDeletionPolicy()
+	meta := metav1.ObjectMeta{Name: db.Name, Namespace: db.Namespace}
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: claimName(db), Namespace: db.Namespace}}
+	if err := policy.Validate(); err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// RETAIN
+	if policy == databasesv1.DeletionPolicyRetain {
+		for _, o := range []actuators.Object{&appsv1.StatefulSet{ObjectMeta: meta}, &corev1.Service{ObjectMeta: meta}, claim} {
+			if err := release(ctx, kube, db, o); err != nil {
+				return databasesv1.NewStatus("ERROR Releasing objects", db.Status.State), err
+			}
+		}
+		if err := kube.ReleasePassword(ctx, db); err != nil {
+			return databasesv1.NewStatus("ERROR Releasing password secret", db.Status.State), err
+		}
+		return databasesv1.NewStatus("Database retained in the cluster", databasesv1.StateDeleted), nil
+	}
+
+	log.Info("deleting database", "deletionPolicy", policy)
+	if err := remove(ctx, kube, &appsv1.StatefulSet{ObjectMeta: meta}); err != nil {
+		return databasesv1.NewStatus("ERROR Deleting statefulset", db.Status.State), err
+	}
+	if err := kube.DeleteService(ctx, db); err != nil {
+		return databasesv1.NewStatus("ERROR Deleting svc", db.Status.State), err
+	}
+
+	// FINAL SNAPSHOT
+	// The volume outlives the object, holding the data of the database
+	if policy == databasesv1.DeletionPolicySnapshot {
+		if err := release(ctx, kube, db, claim); err != nil {
+			return databasesv1.NewStatus("ERROR Releasing volume", db.Status.State), err
+		}
+		if db.Status.FinalSnapshotIdentifier == "" {
+			db.Status.FinalSnapshotIdentifier = claim.Name
+			actuators.Event(client, db, corev1.EventTypeNormal, "FinalSnapshot", fmt.Sprintf("Volume %v kept with the data of the database", claim.Name))
+		}
+	} else if err := remove(ctx, kube, claim); err != nil {
+		return databasesv1.NewStatus("ERROR Deleting volume", db.Status.State), err
+	}
+
+	log.Info("Deletion of database done")
+	return databasesv1.NewStatus("Deleted", databasesv1.StateDeleted), nil
+}
+
+// observe completes the status returned by the actions with the details of the database and
+// the conditions, carrying over the fields and transition times already stored in the object
+func (a *Actuator) observe(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, status databasesv1.RdsStatus, err error) databasesv1.RdsStatus {
+	hasService := (&actuators.Kube{Client: client.Client}).HasService(ctx, db)
+	return actuators.Observe(db, status, err, hasService, func(observed *databasesv1.RdsStatus) {
+		if e, eerr := engineOf(db); eerr == nil && status.State != databasesv1.StateDeleted && db.Status.InstanceIdentifier != "" {
+			observed.Address = fmt.Sprintf("%v.%v.svc", db.Name, db.Namespace)
+			observed.Port = int64(e.port)
+			observed.EngineVersion = version(db, e)
+			observed.AllocatedStorage = db.Spec.Size
+		} else {
+			observed.Address = ""
+			observed.Port = 0
+		}
+	})
+}
+
+// state is available once the pod is ready, creating until it was ready once and then modifying
+func state(db *databasesv1.Rds, statefulSet *appsv1.StatefulSet) string {
+	if statefulSet.Status.ReadyReplicas > 0 {
+		return databasesv1.StateAvailable
+	}
+	if db.Status.State == databasesv1.StateAvailable || db.Status.State == databasesv1.StateModifying {
+		return databasesv1.StateModifying
+	}
+	return "creating"
+}
+
+// release removes the database from the owners of the object, so it outlives it
+func release(ctx context.Context, kube *actuators.Kube, db *databasesv1.Rds, o actuators.Object) error {
+	err := kube.Get(ctx, types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}, o)
+	if k8s_errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to fetch %v", o.GetName()))
+	}
+
+	var owners []metav1.OwnerReference
+	for _, owner := range o.GetOwnerReferences() {
+		if owner.UID != db.UID {
+			owners = append(owners, owner)
+		}
+	}
+	if len(owners) == len(o.GetOwnerReferences()) {
+		return nil
+	}
+	o.SetOwnerReferences(owners)
+	return errors.Wrap(kube.Update(ctx, o), fmt.Sprintf("unable to update %v", o.GetName()))
+}
+
+// remove deletes the object, a missing one is not an error
+func remove(ctx context.Context, kube *actuators.Kube, o actuators.Object) error {
+	err := kube.Delete(ctx, o)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return errors.Wrap(err, fmt.Sprintf("unable to delete %v", o.GetName()))
+	}
+	return nil
+}
+
+// unsupported fails for the fields of the spec only the cloud providers implement
+func unsupported(db *databasesv1.Rds) error {
+	var fields []string
+	if db.Spec.SnapshotRef != nil || db.Spec.SnapshotSelector != nil {
+		fields = append(fields, "snapshotRef, snapshotSelector")
+	}
+	fields = append(fields, actuators.AWSOnlyFields(db)...)
+	if db.Spec.PasswordRotationInterval != nil {
+		fields = append(fields, "passwordRotationInterval")
+	}
+	return actuators.Unsupported("local", fields)
+}
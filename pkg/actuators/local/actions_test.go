@@ -0,0 +1,244 @@
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func testReconciler(t *testing.T, objects ...runtime.Object) *controllers.RdsReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	assert.NoError(t, appsv1.AddToScheme(scheme))
+	return &controllers.RdsReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme, objects...),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func testDatabase() *databasesv1.Rds {
+	return &databasesv1.Rds{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default", UID: "8f9c0b0e"},
+		Spec: databasesv1.RdsSpec{
+			Class:            "db.t3.micro",
+			DBName:           "app",
+			DeletionPolicy:   databasesv1.DeletionPolicyDelete,
+			Engine:           "postgres",
+			EngineVersion:    "12.4",
+			GeneratePassword: true,
+			Password:         corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "pgsql-password"}, Key: "password"},
+			Size:             5,
+			Username:         "app",
+		},
+	}
+}
+
+func TestReconcileAndDelete(t *testing.T) {
+	a := &Actuator{log: zap.Logger(true), options: Options{StorageClass: "standard"}}
+	client := testReconciler(t)
+	ctx := context.Background()
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	reconcile := func() databasesv1.RdsStatus {
+		status, err := a.Reconcile(db, client, ctx, key)
+		assert.NoError(t, err)
+		db.Status = status
+		return status
+	}
+
+	// The volume, statefulset and service are created with the generated password
+	assert.Equal(t, "creating", reconcile().State)
+	claim := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-data"}, claim))
+	assert.Equal(t, "standard", *claim.Spec.StorageClassName)
+	assert.Equal(t, resource.MustParse("5Gi"), claim.Spec.Resources.Requests[corev1.ResourceStorage])
+	statefulSet := &appsv1.StatefulSet{}
+	assert.NoError(t, client.Get(ctx, key, statefulSet))
+	container := statefulSet.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "postgres:12.4", container.Image)
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "POSTGRES_USER", Value: "app"})
+	assert.Equal(t, "pgsql-password", container.Env[len(container.Env)-1].ValueFrom.SecretKeyRef.Name)
+	service := &corev1.Service{}
+	assert.NoError(t, client.Get(ctx, key, service))
+	assert.Equal(t, int32(5432), service.Spec.Ports[0].Port)
+	assert.Equal(t, statefulSet.Spec.Selector.MatchLabels, service.Spec.Selector)
+	assert.NotEmpty(t, db.Status.PasswordHash)
+
+	// The connection secret is written once the pod is ready
+	statefulSet.Status.ReadyReplicas = 1
+	assert.NoError(t, client.Update(ctx, statefulSet))
+	status := reconcile()
+	assert.Equal(t, databasesv1.StateAvailable, status.State)
+	assert.Equal(t, "pgsql.default.svc", status.Address)
+	assert.Equal(t, int64(5432), status.Port)
+	assert.Equal(t, "12.4", status.EngineVersion)
+	connection := &corev1.Secret{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-connection"}, connection))
+	assert.Equal(t, "pgsql.default.svc", string(connection.Data["host"]))
+
+	// Volumes grow, the image follows the version
+	db.Spec.Size = 10
+	db.Spec.EngineVersion = "12.5"
+	status = reconcile()
+	assert.Equal(t, databasesv1.StateModifying, status.State)
+	assert.Equal(t, []string{"size", "engineVersion"}, status.Modifications)
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-data"}, claim))
+	assert.Equal(t, resource.MustParse("10Gi"), claim.Spec.Resources.Requests[corev1.ResourceStorage])
+	assert.NoError(t, client.Get(ctx, key, statefulSet))
+	assert.Equal(t, "postgres:12.5", statefulSet.Spec.Template.Spec.Containers[0].Image)
+	db.Spec.Size = 1
+	assert.Equal(t, databasesv1.StateAvailable, reconcile().State, "volumes never shrink")
+
+	status, err := a.Delete(db, client, ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.Error(t, client.Get(ctx, key, &appsv1.StatefulSet{}))
+	assert.Error(t, client.Get(ctx, key, &corev1.Service{}))
+	assert.Error(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-data"}, &corev1.PersistentVolumeClaim{}))
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	a := &Actuator{log: zap.Logger(true)}
+	client := testReconciler(t)
+	ctx := context.Background()
+	db := testDatabase()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicySnapshot
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	status, err := a.Reconcile(db, client, ctx, key)
+	assert.NoError(t, err)
+	db.Status = status
+
+	// The volume is released and named as the final snapshot
+	status, err = a.Delete(db, client, ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.Equal(t, "pgsql-data", status.FinalSnapshotIdentifier)
+	assert.Error(t, client.Get(ctx, key, &appsv1.StatefulSet{}))
+	claim := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-data"}, claim))
+	assert.Empty(t, claim.OwnerReferences)
+}
+
+func TestDeleteRetain(t *testing.T) {
+	a := &Actuator{log: zap.Logger(true)}
+	client := testReconciler(t)
+	ctx := context.Background()
+	db := testDatabase()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicyRetain
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	status, err := a.Reconcile(db, client, ctx, key)
+	assert.NoError(t, err)
+	db.Status = status
+
+	// The database keeps running without its owner
+	status, err = a.Delete(db, client, ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	statefulSet := &appsv1.StatefulSet{}
+	assert.NoError(t, client.Get(ctx, key, statefulSet))
+	assert.Empty(t, statefulSet.OwnerReferences)
+	service := &corev1.Service{}
+	assert.NoError(t, client.Get(ctx, key, service))
+	assert.Empty(t, service.OwnerReferences)
+}
+
+func TestReconcileUnsupported(t *testing.T) {
+	a := &Actuator{log: zap.Logger(true)}
+	client := testReconciler(t)
+
+	db := testDatabase()
+	db.Spec.RestoreFrom = &databasesv1.RestoreSource{SourceIdentifier: "source"}
+	status, err := a.Reconcile(db, client, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+
+	db = testDatabase()
+	db.Spec.Engine = "aurora-postgresql"
+	status, err = a.Reconcile(db, client, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+}
+
+func TestReconcileForeignService(t *testing.T) {
+	a := &Actuator{log: zap.Logger(true), options: Options{StorageClass: "standard"}}
+	foreign := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "db.example.com"},
+	}
+	client := testReconciler(t, foreign)
+	ctx := context.Background()
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	// A service the database doesn't control is never taken over
+	_, err := a.Reconcile(db, client, ctx, key)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not controlled by pgsql")
+	service := &corev1.Service{}
+	assert.NoError(t, client.Get(ctx, key, service))
+	assert.Equal(t, "db.example.com", service.Spec.ExternalName)
+	assert.Empty(t, service.OwnerReferences)
+}
+
+func TestPasswordChangeRefused(t *testing.T) {
+	a := &Actuator{log: zap.Logger(true), options: Options{StorageClass: "standard"}}
+	client := testReconciler(t)
+	ctx := context.Background()
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	status, err := a.Reconcile(db, client, ctx, key)
+	assert.NoError(t, err)
+	db.Status = status
+	statefulSet := &appsv1.StatefulSet{}
+	assert.NoError(t, client.Get(ctx, key, statefulSet))
+	statefulSet.Status.ReadyReplicas = 1
+	assert.NoError(t, client.Update(ctx, statefulSet))
+	status, err = a.Reconcile(db, client, ctx, key)
+	assert.NoError(t, err)
+	db.Status = status
+	secret := &corev1.Secret{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-password"}, secret))
+	password := string(secret.Data["password"])
+
+	// The running database keeps its password, the connection secret too
+	secret.Data["password"] = []byte("rotated")
+	assert.NoError(t, client.Update(ctx, secret))
+	status, err = a.Reconcile(db, client, ctx, key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateAvailable, status.State)
+	assert.True(t, databasesv1.IsConditionTrue(status.Conditions, databasesv1.ConditionDegraded))
+	assert.Equal(t, db.Status.PasswordHash, status.PasswordHash)
+	connection := &corev1.Secret{}
+	assert.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pgsql-connection"}, connection))
+	assert.Equal(t, password, string(connection.Data["password"]))
+
+	// Until the previous password is back
+	secret.Data["password"] = []byte(password)
+	assert.NoError(t, client.Update(ctx, secret))
+	status, err = a.Reconcile(db, client, ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, databasesv1.IsConditionTrue(status.Conditions, databasesv1.ConditionDegraded))
+
+	// Rotation is refused up front
+	db.Spec.PasswordRotationInterval = &metav1.Duration{Duration: time.Hour}
+	_, err = a.Reconcile(db, client, ctx, key)
+	assert.Error(t, err)
+}
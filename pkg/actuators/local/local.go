@@ -0,0 +1,117 @@
+package local
+
+import (
+	"fmt"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/go-logr/logr"
+)
+
+// Actuator runs the Rds objects as single pod StatefulSets in the cluster, for the clusters of
+// development and CI
+type Actuator struct {
+	log     logr.Logger
+	options Options
+}
+
+// Options configures the actuator
+type Options struct {
+	// StorageClass of the volumes, the default class of the cluster when empty
+	StorageClass string
+	// Images overrides the image repository of an engine, like postgres=registry.local/postgres
+	Images map[string]string
+	// DumpCredentials is the secret holding the environment of the containers downloading the
+	// dumps from object stores, looked up in the namespace of the database
+	DumpCredentials string
+}
+
+// engine is how the image of an engine is run
+type engine struct {
+	name           string
+	image          string
+	defaultVersion string
+	port           int32
+	dataDir        string
+	// env sets the user, its password and the database of the first start
+	env func(db *databasesv1.Rds) map[string]string
+	// probe tells whether the server accepts connections
+	probe []string
+}
+
+var engines = map[string]engine{
+	"postgres": {
+		name:           "postgres",
+		image:          "postgres",
+		defaultVersion: "12",
+		port:           5432,
+		dataDir:        "/var/lib/postgresql/data",
+		env: func(db *databasesv1.Rds) map[string]string {
+			return map[string]string{"POSTGRES_USER": db.Spec.Username, "POSTGRES_DB": db.Spec.DBName}
+		},
+		probe: []string{"sh", "-c", `pg_isready -h 127.0.0.1 -U "$POSTGRES_USER"`},
+	},
+	"mysql": {
+		name:           "mysql",
+		image:          "mysql",
+		defaultVersion: "8.0",
+		port:           3306,
+		dataDir:        "/var/lib/mysql",
+		env:            mysqlEnv,
+		probe:          []string{"sh", "-c", `mysqladmin ping -h 127.0.0.1 -u "$MYSQL_USER" -p"$MYSQL_PASSWORD"`},
+	},
+	"mariadb": {
+		name:           "mariadb",
+		image:          "mariadb",
+		defaultVersion: "10.4",
+		port:           3306,
+		dataDir:        "/var/lib/mysql",
+		env:            mysqlEnv,
+		probe:          []string{"sh", "-c", `mysqladmin ping -h 127.0.0.1 -u "$MYSQL_USER" -p"$MYSQL_PASSWORD"`},
+	},
+}
+
+func mysqlEnv(db *databasesv1.Rds) map[string]string {
+	return map[string]string{"MYSQL_USER": db.Spec.Username, "MYSQL_DATABASE": db.Spec.DBName}
+}
+
+// NewActuator returns an actuator running the databases in the cluster
+func NewActuator(log logr.Logger, options Options) (*Actuator, error) {
+	for name := range options.Images {
+		if _, ok := engines[name]; !ok {
+			return nil, fmt.Errorf("no image for engine %v, the engines are postgres, mysql and mariadb", name)
+		}
+	}
+	log.Info("local", "storageClass", options.StorageClass, "images", options.Images)
+	return &Actuator{log: log, options: options}, nil
+}
+
+// engineOf returns how the engine of the spec runs
+func engineOf(db *databasesv1.Rds) (engine, error) {
+	name := strings.ToLower(db.Spec.Engine)
+	if name == "postgresql" {
+		name = "postgres"
+	}
+	e, ok := engines[name]
+	if !ok {
+		return engine{}, fmt.Errorf("engine %v can't run in the cluster, use postgres, mysql or mariadb", db.Spec.Engine)
+	}
+	return e, nil
+}
+
+// image returns the image of the engine and version of the spec
+func (a *Actuator) image(db *databasesv1.Rds, e engine) string {
+	repository := e.image
+	if override, ok := a.options.Images[e.name]; ok {
+		repository = override
+	}
+	return repository + ":" + version(db, e)
+}
+
+// version returns the engine version of the spec, the default of the engine when empty
+func version(db *databasesv1.Rds, e engine) string {
+	if db.Spec.EngineVersion == "" {
+		return e.defaultVersion
+	}
+	return db.Spec.EngineVersion
+}
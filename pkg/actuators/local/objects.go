@@ -0,0 +1,199 @@
+package local
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Directory of the scripts and dumps the images run on the first start
+const initDir = "/docker-entrypoint-initdb.d"
+
+// Dumps the images load, by extension
+var dumpExtensions = []string{".sql", ".sql.gz", ".sql.xz", ".sh"}
+
+// Images downloading the dumps, by scheme of the snapshot identifier
+var downloaders = map[string]func(source string, target string) corev1.Container{
+	"pvc": func(source string, target string) corev1.Container {
+		return corev1.Container{Image: "busybox", Command: []string{"cp", source, target}}
+	},
+	"http":  curl,
+	"https": curl,
+	"s3": func(source string, target string) corev1.Container {
+		return corev1.Container{Image: "amazon/aws-cli", Args: []string{"s3", "cp", source, target}}
+	},
+	"gs": func(source string, target string) corev1.Container {
+		return corev1.Container{Image: "google/cloud-sdk:slim", Command: []string{"gsutil", "cp", source, target}}
+	},
+}
+
+func curl(source string, target string) corev1.Container {
+	return corev1.Container{Image: "curlimages/curl", Command: []string{"curl", "-fsSL", "-o", target, source}}
+}
+
+// labels select the pod of the database
+func labels(db *databasesv1.Rds, e engine) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       e.name,
+		"app.kubernetes.io/instance":   db.Name,
+		"app.kubernetes.io/managed-by": "kube-db",
+	}
+}
+
+// claimName is the name of the volume of the database
+func claimName(db *databasesv1.Rds) string {
+	return db.Name + "-data"
+}
+
+// storage is the size of the volume, 1GB at least
+func storage(db *databasesv1.Rds) resource.Quantity {
+	size := db.Spec.Size
+	if size < 1 {
+		size = 1
+	}
+	return resource.MustParse(fmt.Sprintf("%vGi", size))
+}
+
+// claimSpec is the spec of the volume of the database
+func (a *Actuator) claimSpec(db *databasesv1.Rds) corev1.PersistentVolumeClaimSpec {
+	spec := corev1.PersistentVolumeClaimSpec{
+		AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceStorage: storage(db)},
+		},
+	}
+	if a.options.StorageClass != "" {
+		spec.StorageClassName = &a.options.StorageClass
+	}
+	return spec
+}
+
+// statefulSetSpec runs the image of the engine with the volume of the database, loading the
+// dump of the snapshot identifier on the first start
+func (a *Actuator) statefulSetSpec(db *databasesv1.Rds, e engine) (appsv1.StatefulSetSpec, error) {
+	replicas := int32(1)
+	password := &corev1.EnvVarSource{SecretKeyRef: db.Spec.Password.DeepCopy()}
+
+	env := []corev1.EnvVar{}
+	for k, v := range e.env(db) {
+		env = append(env, corev1.EnvVar{Name: k, Value: v})
+	}
+	sort.Slice(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+	if e.name == "postgres" {
+		env = append(env, corev1.EnvVar{Name: "POSTGRES_PASSWORD", ValueFrom: password})
+	} else {
+		env = append(env,
+			corev1.EnvVar{Name: "MYSQL_PASSWORD", ValueFrom: password},
+			corev1.EnvVar{Name: "MYSQL_ROOT_PASSWORD", ValueFrom: password})
+	}
+
+	container := corev1.Container{
+		Name:         "database",
+		Image:        a.image(db, e),
+		Env:          env,
+		Ports:        []corev1.ContainerPort{{Name: "db", ContainerPort: e.port}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: e.dataDir, SubPath: "data"}},
+		ReadinessProbe: &corev1.Probe{
+			Handler:             corev1.Handler{Exec: &corev1.ExecAction{Command: e.probe}},
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       10,
+		},
+	}
+	pod := corev1.PodSpec{
+		Volumes: []corev1.Volume{{
+			Name:         "data",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName(db)}},
+		}},
+	}
+
+	if db.Spec.DBSnapshotIdentifier != "" {
+		loader, volumes, err := a.dumpLoader(db)
+		if err != nil {
+			return appsv1.StatefulSetSpec{}, err
+		}
+		pod.InitContainers = []corev1.Container{loader}
+		pod.Volumes = append(pod.Volumes, volumes...)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "initdb", MountPath: initDir, ReadOnly: true})
+	}
+	pod.Containers = []corev1.Container{container}
+
+	return appsv1.StatefulSetSpec{
+		Replicas:    &replicas,
+		ServiceName: db.Name,
+		Selector:    &metav1.LabelSelector{MatchLabels: labels(db, e)},
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels(db, e)},
+			Spec:       pod,
+		},
+	}, nil
+}
+
+// dumpLoader returns the init container copying the dump of the snapshot identifier to the init
+// directory of the image, along its volumes. The identifier is pvc://CLAIM/PATH, s3://, gs://,
+// http:// or https://
+func (a *Actuator) dumpLoader(db *databasesv1.Rds) (corev1.Container, []corev1.Volume, error) {
+	source := db.Spec.DBSnapshotIdentifier
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" || u.Path == "" {
+		return corev1.Container{}, nil, fmt.Errorf("snapshot %q is not a dump URL like pvc://claim/dump.sql.gz or s3://bucket/dump.sql.gz", source)
+	}
+	download, ok := downloaders[u.Scheme]
+	if !ok {
+		return corev1.Container{}, nil, fmt.Errorf("snapshot %v: dumps are loaded from pvc, s3, gs, http or https URLs", source)
+	}
+	file := path.Base(u.Path)
+	if !hasDumpExtension(file) {
+		return corev1.Container{}, nil, fmt.Errorf("snapshot %v: dumps end with %v", source, strings.Join(dumpExtensions, ", "))
+	}
+
+	volumes := []corev1.Volume{{Name: "initdb", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	mounts := []corev1.VolumeMount{{Name: "initdb", MountPath: initDir}}
+	if u.Scheme == "pvc" {
+		volumes = append(volumes, corev1.Volume{
+			Name:         "dump",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: u.Host, ReadOnly: true}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: "dump", MountPath: "/dump", ReadOnly: true})
+		source = path.Join("/dump", u.Path)
+	}
+
+	loader := download(source, path.Join(initDir, file))
+	loader.Name = "load-dump"
+	loader.VolumeMounts = mounts
+	if a.options.DumpCredentials != "" && u.Scheme != "pvc" {
+		optional := true
+		loader.EnvFrom = []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: a.options.DumpCredentials},
+			Optional:             &optional,
+		}}}
+	}
+	return loader, volumes, nil
+}
+
+func hasDumpExtension(file string) bool {
+	for _, extension := range dumpExtensions {
+		if strings.HasSuffix(file, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// setService points the service of the database to its pod
+func setService(service *corev1.Service, db *databasesv1.Rds, e engine) {
+	service.Annotations = map[string]string{"origin": "rds"}
+	service.OwnerReferences = []metav1.OwnerReference{actuators.OwnerReference(db)}
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	service.Spec.Selector = labels(db, e)
+	service.Spec.Ports = []corev1.ServicePort{{Name: "db", Port: e.port, TargetPort: intstr.FromString("db")}}
+}
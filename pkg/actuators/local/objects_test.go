@@ -0,0 +1,58 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpLoader(t *testing.T) {
+	a := &Actuator{options: Options{DumpCredentials: "dump-credentials"}}
+	db := testDatabase()
+
+	// Dumps in volumes are copied from a read only mount
+	db.Spec.DBSnapshotIdentifier = "pvc://dumps/nightly/app.sql.gz"
+	loader, volumes, err := a.dumpLoader(db)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cp", "/dump/nightly/app.sql.gz", "/docker-entrypoint-initdb.d/app.sql.gz"}, loader.Command)
+	assert.Len(t, volumes, 2)
+	assert.Equal(t, "dumps", volumes[1].PersistentVolumeClaim.ClaimName)
+	assert.Empty(t, loader.EnvFrom)
+
+	// Object stores read the credentials secret
+	db.Spec.DBSnapshotIdentifier = "s3://backups/app.sql"
+	loader, volumes, err = a.dumpLoader(db)
+	assert.NoError(t, err)
+	assert.Equal(t, "amazon/aws-cli", loader.Image)
+	assert.Equal(t, []string{"s3", "cp", "s3://backups/app.sql", "/docker-entrypoint-initdb.d/app.sql"}, loader.Args)
+	assert.Len(t, volumes, 1)
+	assert.Equal(t, "dump-credentials", loader.EnvFrom[0].SecretRef.Name)
+
+	for _, source := range []string{"rds:snapshot-1", "s3://backups/app.dump", "ftp://host/app.sql"} {
+		db.Spec.DBSnapshotIdentifier = source
+		_, _, err = a.dumpLoader(db)
+		assert.Error(t, err, source)
+	}
+}
+
+func TestStatefulSetSpec(t *testing.T) {
+	a := &Actuator{options: Options{Images: map[string]string{"mysql": "registry.local/mysql"}}}
+	db := testDatabase()
+	db.Spec.Engine, db.Spec.EngineVersion = "mysql", ""
+	e, err := engineOf(db)
+	assert.NoError(t, err)
+
+	spec, err := a.statefulSetSpec(db, e)
+	assert.NoError(t, err)
+	container := spec.Template.Spec.Containers[0]
+	assert.Equal(t, "registry.local/mysql:8.0", container.Image)
+	assert.Equal(t, "MYSQL_ROOT_PASSWORD", container.Env[len(container.Env)-1].Name)
+	assert.Empty(t, spec.Template.Spec.InitContainers)
+
+	// The dump is mounted in the init directory of the image
+	db.Spec.DBSnapshotIdentifier = "https://example.com/app.sql"
+	spec, err = a.statefulSetSpec(db, e)
+	assert.NoError(t, err)
+	assert.Equal(t, "load-dump", spec.Template.Spec.InitContainers[0].Name)
+	assert.Equal(t, initDir, spec.Template.Spec.Containers[0].VolumeMounts[1].MountPath)
+}
@@ -0,0 +1,105 @@
+package actuators
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"unicode"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+)
+
+const (
+	letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits  = "0123456789"
+	// Unreserved URI characters, RDS refuses / " @ and spaces and the password
+	// ends up in the connection URIs
+	symbols = "-_.~"
+)
+
+// PasswordHash salts the hash with the object UID, the status is readable by anyone allowed to get the object
+func PasswordHash(db *databasesv1.Rds, password string) string {
+	sum := sha256.Sum256([]byte(string(db.UID) + password))
+	return hex.EncodeToString(sum[:])
+}
+
+type passwordRule struct {
+	length       int
+	charset      string
+	startLetter  bool
+	needsSymbols bool
+}
+
+// passwordRuleFor returns the master password constraints of the engine
+// https://docs.aws.amazon.com/AmazonRDS/latest/APIReference/API_CreateDBInstance.html
+func passwordRuleFor(engine string) passwordRule {
+	engine = strings.ToLower(engine)
+	switch {
+	case strings.HasPrefix(engine, "oracle"):
+		// up to 30 characters, quoting rules make symbols painful
+		return passwordRule{length: 30, charset: letters + digits, startLetter: true}
+	case strings.Contains(engine, "mysql") || engine == "mariadb" || engine == "aurora":
+		// up to 41 characters
+		return passwordRule{length: 40, charset: letters + digits + symbols}
+	case strings.HasPrefix(engine, "sqlserver"):
+		// up to 128 characters, the default policy asks for all character classes
+		return passwordRule{length: 40, charset: letters + digits + symbols, needsSymbols: true}
+	default:
+		// postgres allows up to 128 characters
+		return passwordRule{length: 40, charset: letters + digits + symbols}
+	}
+}
+
+// GeneratePassword returns a random password accepted by the engine, always mixing upper
+// and lower case letters and digits
+func GeneratePassword(engine string) (string, error) {
+	rule := passwordRuleFor(engine)
+	for {
+		password, err := randomString(rule.length, rule.charset)
+		if err != nil {
+			return "", err
+		}
+		if rule.startLetter {
+			first, err := randomString(1, letters)
+			if err != nil {
+				return "", err
+			}
+			password = first + password[1:]
+		}
+		if rule.valid(password) {
+			return password, nil
+		}
+	}
+}
+
+func (r passwordRule) valid(password string) bool {
+	var upper, lower, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	return upper && lower && digit && (symbol || !r.needsSymbols)
+}
+
+func randomString(length int, charset string) (string, error) {
+	max := big.NewInt(int64(len(charset)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
+	}
+	return string(b), nil
+}
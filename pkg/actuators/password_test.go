@@ -0,0 +1,32 @@
+package actuators
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	for _, engine := range []string{"postgres", "mysql", "mariadb", "oracle-se2", "sqlserver-ex"} {
+		rule := passwordRuleFor(engine)
+		password, err := GeneratePassword(engine)
+		assert.NoError(t, err)
+		assert.Len(t, password, rule.length, engine)
+		assert.True(t, rule.valid(password), engine)
+		for _, c := range password {
+			assert.True(t, strings.ContainsRune(rule.charset, c), engine)
+			assert.False(t, strings.ContainsRune(`/"@ `, c), engine)
+		}
+	}
+
+	password, err := GeneratePassword("oracle-ee")
+	assert.NoError(t, err)
+	assert.True(t, unicode.IsLetter(rune(password[0])))
+	assert.True(t, len(password) <= 30)
+
+	other, err := GeneratePassword("oracle-ee")
+	assert.NoError(t, err)
+	assert.NotEqual(t, password, other)
+}
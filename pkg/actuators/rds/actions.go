@@ -2,42 +2,111 @@ package rds
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	databasesv1 "github.com/cloud104/kube-db/api/v1"
 	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
 func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	scoped, err := a.forProvider(db, client.Client, ctx)
+	if err != nil {
+		return a.providerFailure(db, err), err
+	}
+	status, err = scoped.reconcile(db, client, ctx, namespacedName)
+	return scoped.observe(db, status, err), err
+}
+
+func (a *Actuator) reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
 	log := a.log.WithValues("reconcilingDatabase", db.Name)
 	log.Info("Start reconciling")
 
+	// ADOPT
+	// Bind to an existing instance instead of creating one, unless an instance was already managed
+	if adopt := db.AdoptIdentifier(); adopt != "" && adopt != db.Status.InstanceIdentifier {
+		if db.Status.ARN != "" {
+			err = fmt.Errorf("already bound to db instance %v, can't adopt %v", db.Status.InstanceIdentifier, adopt)
+			return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+		}
+		log.Info("adopting database", "identifier", adopt)
+		db.Status.InstanceIdentifier = adopt
+	}
+
+	// Persist the identifier before anything gets created, so it never changes afterwards
+	if db.Status.InstanceIdentifier == "" {
+		db.Status.InstanceIdentifier = a.k8srds.InstanceIdentifier(db)
+	}
+
 	// Get database current status
 	currentStatus, err := a.k8srds.GetStatus(db)
 	if err != nil {
-		return databasesv1.NewStatus(err.Error(), "error"), err
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// OWNERSHIP
+	// Never touch an instance created by someone else that happens to share the identifier
+	if currentStatus != databasesv1.StatePending {
+		if status, err := a.verifyOwnership(db, client, true); err != nil {
+			return status, err
+		}
 	}
 
 	// // Get database pendingReboot state
 	// pendingReboot, err := a.k8srds.PendingReboot(db)
 	// if err != nil {
 	// 	pp.Println(err)
-	// 	return databasesv1.NewStatus(err.Error(), "error"), err
+	// 	return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
 	// }
 
 	// Get service current state
-	hasService := a.kubeClient.HasService(db.Namespace, db.Name)
+	hasService := a.kubeClient.HasService(db.Namespace, db.Name, db.UID)
+
+	// AVAILABLE, MODIFY
+	// If AVAILABLE and HAS_SERVICE: already Created and Reboted, apply any spec drift
+	if currentStatus == databasesv1.StateAvailable && hasService {
+		changes, err := a.k8srds.ModifyDatabase(db)
+		if err != nil {
+			return databasesv1.NewStatus("Failed To Modify Database", currentStatus), err
+		}
+
+		if len(changes) > 0 {
+			log.Info("Modifying database", "changes", changes)
+			status := databasesv1.NewStatus(fmt.Sprintf("Modifying %v", strings.Join(changes, ", ")), databasesv1.StateModifying)
+			status.Modifications = changes
+			return status, nil
+		}
+
+		rotated, err := a.reconcilePassword(db)
+		if err != nil {
+			return databasesv1.NewStatus("Failing Reconciled Password", currentStatus), err
+		}
+		if rotated {
+			log.Info("Master password changed")
+			return databasesv1.NewStatus("Applying new master password", databasesv1.StateModifying), nil
+		}
+
+		err = a.reconcileConnectionSecret(db)
+		if err != nil {
+			return databasesv1.NewStatus("Failing Reconciled Connection Secret", currentStatus), err
+		}
 
-	// AVAILABLE, SKIP
-	// If AVAILABLE and HAS_SERVICE: nothing to do, already Created and Reboted
-	if currentStatus == "available" && hasService {
 		log.Info("database reconciliation done, skipping")
 		return databasesv1.NewStatus("Database reconciled", currentStatus), nil
 	}
 
 	// // REBOOT
 	// // If AVAILABLE and HAS_NO_SERVICE: reboot before reconciling service
-	// if currentStatus == "available" && !pendingReboot {
+	// if currentStatus == databasesv1.StateAvailable && !pendingReboot {
 	// 	log.Info("Rebooting database")
 	// 	err = a.k8srds.RebootDatabase(db)
 	// 	if err != nil {
@@ -48,7 +117,7 @@ func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconci
 
 	// SERVICE
 	// If NO_SERVICE: Create service
-	if currentStatus == "available" && !hasService {
+	if currentStatus == databasesv1.StateAvailable && !hasService {
 		log.Info("Getting endpoint")
 		hostname, err := a.k8srds.GetEndpoint(db)
 		if err != nil {
@@ -56,7 +125,7 @@ func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconci
 		}
 
 		log.Info("Reconciling service", "name", db.Name, "hostname", hostname, "namespace", db.Namespace)
-		err = a.kubeClient.ReconcileService(db.Namespace, hostname, db.Name)
+		err = a.kubeClient.ReconcileService(db.Namespace, hostname, db.Name, ownerReference(db))
 		if err != nil {
 			return databasesv1.NewStatus("Failing Reconciled Service", currentStatus), err
 		}
@@ -65,24 +134,49 @@ func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconci
 	}
 
 	// If went throw all validations and arrived here with status diferent  from pending, return
-	if currentStatus != "pending" {
+	if currentStatus != databasesv1.StatePending {
 		return databasesv1.NewStatus("Database not in a reconcilable state, will wait", currentStatus), nil
 	}
 
+	// An adopted instance is never created
+	if db.AdoptIdentifier() != "" {
+		err = fmt.Errorf("db instance %v to adopt not found", db.Status.InstanceIdentifier)
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
 	// If pending and has no service, reconciliate
 
 	// Based in the field, it creates or restores
-	if db.Spec.DBSnapshotIdentifier != "" {
-		log.Info("restoring")
-		err = a.k8srds.RestoreDatabase(db)
+	if db.Spec.RestoreFrom != nil {
+		var source string
+		source, err = a.restoreSource(db, client, ctx)
+		if err != nil {
+			return databasesv1.NewStatus(err.Error(), currentStatus), err
+		}
+		log.Info("restoring to point in time", "source", source)
+		err = a.k8srds.RestoreDatabaseToPointInTime(db, source)
+	} else if db.Spec.DBSnapshotIdentifier != "" || db.Spec.SnapshotSelector != nil || db.Spec.SnapshotRef != nil {
+		var snapshot string
+		snapshot, err = a.restoreSnapshot(db, client, ctx)
+		if err != nil {
+			return databasesv1.NewStatus(err.Error(), currentStatus), err
+		}
+		log.Info("restoring", "snapshot", snapshot)
+		err = a.k8srds.RestoreDatabase(db, snapshot)
 	} else {
 		log.Info("creating")
 		log.Info("getting secret: Name", "name", db.Spec.Password.Name, "key", db.Spec.Password.Key)
-		pw, err := a.kubeClient.GetSecret(db.Namespace, db.Spec.Password.Name, db.Spec.Password.Key)
+		var pw string
+		pw, err = a.masterPassword(db)
 		if err != nil {
 			return databasesv1.NewStatus("Failing Geting Secret", currentStatus), err
 		}
 		err = a.k8srds.CreateDatabase(db, pw)
+		if err == nil {
+			now := metav1.Now()
+			db.Status.PasswordHash = actuators.PasswordHash(db, pw)
+			db.Status.PasswordRotatedAt = &now
+		}
 	}
 	if err != nil {
 		return databasesv1.NewStatus(err.Error(), currentStatus), err
@@ -93,22 +187,65 @@ func (a *Actuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconci
 }
 
 func (a *Actuator) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
+	// The instance is deleted in the account it was created in, whatever the spec says now
+	scoped, err := a.forConfig(providerConfigOf(db), db.Namespace, client.Client, ctx)
+	if err != nil {
+		return a.providerFailure(db, err), err
+	}
+	status, err = scoped.delete(db, client, ctx, namespacedName)
+	return scoped.observe(db, status, err), err
+}
+
+func (a *Actuator) delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsStatus, err error) {
 	log := a.log.WithValues("delete", db.Name)
+	policy := db.GetDeletionPolicy()
+	if err := policy.Validate(); err != nil {
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	if db.Status.InstanceIdentifier == "" {
+		db.Status.InstanceIdentifier = a.k8srds.InstanceIdentifier(db)
+	}
+
+	// RETAIN
+	// Leave the database at AWS, only the kubernetes objects go away
+	if policy == databasesv1.DeletionPolicyRetain {
+		return a.retain(db)
+	}
 
 	currentStatus, err := a.k8srds.GetStatus(db)
 	if err != nil {
 		return databasesv1.NewStatus("Error Getting Status", currentStatus), err
 	}
-	hasService := a.kubeClient.HasService(db.Namespace, db.Name)
+	hasService := a.kubeClient.HasService(db.Namespace, db.Name, db.UID)
+
+	// OWNERSHIP
+	// The deletion is blocked until the policy is changed to Retain
+	if currentStatus != databasesv1.StatePending {
+		if status, err := a.verifyOwnership(db, client, false); err != nil {
+			return status, err
+		}
+	}
 
 	if currentStatus == "rebooting" || currentStatus == "creating" || currentStatus == "deleting" {
-		return databasesv1.NewStatus("Database not in a deletable state, will wait", "WAITING"), err
+		return databasesv1.NewStatus("Database not in a deletable state, will wait", currentStatus), err
 	}
 
 	// If status pending, meaning that the database does not exist
-	if currentStatus != "pending" {
-		log.Info("deleting database")
-		err := a.k8srds.DeleteDatabase(db)
+	if currentStatus != databasesv1.StatePending {
+		// The identifier of the final snapshot is persisted before the deletion starts, so the
+		// snapshot is always checked once the instance is gone
+		finalSnapshot := ""
+		if policy != databasesv1.DeletionPolicyDelete {
+			if db.Status.FinalSnapshotIdentifier == "" {
+				db.Status.FinalSnapshotIdentifier = k8srds.FinalSnapshotIdentifier(a.k8srds.InstanceIdentifier(db), time.Now())
+				return databasesv1.NewStatus("Preparing final snapshot", currentStatus), nil
+			}
+			finalSnapshot = db.Status.FinalSnapshotIdentifier
+		}
+
+		log.Info("deleting database", "deletionPolicy", policy, "finalSnapshot", finalSnapshot)
+		err := a.k8srds.DeleteDatabase(db, finalSnapshot)
 		if err != nil {
 			return databasesv1.NewStatus(err.Error(), currentStatus), err
 		}
@@ -116,10 +253,19 @@ func (a *Actuator) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler
 		return databasesv1.NewStatus("Deleting", currentStatus), err
 	}
 
+	// FINAL SNAPSHOT
+	// The instance is gone, the object only goes away once the final snapshot is available
+	if policy == databasesv1.DeletionPolicySnapshot && db.Status.FinalSnapshotIdentifier != "" && db.Status.FinalSnapshotARN == "" {
+		status, err := a.verifyFinalSnapshot(db, client)
+		if err != nil || db.Status.FinalSnapshotARN == "" {
+			return status, err
+		}
+	}
+
 	// If hasService Remove it
 	if hasService {
 		log.Info("deleting svc")
-		err = a.kubeClient.DeleteService(db.Namespace, db.Name)
+		err = a.kubeClient.DeleteService(db.Namespace, db.Name, db.UID)
 		if err != nil {
 			log.Error(err, "could not delete service")
 			return databasesv1.NewStatus("ERROR Deleting svc", currentStatus), err
@@ -128,5 +274,234 @@ func (a *Actuator) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler
 	}
 
 	log.Info("Deletion of database done")
-	return databasesv1.NewStatus("Deleted", currentStatus), err
+	return databasesv1.NewStatus("Deleted", databasesv1.StateDeleted), err
+}
+
+// restoreSource returns the identifier of the instance to restore from, resolving the Rds reference
+func (a *Actuator) restoreSource(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (string, error) {
+	restore := db.Spec.RestoreFrom
+	if restore.SourceIdentifier != "" {
+		tags, err := a.k8srds.InstanceTags(restore.SourceIdentifier)
+		if err != nil {
+			return "", err
+		}
+		if tags == nil {
+			return "", fmt.Errorf("restore source %v not found", restore.SourceIdentifier)
+		}
+		if err := a.allowedSource(fmt.Sprintf("restore source %v", restore.SourceIdentifier), tags, db, client, ctx); err != nil {
+			return "", err
+		}
+		return restore.SourceIdentifier, nil
+	}
+	if restore.SourceRef == nil {
+		return "", fmt.Errorf("restoreFrom needs a sourceIdentifier or a sourceRef")
+	}
+
+	namespace := restore.SourceRef.Namespace
+	if namespace == "" {
+		namespace = db.Namespace
+	}
+	key := types.NamespacedName{Namespace: namespace, Name: restore.SourceRef.Name}
+	source := &databasesv1.Rds{}
+	if err := client.Get(ctx, key, source); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to get the restore source %v", key))
+	}
+
+	// A restore copies the data, another namespace must be allowed like for replicas
+	if !source.AllowsReplicaIn(db.Namespace) {
+		return "", fmt.Errorf("source %v does not allow restores in namespace %v, see the %v annotation", key, db.Namespace, databasesv1.ReplicaNamespacesAnnotation)
+	}
+	if source.Status.InstanceIdentifier == "" {
+		return "", fmt.Errorf("restore source %v has no instance yet", key)
+	}
+	return source.Status.InstanceIdentifier, nil
+}
+
+// allowedSource fails unless the owner tags of a restore source show it belongs to this cluster and to
+// the namespace of the object, or to an Rds allowing restores in that namespace like for replicas
+func (a *Actuator) allowedSource(what string, tags map[string]string, db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) error {
+	namespace := tags[k8srds.TagNamespace]
+	if namespace == "" || tags[k8srds.TagClusterID] != a.k8srds.ClusterID {
+		return fmt.Errorf("%v is not managed by this cluster", what)
+	}
+	if namespace == db.Namespace {
+		return nil
+	}
+
+	key := types.NamespacedName{Namespace: namespace, Name: tags[k8srds.TagName]}
+	owner := &databasesv1.Rds{}
+	if err := client.Get(ctx, key, owner); err != nil || string(owner.UID) != tags[k8srds.TagUID] {
+		return fmt.Errorf("%v belongs to namespace %v", what, namespace)
+	}
+	if !owner.AllowsReplicaIn(db.Namespace) {
+		return fmt.Errorf("%v does not allow restores in namespace %v, see the %v annotation of %v", what, db.Namespace, databasesv1.ReplicaNamespacesAnnotation, key)
+	}
+	return nil
+}
+
+// restoreSnapshot returns the snapshot to restore, the selector is resolved once and kept in the
+// status so retries restore the same snapshot
+func (a *Actuator) restoreSnapshot(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) (string, error) {
+	if db.Status.RestoredSnapshot != "" {
+		return db.Status.RestoredSnapshot, nil
+	}
+
+	snapshot := db.Spec.DBSnapshotIdentifier
+	if snapshot != "" {
+		found, err := a.k8srds.DescribeSnapshot(snapshot)
+		if err != nil {
+			return "", err
+		}
+		if found == nil {
+			return "", fmt.Errorf("snapshot %v not found", snapshot)
+		}
+		if err := a.allowedSnapshot(found, db, client, ctx); err != nil {
+			return "", err
+		}
+	}
+	if snapshot == "" && db.Spec.SnapshotRef != nil {
+		key := types.NamespacedName{Namespace: db.Namespace, Name: db.Spec.SnapshotRef.Name}
+		ref := &databasesv1.RdsSnapshot{}
+		if err := client.Get(ctx, key, ref); err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("unable to get the snapshot %v", key))
+		}
+		if ref.Status.State != databasesv1.StateAvailable {
+			return "", fmt.Errorf("snapshot %v is not available yet", key)
+		}
+		snapshot = ref.Status.SnapshotIdentifier
+	}
+	if snapshot == "" {
+		selector := db.Spec.SnapshotSelector
+		found, err := a.k8srds.LatestSnapshot(selector.SourceIdentifier, selector.SnapshotType, selector.Tags, func(s *rds.DBSnapshot) bool {
+			err := a.allowedSnapshot(s, db, client, ctx)
+			if err != nil {
+				a.log.Info("skipping snapshot", "name", db.Name, "reason", err.Error())
+			}
+			return err == nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if found == nil {
+			return "", fmt.Errorf("no available snapshot of %v matches the selector", selector.SourceIdentifier)
+		}
+		snapshot = aws.StringValue(found.DBSnapshotIdentifier)
+	}
+
+	db.Status.RestoredSnapshot = snapshot
+	return snapshot, nil
+}
+
+// allowedSnapshot checks the owner tags of a snapshot to restore like allowedSource. A snapshot
+// without them, like the automated ones, is checked against its instance
+func (a *Actuator) allowedSnapshot(snapshot *rds.DBSnapshot, db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context) error {
+	identifier := aws.StringValue(snapshot.DBSnapshotIdentifier)
+	tags, err := a.k8srds.ResourceTags(aws.StringValue(snapshot.DBSnapshotArn))
+	if err != nil {
+		return err
+	}
+	if tags[k8srds.TagUID] == "" {
+		tags, err = a.k8srds.InstanceTags(aws.StringValue(snapshot.DBInstanceIdentifier))
+		if err != nil {
+			return err
+		}
+	}
+	return a.allowedSource(fmt.Sprintf("snapshot %v", identifier), tags, db, client, ctx)
+}
+
+// verifyFinalSnapshot records the ARN of the final snapshot once it and its copies are available,
+// failing when the snapshot failed or vanished so the finalizer is kept
+func (a *Actuator) verifyFinalSnapshot(db *databasesv1.Rds, client *controllers.RdsReconciler) (databasesv1.RdsStatus, error) {
+	identifier := db.Status.FinalSnapshotIdentifier
+
+	snapshot, err := a.k8srds.DescribeSnapshot(identifier)
+	if err != nil {
+		return databasesv1.NewStatus("Error Getting Final Snapshot", databasesv1.StatePending), err
+	}
+
+	if snapshot == nil || aws.StringValue(snapshot.Status) == "failed" {
+		err = fmt.Errorf("final snapshot %v failed, remove the finalizer %v by hand once the data is safe", identifier, databasesv1.RdsFinalizer)
+		a.event(client, db, corev1.EventTypeWarning, "FinalSnapshotFailed", err.Error())
+		return databasesv1.NewStatus(err.Error(), databasesv1.StateSnapshotFailed), err
+	}
+
+	if aws.StringValue(snapshot.Status) != "available" {
+		message := fmt.Sprintf("Waiting for final snapshot %v, %v%% done", identifier, aws.Int64Value(snapshot.PercentProgress))
+		return databasesv1.NewStatus(message, databasesv1.StatePending), nil
+	}
+
+	err = a.k8srds.TagResource(aws.StringValue(snapshot.DBSnapshotArn), db)
+	if err != nil {
+		return databasesv1.NewStatus("Error Tagging Final Snapshot", databasesv1.StatePending), err
+	}
+
+	// Offsite copies are part of the final snapshot
+	copies, done, err := a.replicateSnapshot(snapshot, db.Spec.FinalSnapshotCopies, db)
+	db.Status.FinalSnapshotCopies = copies
+	if err != nil {
+		return databasesv1.NewStatus("Error Copying Final Snapshot", databasesv1.StatePending), err
+	}
+	if !done {
+		return databasesv1.NewStatus(fmt.Sprintf("Waiting for the copies of final snapshot %v", identifier), databasesv1.StatePending), nil
+	}
+
+	db.Status.FinalSnapshotARN = aws.StringValue(snapshot.DBSnapshotArn)
+	a.event(client, db, corev1.EventTypeNormal, "FinalSnapshot", fmt.Sprintf("Final snapshot %v available: %v", identifier, db.Status.FinalSnapshotARN))
+	return databasesv1.NewStatus("Final snapshot available", databasesv1.StatePending), nil
+}
+
+// verifyOwnership fails with the conflict state when the instance is not tagged as owned by the object.
+// When adopt is set and the object asks to adopt the instance, it is tagged as owned instead
+func (a *Actuator) verifyOwnership(db *databasesv1.Rds, client *controllers.RdsReconciler, adopt bool) (databasesv1.RdsStatus, error) {
+	err := a.k8srds.VerifyOwnership(db)
+	if err == nil {
+		return databasesv1.RdsStatus{}, nil
+	}
+	if !k8srds.IsOwnershipError(err) {
+		return databasesv1.NewStatus("Error Verifying Ownership", databasesv1.StateError), err
+	}
+
+	if adopt && db.AdoptIdentifier() == db.Status.InstanceIdentifier {
+		arn, aerr := a.k8srds.AdoptDatabase(db)
+		if aerr == nil {
+			a.event(client, db, corev1.EventTypeNormal, "Adopted", fmt.Sprintf("Adopted db instance %v", arn))
+			return databasesv1.RdsStatus{}, nil
+		}
+		if !k8srds.IsOwnershipError(aerr) {
+			return databasesv1.NewStatus("Error Adopting Database", databasesv1.StateError), aerr
+		}
+		err = aerr
+	}
+
+	a.event(client, db, corev1.EventTypeWarning, "Conflict", err.Error())
+	return databasesv1.NewStatus(err.Error(), databasesv1.StateConflict), err
+}
+
+// event records a kubernetes event on the object, when the reconciler has a recorder
+func (a *Actuator) event(client *controllers.RdsReconciler, db *databasesv1.Rds, eventType string, reason string, message string) {
+	if client == nil || client.Recorder == nil {
+		return
+	}
+	client.Recorder.Event(db, eventType, reason, message)
+}
+
+// retain removes the service and releases the password secret, so it outlives the object along the database
+func (a *Actuator) retain(db *databasesv1.Rds) (status databasesv1.RdsStatus, err error) {
+	log := a.log.WithValues("retain", db.Name)
+	currentStatus := db.Status.State
+
+	log.Info("releasing password secret")
+	err = a.kubeClient.RemoveOwnerReference(db.Namespace, db.Spec.Password.Name, db.UID)
+	if err != nil {
+		return databasesv1.NewStatus("ERROR Releasing password secret", currentStatus), err
+	}
+
+	log.Info("deleting svc")
+	err = a.kubeClient.DeleteService(db.Namespace, db.Name, db.UID)
+	if err != nil {
+		return databasesv1.NewStatus("ERROR Deleting svc", currentStatus), err
+	}
+
+	log.Info("Database retained")
+	return databasesv1.NewStatus("Database retained at AWS", databasesv1.StateDeleted), nil
 }
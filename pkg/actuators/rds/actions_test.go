@@ -0,0 +1,636 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	"github.com/cloud104/kube-db/pkg/actuators/rds/client/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// testActuator returns an actuator on an empty fake account, the kubernetes objects are the
+// ones of the fake clientset
+func testActuator(backend *fake.Backend, objects ...runtime.Object) *Actuator {
+	clients := backend.AWS(nil)
+	clients.Subnets = []string{"subnet-a", "subnet-b"}
+	clients.SecurityGroups = []string{"sg-a"}
+	clients.ClusterID = "cluster"
+	clients.IdentifierTemplate = "{{.Name}}"
+	return &Actuator{
+		log:        zap.Logger(true),
+		kubeClient: &Kube{Client: k8sfake.NewSimpleClientset(objects...)},
+		k8srds:     clients,
+		providers:  newProviders(),
+	}
+}
+
+func testReconciler(t *testing.T, objects ...runtime.Object) *controllers.RdsReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	return &controllers.RdsReconciler{
+		Client:   ctrlfake.NewFakeClientWithScheme(scheme, objects...),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func testDatabase() *databasesv1.Rds {
+	return &databasesv1.Rds{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default", UID: "8f9c0b0e"},
+		Spec: databasesv1.RdsSpec{
+			Class:             "db.t3.micro",
+			DBName:            "app",
+			DBSubnetGroupName: "pgsql",
+			Engine:            "postgres",
+			Password:          corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "pgsql-password"}, Key: "password"},
+			Size:              20,
+			Username:          "app",
+		},
+	}
+}
+
+func testSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgsql-password", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("secret-password")},
+	}
+}
+
+// reconcileDatabase reconciles the database until it is reconciled, advancing the backend in between
+func reconcileDatabase(t *testing.T, a *Actuator, backend *fake.Backend, client *controllers.RdsReconciler, db *databasesv1.Rds) {
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+	for i := 0; i < 10; i++ {
+		status, err := a.Reconcile(db, client, context.Background(), key)
+		assert.NoError(t, err)
+		db.Status = status
+		if status.Message == "Database reconciled" {
+			return
+		}
+		backend.Advance()
+	}
+	t.Fatalf("database not reconciled: %v", db.Status.Message)
+}
+
+func TestReconcileAndDelete(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	client := testReconciler(t)
+	ctx := context.Background()
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	reconcile := func() databasesv1.RdsStatus {
+		status, err := a.Reconcile(db, client, ctx, key)
+		assert.NoError(t, err)
+		db.Status = status
+		return status
+	}
+	remove := func() databasesv1.RdsStatus {
+		status, err := a.Delete(db, client, ctx, key)
+		assert.NoError(t, err)
+		db.Status = status
+		return status
+	}
+
+	// The instance is created in the subnet group, tagged as owned by the object
+	status := reconcile()
+	assert.Equal(t, databasesv1.StatePending, status.State)
+	instance := backend.Instances["pgsql"]
+	if !assert.NotNil(t, instance) {
+		return
+	}
+	assert.Equal(t, "secret-password", instance.Password)
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, []string{
+		aws.StringValue(backend.SubnetGroups["pgsql"].Subnets[0].SubnetIdentifier),
+		aws.StringValue(backend.SubnetGroups["pgsql"].Subnets[1].SubnetIdentifier),
+	})
+	assert.NoError(t, a.k8srds.VerifyOwnership(db))
+	assert.NotEmpty(t, db.Status.PasswordHash)
+
+	backend.Advance()
+	status = reconcile()
+	assert.Equal(t, "backing-up", status.State)
+	assert.Equal(t, "pgsql.c0ffee.us-east-1.rds.amazonaws.com", status.Address)
+
+	// Clients connect through the service, the database is not Ready without it
+	ready := databasesv1.FindCondition(status.Conditions, databasesv1.ConditionReady)
+	assert.Equal(t, corev1.ConditionFalse, ready.Status)
+	assert.Equal(t, "WaitingForService", ready.Reason)
+
+	// Once available the service points to the endpoint, then the connection secret is written
+	backend.Advance()
+	status = reconcile()
+	assert.Equal(t, databasesv1.StateAvailable, status.State)
+	service, err := a.kubeClient.Client.CoreV1().Services("default").Get("pgsql", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "pgsql.c0ffee.us-east-1.rds.amazonaws.com", service.Spec.ExternalName)
+	assert.True(t, databasesv1.IsConditionTrue(status.Conditions, databasesv1.ConditionReady))
+
+	status = reconcile()
+	assert.Equal(t, "Database reconciled", status.Message)
+	secret, err := a.kubeClient.Client.CoreV1().Secrets("default").Get(db.ConnectionSecret(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-password", string(secret.Data["password"]))
+
+	// Spec changes are applied by a modification
+	db.Spec.Size = 50
+	db.Spec.ApplyImmediately = true
+	status = reconcile()
+	assert.Equal(t, databasesv1.StateModifying, status.State)
+	assert.Equal(t, []string{"size"}, status.Modifications)
+	backend.Advance()
+	status = reconcile()
+	assert.Equal(t, "Database reconciled", status.Message)
+	assert.Equal(t, int64(50), status.AllocatedStorage)
+
+	// The identifier of the final snapshot is persisted before the instance is deleted
+	backend.Operations()
+	status = remove()
+	assert.Equal(t, "Preparing final snapshot", status.Message)
+	assert.NotContains(t, backend.Operations(), "DeleteDBInstance")
+	snapshot := db.Status.FinalSnapshotIdentifier
+	assert.NotEmpty(t, snapshot)
+
+	// The deletion waits for the final snapshot, then removes the service. A status lost after
+	// the deletion started still knows the snapshot
+	persisted := *db.Status.DeepCopy()
+	status = remove()
+	assert.Equal(t, "Deleting", status.Message)
+	assert.Contains(t, backend.Operations(), "DeleteDBInstance")
+	assert.Equal(t, snapshot, status.FinalSnapshotIdentifier)
+	db.Status = persisted
+
+	status = remove()
+	assert.Equal(t, "deleting", status.State)
+
+	backend.Advance()
+	assert.Empty(t, backend.Instances)
+	status = remove()
+	assert.Equal(t, "Deleting", status.Message)
+	assert.Equal(t, aws.StringValue(backend.Snapshots[snapshot].DBSnapshotArn), db.Status.FinalSnapshotARN)
+	assert.NoError(t, checkTags(backend, db.Status.FinalSnapshotARN, "databases.tks.sh/uid", "8f9c0b0e"))
+
+	status = remove()
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	_, err = a.kubeClient.Client.CoreV1().Services("default").Get("pgsql", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestRestoreFromSnapshot(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	snapshot := func(identifier string, namespace string) {
+		arn := "arn:aws:rds:us-east-1:123456789012:snapshot:" + identifier
+		backend.Snapshots[identifier] = &rds.DBSnapshot{
+			AllocatedStorage:     aws.Int64(100),
+			DBInstanceIdentifier: aws.String("source"),
+			DBSnapshotArn:        aws.String(arn),
+			DBSnapshotIdentifier: aws.String(identifier),
+			Engine:               aws.String("postgres"),
+			EngineVersion:        aws.String("11.5"),
+			SnapshotType:         aws.String("manual"),
+			Status:               aws.String("available"),
+		}
+		if namespace != "" {
+			backend.Tags[arn] = []rds.Tag{
+				{Key: aws.String(k8srds.TagClusterID), Value: aws.String("cluster")},
+				{Key: aws.String(k8srds.TagNamespace), Value: aws.String(namespace)},
+				{Key: aws.String(k8srds.TagName), Value: aws.String("source")},
+				{Key: aws.String(k8srds.TagUID), Value: aws.String("50urce")},
+			}
+		}
+	}
+	snapshot("nightly", "default")
+	snapshot("foreign", "team-a")
+	snapshot("untagged", "")
+	a := testActuator(backend)
+	client := testReconciler(t)
+	key := types.NamespacedName{Namespace: "default", Name: "pgsql"}
+
+	// Snapshots of other namespaces, or not managed by the cluster, can't be restored
+	for _, identifier := range []string{"foreign", "untagged", "missing"} {
+		db := testDatabase()
+		db.Spec.DBSnapshotIdentifier = identifier
+		_, err := a.Reconcile(db, client, context.Background(), key)
+		assert.Error(t, err, identifier)
+	}
+	assert.NotContains(t, backend.Operations(), "RestoreDBInstanceFromDBSnapshot")
+
+	db := testDatabase()
+	db.Spec.DBSnapshotIdentifier = "nightly"
+	status, err := a.Reconcile(db, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StatePending, status.State)
+	assert.Equal(t, "nightly", status.RestoredSnapshot)
+	assert.Equal(t, "11.5", status.EngineVersion)
+	assert.Equal(t, int64(100), status.AllocatedStorage)
+	assert.Contains(t, backend.Operations(), "RestoreDBInstanceFromDBSnapshot")
+}
+
+func TestRestoreFromSnapshotSelector(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	for i, namespace := range []string{"default", "team-a", ""} {
+		identifier := fmt.Sprintf("snapshot-%v", i)
+		arn := "arn:aws:rds:us-east-1:123456789012:snapshot:" + identifier
+		backend.Snapshots[identifier] = &rds.DBSnapshot{
+			DBInstanceIdentifier: aws.String("source"),
+			DBSnapshotArn:        aws.String(arn),
+			DBSnapshotIdentifier: aws.String(identifier),
+			Engine:               aws.String("postgres"),
+			SnapshotCreateTime:   aws.Time(time.Date(2019, 9, 1+i, 0, 0, 0, 0, time.UTC)),
+			SnapshotType:         aws.String("manual"),
+			Status:               aws.String("available"),
+		}
+		if namespace != "" {
+			backend.Tags[arn] = []rds.Tag{
+				{Key: aws.String(k8srds.TagClusterID), Value: aws.String("cluster")},
+				{Key: aws.String(k8srds.TagNamespace), Value: aws.String(namespace)},
+				{Key: aws.String(k8srds.TagName), Value: aws.String("source")},
+				{Key: aws.String(k8srds.TagUID), Value: aws.String("50urce")},
+			}
+		}
+	}
+	a := testActuator(backend)
+	db := testDatabase()
+	db.Spec.SnapshotSelector = &databasesv1.SnapshotSelector{SourceIdentifier: "source"}
+
+	// The newer snapshots belong to another namespace or to nobody
+	status, err := a.Reconcile(db, testReconciler(t), context.Background(), types.NamespacedName{Namespace: "default", Name: "pgsql"})
+	assert.NoError(t, err)
+	assert.Equal(t, "snapshot-0", status.RestoredSnapshot)
+}
+
+func TestReconcileConflict(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	backend.Instances["pgsql"] = &fake.Instance{DBInstance: rds.DBInstance{
+		DBInstanceArn:        aws.String("arn:aws:rds:us-east-1:123456789012:db:pgsql"),
+		DBInstanceIdentifier: aws.String("pgsql"),
+		DBInstanceStatus:     aws.String("available"),
+	}}
+	a := testActuator(backend, testSecret())
+	client := testReconciler(t)
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	// An instance created by someone else is never modified nor deleted
+	status, err := a.Reconcile(db, client, context.Background(), key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+
+	db.Status = status
+	status, err = a.Delete(db, client, context.Background(), key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+	assert.NotContains(t, backend.Operations(), "DeleteDBInstance")
+	assert.Len(t, backend.Instances, 1)
+}
+
+func TestReconcilePendingConflict(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	backend.Instances["pgsql"] = &fake.Instance{DBInstance: rds.DBInstance{
+		DBInstanceArn:        aws.String("arn:aws:rds:us-east-1:123456789012:db:pgsql"),
+		DBInstanceIdentifier: aws.String("pgsql"),
+		DBInstanceStatus:     aws.String("available"),
+	}}
+	a := testActuator(backend, testSecret())
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	// A pending object never created anything, the untagged instance is not adopted
+	db.Status = databasesv1.RdsStatus{State: databasesv1.StatePending, Conditions: []databasesv1.Condition{{Type: databasesv1.ConditionReady}}}
+	status, err := a.Reconcile(db, testReconciler(t), context.Background(), key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+	assert.NotContains(t, backend.Operations(), "AddTagsToResource")
+	assert.Error(t, checkTags(backend, "arn:aws:rds:us-east-1:123456789012:db:pgsql", "databases.tks.sh/uid", "8f9c0b0e"))
+}
+
+func TestSubnetGroupChange(t *testing.T) {
+	subnetGroup := func(backend *fake.Backend, uid string) {
+		arn := "arn:aws:rds:us-east-1:123456789012:subgrp:pgsql"
+		backend.SubnetGroups["pgsql"] = &rds.DBSubnetGroup{
+			DBSubnetGroupArn:  aws.String(arn),
+			DBSubnetGroupName: aws.String("pgsql"),
+			Subnets:           []rds.Subnet{{SubnetIdentifier: aws.String("subnet-old")}},
+		}
+		backend.Tags[arn] = []rds.Tag{{Key: aws.String(k8srds.TagUID), Value: aws.String(uid)}}
+	}
+	subnets := func(backend *fake.Backend) []string {
+		var ids []string
+		for _, s := range backend.SubnetGroups["pgsql"].Subnets {
+			ids = append(ids, aws.StringValue(s.SubnetIdentifier))
+		}
+		return ids
+	}
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	// The group of the object follows the configured subnets
+	backend := fake.NewBackend("us-east-1")
+	subnetGroup(backend, "8f9c0b0e")
+	_, err := testActuator(backend, testSecret()).Reconcile(db, testReconciler(t), context.Background(), key)
+	assert.NoError(t, err)
+	assert.Contains(t, backend.Operations(), "ModifyDBSubnetGroup")
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, subnets(backend))
+	assert.Len(t, backend.Instances, 1)
+
+	// The group of someone else is left alone and the instance is not created in the wrong subnets
+	backend = fake.NewBackend("us-east-1")
+	subnetGroup(backend, "0badc0de")
+	_, err = testActuator(backend, testSecret()).Reconcile(db, testReconciler(t), context.Background(), key)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not applied")
+	assert.NotContains(t, backend.Operations(), "ModifyDBSubnetGroup")
+	assert.Equal(t, []string{"subnet-old"}, subnets(backend))
+	assert.Empty(t, backend.Instances)
+}
+
+func TestReconcileAWSError(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	backend.Errors["CreateDBInstance"] = fake.Error("InsufficientDBInstanceCapacity", "No capacity")
+	a := testActuator(backend, testSecret())
+	client := testReconciler(t)
+	db := testDatabase()
+	key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+
+	status, err := a.Reconcile(db, client, context.Background(), key)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "InsufficientDBInstanceCapacity")
+	assert.Empty(t, backend.Instances)
+	assert.Empty(t, status.PasswordHash)
+
+	// The next reconciliation retries
+	delete(backend.Errors, "CreateDBInstance")
+	db.Status = status
+	_, err = a.Reconcile(db, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Len(t, backend.Instances, 1)
+}
+
+// checkTags fails unless the resource has the tag
+func checkTags(backend *fake.Backend, arn string, key string, value string) error {
+	tags, err := backend.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: aws.String(arn)}).Send(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, t := range tags.TagList {
+		if aws.StringValue(t.Key) == key && aws.StringValue(t.Value) == value {
+			return nil
+		}
+	}
+	return fake.Error("TagNotFound", "%v has no tag %v=%v", arn, key, value)
+}
+
+func TestAdoptOwnedByAnother(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	client := testReconciler(t)
+	ctx := context.Background()
+
+	owner := testDatabase()
+	_, err := a.Reconcile(owner, client, ctx, types.NamespacedName{Namespace: owner.Namespace, Name: owner.Name})
+	assert.NoError(t, err)
+	backend.Advance()
+	backend.Advance()
+
+	// Another object naming the instance in the adopt annotation never takes it over
+	thief := testDatabase()
+	thief.Name, thief.Namespace, thief.UID = "thief", "team-b", "0badc0de"
+	thief.Annotations = map[string]string{databasesv1.AdoptAnnotation: "pgsql"}
+	key := types.NamespacedName{Namespace: thief.Namespace, Name: thief.Name}
+	status, err := a.Reconcile(thief, client, ctx, key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+	assert.NoError(t, a.k8srds.VerifyOwnership(owner))
+
+	thief.Status = status
+	status, err = a.Delete(thief, client, ctx, key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+	assert.NotContains(t, backend.Operations(), "DeleteDBInstance")
+}
+
+func TestAdoptUntagged(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	backend.Instances["legacy"] = &fake.Instance{DBInstance: rds.DBInstance{
+		DBInstanceArn:        aws.String("arn:aws:rds:us-east-1:123456789012:db:legacy"),
+		DBInstanceIdentifier: aws.String("legacy"),
+		DBInstanceStatus:     aws.String("available"),
+		Endpoint:             &rds.Endpoint{Address: aws.String("legacy.c0ffee.us-east-1.rds.amazonaws.com"), Port: aws.Int64(5432)},
+	}}
+	backend.Tags["arn:aws:rds:us-east-1:123456789012:db:legacy"] = []rds.Tag{{Key: aws.String(k8srds.TagAdoptNamespaces), Value: aws.String("team-a default")}}
+	a := testActuator(backend, testSecret())
+	db := testDatabase()
+	db.Annotations = map[string]string{databasesv1.AdoptAnnotation: "legacy"}
+
+	status, err := a.Reconcile(db, testReconciler(t), context.Background(), types.NamespacedName{Namespace: db.Namespace, Name: db.Name})
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", status.InstanceIdentifier)
+	assert.NoError(t, a.k8srds.VerifyOwnership(db))
+}
+
+func TestAdoptUnauthorizedNamespace(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	for _, identifier := range []string{"untagged", "team-a"} {
+		backend.Instances[identifier] = &fake.Instance{DBInstance: rds.DBInstance{
+			DBInstanceArn:        aws.String("arn:aws:rds:us-east-1:123456789012:db:" + identifier),
+			DBInstanceIdentifier: aws.String(identifier),
+			DBInstanceStatus:     aws.String("available"),
+		}}
+	}
+	backend.Tags["arn:aws:rds:us-east-1:123456789012:db:team-a"] = []rds.Tag{{Key: aws.String(k8srds.TagAdoptNamespaces), Value: aws.String("team-a")}}
+	a := testActuator(backend, testSecret())
+
+	// Only the namespaces listed in the tag of the instance may adopt it
+	for _, identifier := range []string{"untagged", "team-a"} {
+		db := testDatabase()
+		db.Annotations = map[string]string{databasesv1.AdoptAnnotation: identifier}
+		status, err := a.Reconcile(db, testReconciler(t), context.Background(), types.NamespacedName{Namespace: db.Namespace, Name: db.Name})
+		assert.Error(t, err, identifier)
+		assert.Contains(t, err.Error(), k8srds.TagAdoptNamespaces, identifier)
+		assert.Equal(t, databasesv1.StateConflict, status.State, identifier)
+		assert.Error(t, checkTags(backend, "arn:aws:rds:us-east-1:123456789012:db:"+identifier, "databases.tks.sh/uid", "8f9c0b0e"), identifier)
+	}
+	assert.NotContains(t, backend.Operations(), "AddTagsToResource")
+	assert.NotContains(t, backend.Operations(), "ModifyDBInstance")
+}
+
+func TestUpgradedUntaggedInstance(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	backend.Instances["pgsql"] = &fake.Instance{DBInstance: rds.DBInstance{
+		AllocatedStorage:     aws.Int64(20),
+		DBInstanceArn:        aws.String("arn:aws:rds:us-east-1:123456789012:db:pgsql"),
+		DBInstanceClass:      aws.String("db.t3.micro"),
+		DBInstanceIdentifier: aws.String("pgsql"),
+		DBInstanceStatus:     aws.String("available"),
+		Endpoint:             &rds.Endpoint{Address: aws.String("pgsql.c0ffee.us-east-1.rds.amazonaws.com"), Port: aws.Int64(5432)},
+	}}
+	a := testActuator(backend, testSecret())
+	client := testReconciler(t)
+	key := types.NamespacedName{Namespace: "default", Name: "pgsql"}
+
+	// Objects created before the ownership tags only have a state, the instance is tagged on
+	// the first reconciliation
+	db := testDatabase()
+	db.Status = databasesv1.RdsStatus{State: databasesv1.StateAvailable, Message: "Database reconciled"}
+	status, err := a.Reconcile(db, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.NotEqual(t, databasesv1.StateConflict, status.State)
+	assert.NoError(t, checkTags(backend, "arn:aws:rds:us-east-1:123456789012:db:pgsql", "databases.tks.sh/uid", "8f9c0b0e"))
+
+	// So are the ones deleted right after the upgrade
+	backend.Tags = map[string][]rds.Tag{}
+	db = testDatabase()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicyDelete
+	db.Status = databasesv1.RdsStatus{State: databasesv1.StateAvailable}
+	status, err = a.Delete(db, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "Deleting", status.Message)
+	assert.Contains(t, backend.Operations(), "DeleteDBInstance")
+}
+
+func TestLegacyIdentifier(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	a.k8srds.IdentifierTemplate = "{{.ClusterID}}-{{.Namespace}}-{{.Name}}"
+	client := testReconciler(t)
+	ctx := context.Background()
+
+	created := testDatabase()
+	created.Status.InstanceIdentifier = "pgsql"
+	_, err := a.Reconcile(created, client, ctx, types.NamespacedName{Namespace: "default", Name: "pgsql"})
+	assert.NoError(t, err)
+	backend.Advance()
+	backend.Advance()
+
+	// An object created before the identifier was persisted keeps managing its instance
+	db := testDatabase()
+	db.Status = databasesv1.RdsStatus{State: databasesv1.StateAvailable}
+	status, err := a.Reconcile(db, client, ctx, types.NamespacedName{Namespace: "default", Name: "pgsql"})
+	assert.NoError(t, err)
+	assert.Equal(t, "pgsql", status.InstanceIdentifier)
+	assert.Len(t, backend.Instances, 1)
+}
+
+func TestRestoreFromIdentifier(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	ctx := context.Background()
+
+	// The source belongs to another namespace
+	source := testDatabase()
+	source.Namespace, source.UID = "team-a", "50urce"
+	source.Spec.GeneratePassword = true
+	_, err := a.Reconcile(source, testReconciler(t), ctx, types.NamespacedName{Namespace: "team-a", Name: "pgsql"})
+	assert.NoError(t, err)
+	backend.Instances["hand-made"] = &fake.Instance{DBInstance: rds.DBInstance{
+		DBInstanceArn:        aws.String("arn:aws:rds:us-east-1:123456789012:db:hand-made"),
+		DBInstanceIdentifier: aws.String("hand-made"),
+		DBInstanceStatus:     aws.String("available"),
+	}}
+
+	restore := func(identifier string, objects ...runtime.Object) error {
+		db := testDatabase()
+		db.Name = "copy"
+		db.Spec.RestoreFrom = &databasesv1.RestoreSource{SourceIdentifier: identifier}
+		_, err := a.Reconcile(db, testReconciler(t, objects...), ctx, types.NamespacedName{Namespace: "default", Name: "copy"})
+		return err
+	}
+
+	// Instances of another namespace, or not managed by the cluster, can't be cloned
+	err = restore("pgsql", source)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "replica-namespaces")
+	err = restore("hand-made")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not managed by this cluster")
+	err = restore("missing")
+	assert.Error(t, err)
+	assert.NotContains(t, backend.Operations(), "RestoreDBInstanceToPointInTime")
+
+	// Unless the source allows it
+	source.Annotations = map[string]string{databasesv1.ReplicaNamespacesAnnotation: "default"}
+	assert.NoError(t, restore("pgsql", source))
+	assert.Contains(t, backend.Operations(), "RestoreDBInstanceToPointInTime")
+}
+
+func TestDeletionPolicies(t *testing.T) {
+	cases := []struct {
+		name       string
+		spec       databasesv1.DeletionPolicy
+		annotation string
+		deleted    bool
+	}{
+		{name: "retain", spec: databasesv1.DeletionPolicyRetain},
+		{name: "orphan", spec: databasesv1.DeletionPolicyOrphan},
+		{name: "annotation retains", spec: databasesv1.DeletionPolicyDelete, annotation: "Retain"},
+		{name: "annotation deletes", spec: databasesv1.DeletionPolicyRetain, annotation: "Delete", deleted: true},
+	}
+	for _, c := range cases {
+		backend := fake.NewBackend("us-east-1")
+		a := testActuator(backend)
+		client := testReconciler(t)
+		db := testDatabase()
+		db.Spec.GeneratePassword = true
+		db.Spec.DeletionPolicy = c.spec
+		if c.annotation != "" {
+			db.Annotations = map[string]string{databasesv1.DeletionPolicyAnnotation: c.annotation}
+		}
+		reconcileDatabase(t, a, backend, client, db)
+		secret, _ := a.kubeClient.Client.CoreV1().Secrets("default").Get("pgsql-password", metav1.GetOptions{})
+		assert.Len(t, secret.OwnerReferences, 1, c.name)
+
+		backend.Operations()
+		status, err := a.Delete(db, client, context.Background(), types.NamespacedName{Namespace: "default", Name: "pgsql"})
+		assert.NoError(t, err, c.name)
+		if c.deleted {
+			assert.Contains(t, backend.Operations(), "DeleteDBInstance", c.name)
+			continue
+		}
+
+		// Retained: the instance stays, the password secret is released and the service removed
+		assert.Equal(t, databasesv1.StateDeleted, status.State, c.name)
+		assert.NotContains(t, backend.Operations(), "DeleteDBInstance", c.name)
+		assert.NotNil(t, backend.Instances["pgsql"], c.name)
+		secret, err = a.kubeClient.Client.CoreV1().Secrets("default").Get("pgsql-password", metav1.GetOptions{})
+		assert.NoError(t, err, c.name)
+		assert.Empty(t, secret.OwnerReferences, c.name)
+		_, err = a.kubeClient.Client.CoreV1().Services("default").Get("pgsql", metav1.GetOptions{})
+		assert.Error(t, err, c.name)
+	}
+}
+
+func TestInvalidDeletionPolicy(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	client := testReconciler(t)
+	db := testDatabase()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicyDelete
+	reconcileDatabase(t, a, backend, client, db)
+
+	// A typo never falls back to a policy deleting the instance
+	db.Annotations = map[string]string{databasesv1.DeletionPolicyAnnotation: "retain"}
+	backend.Operations()
+	status, err := a.Delete(db, client, context.Background(), types.NamespacedName{Namespace: "default", Name: "pgsql"})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+	assert.True(t, databasesv1.IsConditionTrue(status.Conditions, databasesv1.ConditionDegraded))
+	assert.NotContains(t, backend.Operations(), "DeleteDBInstance")
+	assert.NotNil(t, backend.Instances["pgsql"])
+	_, err = a.kubeClient.Client.CoreV1().Services("default").Get("pgsql", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -7,20 +7,28 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/pkg/errors"
 
 	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/util"
 )
 
 // AWS ...
 type AWS struct {
-	RDS            *rds.Client
-	EC2            *ec2.Client
-	Subnets        []string
-	SecurityGroups []string
+	RDS        RDSAPI
+	EC2        EC2API
+	CloudWatch CloudWatchAPI
+	// Config of the clients, holding their region
+	Config aws.Config
+	// NewRDS builds the RDS client of another region, rds.New when nil
+	NewRDS             func(cfg aws.Config) RDSAPI
+	Subnets            []string
+	PublicSubnets      []string
+	SecurityGroups     []string
+	ClusterID          string
+	IdentifierTemplate string
 }
 
 // CreateDatabase ...
@@ -32,7 +40,8 @@ func (a *AWS) CreateDatabase(db *databasesv1.Rds, password string) error {
 		return err
 	}
 
-	input := convertSpecToInputCreate(db, subnetName, a.SecurityGroups, password)
+	input := convertSpecToInputCreate(db, a.InstanceIdentifier(db), subnetName, a.securityGroups(db.Spec.VpcSecurityGroupIds), password)
+	input.Tags = a.withOwnerTags(db.Spec.Tags, db)
 
 	// search for the instance
 	log.Printf("Trying to find db instance %v\n", db.Spec.DBName)
@@ -54,8 +63,8 @@ func (a *AWS) CreateDatabase(db *databasesv1.Rds, password string) error {
 	return nil
 }
 
-// RestoreDatabase ...
-func (a *AWS) RestoreDatabase(db *databasesv1.Rds) error {
+// RestoreDatabase restores the given snapshot
+func (a *AWS) RestoreDatabase(db *databasesv1.Rds, snapshot string) error {
 	ctx := context.Background()
 	log.Println("Trying to find the correct subnets")
 	subnetName, err := a.ensureSubnets(db)
@@ -63,19 +72,9 @@ func (a *AWS) RestoreDatabase(db *databasesv1.Rds) error {
 		return err
 	}
 
-	var securityGroups []string
-	if len(db.Spec.VpcSecurityGroupIds) > 0 {
-		securityGroups = append(securityGroups, db.Spec.VpcSecurityGroupIds)
-	} else {
-		securityGroups = a.SecurityGroups
-	}
-
-	input := convertSpecToInputRestore(db, subnetName, securityGroups)
-
-	fmt.Printf("%v\n", subnetName)
-	fmt.Printf("%v\n", a.SecurityGroups)
-	fmt.Printf("%v\n", input)
-	//panic("Nope")
+	securityGroups := a.securityGroups(db.Spec.VpcSecurityGroupIds)
+	input := convertSpecToInputRestore(db, a.InstanceIdentifier(db), snapshot, subnetName, securityGroups)
+	input.Tags = a.withOwnerTags(db.Spec.Tags, db)
 
 	// search for the instance
 	log.Printf("Trying to find db instance %v\n", db.Spec.DBName)
@@ -98,25 +97,35 @@ func (a *AWS) RestoreDatabase(db *databasesv1.Rds) error {
 	return nil
 }
 
-// Get Endpoint
-func (a *AWS) GetEndpoint(db *databasesv1.Rds) (string, error) {
-	log.Println("Trying to find the correct subnets")
+// RestoreDatabaseToPointInTime restores the source instance at the time of the spec
+func (a *AWS) RestoreDatabaseToPointInTime(db *databasesv1.Rds, sourceIdentifier string) error {
 	subnetName, err := a.ensureSubnets(db)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	var securityGroups []string
-	if len(db.Spec.VpcSecurityGroupIds) > 0 {
-		securityGroups = append(securityGroups, db.Spec.VpcSecurityGroupIds)
-	} else {
-		securityGroups = a.SecurityGroups
+	input := convertSpecToInputPointInTime(db, a.InstanceIdentifier(db), sourceIdentifier, subnetName, a.securityGroups(db.Spec.VpcSecurityGroupIds))
+	input.Tags = a.withOwnerTags(db.Spec.Tags, db)
+
+	log.Printf("Restoring db instance %v to %v from %v\n", *input.TargetDBInstanceIdentifier, restoreTime(input), sourceIdentifier)
+	_, err = a.RDS.RestoreDBInstanceToPointInTimeRequest(input).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "RestoreDBInstanceToPointInTime")
 	}
+	return nil
+}
 
-	input := convertSpecToInputRestore(db, subnetName, securityGroups)
+func restoreTime(input *rds.RestoreDBInstanceToPointInTimeInput) string {
+	if input.RestoreTime == nil {
+		return "the latest restorable time"
+	}
+	return input.RestoreTime.Format(time.RFC3339)
+}
 
+// Get Endpoint
+func (a *AWS) GetEndpoint(db *databasesv1.Rds) (string, error) {
 	// Get the newly created database so we can get the endpoint
-	dbHostname, err := getEndpoint(input.DBInstanceIdentifier, a.RDS)
+	dbHostname, err := getEndpoint(aws.String(a.InstanceIdentifier(db)), a.RDS)
 	if err != nil {
 		return "", err
 	}
@@ -134,13 +143,25 @@ func (a *AWS) GetStatus(db *databasesv1.Rds) (string, error) {
 	instance, err := a.getInstance(db)
 	if err != nil {
 		if err.Error() == "DBInstanceNotFound" {
-			return "pending", nil
+			return databasesv1.StatePending, nil
 		}
-		return "error", err
+		return databasesv1.StateError, err
 	}
 	return *instance.DBInstanceStatus, nil
 }
 
+// DescribeDatabase returns the instance at AWS, nil if it does not exist
+func (a *AWS) DescribeDatabase(db *databasesv1.Rds) (*rds.DBInstance, error) {
+	instance, err := a.getInstance(db)
+	if err != nil {
+		if err.Error() == "DBInstanceNotFound" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return instance, nil
+}
+
 func (a *AWS) PendingReboot(db *databasesv1.Rds) (bool, error) {
 	_, err := a.getInstance(db)
 	if err != nil {
@@ -156,20 +177,7 @@ func (a *AWS) PendingReboot(db *databasesv1.Rds) (bool, error) {
 }
 
 func (a *AWS) getInstance(db *databasesv1.Rds) (*rds.DBInstance, error) {
-	subnetName, err := a.ensureSubnets(db)
-	if err != nil {
-		return nil, err
-	}
-
-	var securityGroups []string
-	if len(db.Spec.VpcSecurityGroupIds) > 0 {
-		securityGroups = append(securityGroups, db.Spec.VpcSecurityGroupIds)
-	} else {
-		securityGroups = a.SecurityGroups
-	}
-
-	input := convertSpecToInputRestore(db, subnetName, securityGroups)
-	instance, err := a.RDS.DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: input.DBInstanceIdentifier}).Send(context.Background())
+	instance, err := a.RDS.DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(a.InstanceIdentifier(db))}).Send(context.Background())
 	if err != nil {
 		if awsErr, ok := err.(awserr.Error); ok {
 			return nil, fmt.Errorf(awsErr.Code())
@@ -182,67 +190,123 @@ func (a *AWS) getInstance(db *databasesv1.Rds) (*rds.DBInstance, error) {
 	return &instance.DescribeDBInstancesOutput.DBInstances[0], nil
 }
 
-// RebootDatabase
-func (a *AWS) RebootDatabase(db *databasesv1.Rds) error {
-	ctx := context.Background()
-	log.Println("Trying to find the correct subnets")
-	subnetName, err := a.ensureSubnets(db)
+// ModifyDatabase applies the differences between the spec and the running instance
+// and returns the name of the fields that were changed
+func (a *AWS) ModifyDatabase(db *databasesv1.Rds) ([]string, error) {
+	instance, err := a.getInstance(db)
+	if err != nil {
+		return nil, err
+	}
+
+	input, changes := convertSpecToInputModify(db, instance)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	log.Printf("Modifying db instance %v: %v\n", *input.DBInstanceIdentifier, changes)
+	_, err = a.RDS.ModifyDBInstanceRequest(input).Send(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("ModifyDBInstance for db instance %v", *input.DBInstanceIdentifier))
+	}
+
+	return changes, nil
+}
+
+// ModifyPassword sets a new master password, it is always applied immediately
+func (a *AWS) ModifyPassword(db *databasesv1.Rds, password string) error {
+	instance, err := a.getInstance(db)
 	if err != nil {
 		return err
 	}
 
-	var securityGroups []string
-	if len(db.Spec.VpcSecurityGroupIds) > 0 {
-		securityGroups = append(securityGroups, db.Spec.VpcSecurityGroupIds)
-	} else {
-		securityGroups = a.SecurityGroups
+	log.Printf("Modifying master password of db instance %v\n", *instance.DBInstanceIdentifier)
+	_, err = a.RDS.ModifyDBInstanceRequest(&rds.ModifyDBInstanceInput{
+		ApplyImmediately:     aws.Bool(true),
+		DBInstanceIdentifier: instance.DBInstanceIdentifier,
+		MasterUserPassword:   aws.String(password),
+	}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("ModifyDBInstance for db instance %v", *instance.DBInstanceIdentifier))
 	}
 
-	input := convertSpecToInputRestore(db, subnetName, securityGroups)
+	return nil
+}
+
+// RebootDatabase
+func (a *AWS) RebootDatabase(db *databasesv1.Rds) error {
+	ctx := context.Background()
+	identifier := aws.String(a.InstanceIdentifier(db))
 
-	log.Printf("Reboot instance after restoring %v to apply params\n", *input.DBInstanceIdentifier)
-	r := &rds.RebootDBInstanceInput{DBInstanceIdentifier: input.DBInstanceIdentifier}
-	_, err = a.RDS.RebootDBInstanceRequest(r).Send(ctx)
+	log.Printf("Reboot instance after restoring %v to apply params\n", *identifier)
+	r := &rds.RebootDBInstanceInput{DBInstanceIdentifier: identifier}
+	_, err := a.RDS.RebootDBInstanceRequest(r).Send(ctx)
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("something went wrong in RebootDBInstanceRequest for db instance %v", input.DBInstanceIdentifier))
+		return errors.Wrap(err, fmt.Sprintf("something went wrong in RebootDBInstanceRequest for db instance %v", *identifier))
 	}
 
 	return nil
 }
 
-// DeleteDatabase ...
-func (a *AWS) DeleteDatabase(db *databasesv1.Rds) error {
+// DeleteDatabase deletes the instance, with the given final snapshot unless it is empty
+func (a *AWS) DeleteDatabase(db *databasesv1.Rds, finalSnapshotIdentifier string) error {
+	_, err := a.deleteInstance(a.InstanceIdentifier(db), finalSnapshotIdentifier)
+
+	// delete subnetgroup only for creation process
+	//if db.Spec.DBSnapshotIdentifier == "" {
+	//	log.Printf("SubnetGroup %v to be deleted\n", db.Spec.DBSubnetGroupName)
+	//	a.deleteSubnetGroup(db)
+	//}
+
+	return err
+}
+
+// FinalSnapshotIdentifier returns the identifier of a new final snapshot of the instance
+func FinalSnapshotIdentifier(dbName string, t time.Time) string {
+	return fmt.Sprintf("kube-db-%v-%v", dbName, t.Format("20060102150405"))
+}
+
+func (a *AWS) deleteInstance(dbName string, finalSnapshotIdentifier string) (string, error) {
 	ctx := context.Background()
 	// delete the database instance
 	svc := a.RDS
-	dbName := db.Name
-	t := time.Now()
-	finalSnapshotIdentifier := fmt.Sprintf("kube-db-%v-%v", dbName, t.Format("20060102150405"))
-
-	log.Printf("DB: %v to be deleted, with finalSnapshot: %v\n", dbName, finalSnapshotIdentifier)
-	res := svc.DeleteDBInstanceRequest(&rds.DeleteDBInstanceInput{
-		DBInstanceIdentifier:      aws.String(dbName),
-		FinalDBSnapshotIdentifier: aws.String(finalSnapshotIdentifier),
-	})
+	input := &rds.DeleteDBInstanceInput{DBInstanceIdentifier: aws.String(dbName)}
+	if finalSnapshotIdentifier == "" {
+		log.Printf("DB: %v to be deleted, without finalSnapshot\n", dbName)
+		input.SkipFinalSnapshot = aws.Bool(true)
+	} else {
+		log.Printf("DB: %v to be deleted, with finalSnapshot: %v\n", dbName, finalSnapshotIdentifier)
+		input.FinalDBSnapshotIdentifier = aws.String(finalSnapshotIdentifier)
+	}
+
+	res := svc.DeleteDBInstanceRequest(input)
 	_, err := res.Send(ctx)
 	if err != nil {
 		if awsErr, ok := err.(awserr.Error); ok {
 			if awsErr.Code() == "DBInstanceNotFound" {
-				return nil
+				return "", nil
 			}
 		}
 
 		log.Println(errors.Wrap(err, fmt.Sprintf("unable to delete database %v", dbName)))
-		return err
+		return "", err
 	}
 
-	// delete subnetgroup only for creation process
-	//if db.Spec.DBSnapshotIdentifier == "" {
-	//	log.Printf("SubnetGroup %v to be deleted\n", db.Spec.DBSubnetGroupName)
-	//	a.deleteSubnetGroup(db)
-	//}
+	return finalSnapshotIdentifier, nil
+}
 
-	return nil
+// DescribeSnapshot returns the snapshot with the given identifier, nil if it does not exist
+func (a *AWS) DescribeSnapshot(identifier string) (*rds.DBSnapshot, error) {
+	res, err := a.RDS.DescribeDBSnapshotsRequest(&rds.DescribeDBSnapshotsInput{DBSnapshotIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to describe snapshot %v", identifier))
+	}
+	if len(res.DBSnapshots) == 0 {
+		return nil, nil
+	}
+	return &res.DBSnapshots[0], nil
 }
 
 // deleteSubnetGroup ...
@@ -261,39 +325,107 @@ func (a *AWS) deleteSubnetGroup(db *databasesv1.Rds) {
 }
 
 func (a *AWS) ensureSubnets(db *databasesv1.Rds) (string, error) {
+	tags := append([]rds.Tag{{Key: aws.String("DBName"), Value: aws.String(db.Spec.DBName)}}, a.OwnerTags(db)...)
+	return a.ensureSubnetGroup(db.Spec.DBSubnetGroupName, a.subnets(db.Spec.SubnetIDs, db.Spec.PubliclyAccessible), tags)
+}
+
+// subnets returns the subnets of the spec, the public or private ones of the configuration by default.
+// Public instances use the private subnets when the configuration has no public ones, like before
+// they existed
+func (a *AWS) subnets(ids []string, public bool) []string {
+	if len(ids) > 0 {
+		return ids
+	}
+	if public && len(a.PublicSubnets) > 0 {
+		return a.PublicSubnets
+	}
+	if public {
+		log.Println("No public subnets configured, using the subnets", a.Subnets)
+	}
+	return a.Subnets
+}
+
+// ensureSubnetGroup creates the subnet group when it does not exist. An existing group is moved to
+// the subnets when they differ, see reconcileSubnetGroup
+func (a *AWS) ensureSubnetGroup(subnetName string, subnets []string, tags []rds.Tag) (string, error) {
 	ctx := context.Background()
-	if len(a.Subnets) == 0 {
+	if len(subnets) == 0 {
 		log.Println("No subnets passed, will try to find a default")
 	}
 	subnetDescription := "subnet kube-db"
-	subnetName := db.Spec.DBSubnetGroupName
 
 	svc := a.RDS
 
 	sf := &rds.DescribeDBSubnetGroupsInput{DBSubnetGroupName: aws.String(subnetName)}
 	res := svc.DescribeDBSubnetGroupsRequest(sf)
-	_, err := res.Send(ctx)
-	log.Println("Subnets:", a.Subnets)
+	groups, err := res.Send(ctx)
+	log.Println("Subnets:", subnets)
 	if err != nil {
 		// assume we didn't find it..
 		subnet := &rds.CreateDBSubnetGroupInput{
 			DBSubnetGroupDescription: aws.String(subnetDescription),
 			DBSubnetGroupName:        aws.String(subnetName),
-			SubnetIds:                a.Subnets,
-			Tags:                     []rds.Tag{{Key: aws.String("DBName"), Value: aws.String(db.Spec.DBName)}},
+			SubnetIds:                subnets,
+			Tags:                     tags,
 		}
 		res := svc.CreateDBSubnetGroupRequest(subnet)
 		_, err := res.Send(ctx)
 		if err != nil {
 			return "", errors.Wrap(err, "CreateDBSubnetGroup")
 		}
-	} else {
-		log.Printf("Moving on seems like %v exists", subnetName)
+	} else if len(subnets) > 0 && len(groups.DBSubnetGroups) > 0 {
+		return subnetName, a.reconcileSubnetGroup(&groups.DBSubnetGroups[0], subnets, tags)
 	}
 	return subnetName, nil
 }
 
-func getEndpoint(dbName *string, svc *rds.Client) (string, error) {
+// reconcileSubnetGroup moves the group to the subnets when it is owned by the object, the group of
+// someone else with other subnets fails as the change can't be applied
+func (a *AWS) reconcileSubnetGroup(group *rds.DBSubnetGroup, subnets []string, tags []rds.Tag) error {
+	name := aws.StringValue(group.DBSubnetGroupName)
+	var current []string
+	for _, s := range group.Subnets {
+		current = append(current, aws.StringValue(s.SubnetIdentifier))
+	}
+	if sameSubnets(current, subnets) {
+		log.Printf("Moving on seems like %v exists", name)
+		return nil
+	}
+
+	res, err := a.RDS.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: group.DBSubnetGroupArn}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to list the tags of subnet group %v", name))
+	}
+	if uid := tagValues(tags)[TagUID]; uid == "" || tagValues(res.TagList)[TagUID] != uid {
+		return fmt.Errorf("subnet group %v has the subnets %v instead of %v and is not managed by this object, the change is not applied", name, current, subnets)
+	}
+
+	log.Printf("Moving subnet group %v from the subnets %v to %v\n", name, current, subnets)
+	_, err = a.RDS.ModifyDBSubnetGroupRequest(&rds.ModifyDBSubnetGroupInput{
+		DBSubnetGroupDescription: group.DBSubnetGroupDescription,
+		DBSubnetGroupName:        group.DBSubnetGroupName,
+		SubnetIds:                subnets,
+	}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "ModifyDBSubnetGroup")
+	}
+	return nil
+}
+
+// sameSubnets tells if both lists hold the same subnets, in any order
+func sameSubnets(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range b {
+		if !util.Contains(a, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func getEndpoint(dbName *string, svc RDSAPI) (string, error) {
 	instance, err := svc.
 		DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: dbName}).
 		Send(context.Background())
@@ -312,15 +444,15 @@ func getEndpoint(dbName *string, svc *rds.Client) (string, error) {
 	return dbHostname, nil
 }
 
-func convertSpecToInputRestore(v *databasesv1.Rds, subnetName string, securityGroups []string) *rds.RestoreDBInstanceFromDBSnapshotInput {
+func convertSpecToInputRestore(v *databasesv1.Rds, identifier string, snapshot string, subnetName string, securityGroups []string) *rds.RestoreDBInstanceFromDBSnapshotInput {
 	return &rds.RestoreDBInstanceFromDBSnapshotInput{
 		AvailabilityZone:     aws.String(v.Spec.AvailabilityZone),
 		CopyTagsToSnapshot:   aws.Bool(v.Spec.CopyTagsToSnapshot),
 		DBInstanceClass:      aws.String(v.Spec.Class),
-		DBInstanceIdentifier: aws.String(v.Name),
+		DBInstanceIdentifier: aws.String(identifier),
 		DBName:               aws.String(v.Spec.DBName),
 		DBParameterGroupName: aws.String(v.Spec.DBParameterGroupName),
-		DBSnapshotIdentifier: aws.String(v.Spec.DBSnapshotIdentifier),
+		DBSnapshotIdentifier: aws.String(snapshot),
 		DBSubnetGroupName:    aws.String(subnetName),
 		Engine:               aws.String(v.Spec.Engine),
 		LicenseModel:         aws.String("license-included"),
@@ -332,13 +464,45 @@ func convertSpecToInputRestore(v *databasesv1.Rds, subnetName string, securityGr
 	}
 }
 
-func convertSpecToInputCreate(v *databasesv1.Rds, subnetName string, securityGroups []string, password string) *rds.CreateDBInstanceInput {
+func convertSpecToInputPointInTime(v *databasesv1.Rds, identifier string, sourceIdentifier string, subnetName string, securityGroups []string) *rds.RestoreDBInstanceToPointInTimeInput {
+	input := &rds.RestoreDBInstanceToPointInTimeInput{
+		CopyTagsToSnapshot:         aws.Bool(v.Spec.CopyTagsToSnapshot),
+		DBInstanceClass:            aws.String(v.Spec.Class),
+		DBSubnetGroupName:          aws.String(subnetName),
+		MultiAZ:                    aws.Bool(v.Spec.MultiAZ),
+		PubliclyAccessible:         aws.Bool(v.Spec.PubliclyAccessible),
+		SourceDBInstanceIdentifier: aws.String(sourceIdentifier),
+		Tags:                       createTags(v.Spec.Tags),
+		TargetDBInstanceIdentifier: aws.String(identifier),
+		VpcSecurityGroupIds:        securityGroups,
+	}
+	if restore := v.Spec.RestoreFrom; restore != nil && restore.RestoreTime != nil {
+		input.RestoreTime = aws.Time(restore.RestoreTime.UTC())
+	} else {
+		input.UseLatestRestorableTime = aws.Bool(true)
+	}
+	if v.Spec.AvailabilityZone != "" && !v.Spec.MultiAZ {
+		input.AvailabilityZone = aws.String(v.Spec.AvailabilityZone)
+	}
+	if v.Spec.DBParameterGroupName != "" {
+		input.DBParameterGroupName = aws.String(v.Spec.DBParameterGroupName)
+	}
+	if v.Spec.StorageType != "" {
+		input.StorageType = aws.String(v.Spec.StorageType)
+	}
+	if v.Spec.Iops > 0 {
+		input.Iops = aws.Int64(v.Spec.Iops)
+	}
+	return input
+}
+
+func convertSpecToInputCreate(v *databasesv1.Rds, identifier string, subnetName string, securityGroups []string, password string) *rds.CreateDBInstanceInput {
 	input := &rds.CreateDBInstanceInput{
 		AllocatedStorage:      aws.Int64(v.Spec.Size),
 		AvailabilityZone:      aws.String(v.Spec.AvailabilityZone),
 		BackupRetentionPeriod: aws.Int64(v.Spec.BackupRetentionPeriod),
 		DBInstanceClass:       aws.String(v.Spec.Class),
-		DBInstanceIdentifier:  aws.String(v.Name),
+		DBInstanceIdentifier:  aws.String(identifier),
 		DBName:                aws.String(v.Spec.DBName),
 		DBParameterGroupName:  aws.String(v.Spec.DBParameterGroupName),
 		DBSubnetGroupName:     aws.String(subnetName),
@@ -361,6 +525,99 @@ func convertSpecToInputCreate(v *databasesv1.Rds, subnetName string, securityGro
 	return input
 }
 
+// convertSpecToInputModify compares the spec against the instance, taking the pending
+// modifications into account, so a change is only requested once
+func convertSpecToInputModify(v *databasesv1.Rds, instance *rds.DBInstance) (*rds.ModifyDBInstanceInput, []string) {
+	input := &rds.ModifyDBInstanceInput{
+		ApplyImmediately:     aws.Bool(v.Spec.ApplyImmediately),
+		DBInstanceIdentifier: instance.DBInstanceIdentifier,
+	}
+	var changes []string
+
+	pending := instance.PendingModifiedValues
+	if pending == nil {
+		pending = &rds.PendingModifiedValues{}
+	}
+
+	if v.Spec.Class != "" && v.Spec.Class != stringValue(pending.DBInstanceClass, instance.DBInstanceClass) {
+		input.DBInstanceClass = aws.String(v.Spec.Class)
+		changes = append(changes, "class")
+	}
+	// Storage can only grow on RDS, shrinking is ignored
+	if v.Spec.Size > int64Value(pending.AllocatedStorage, instance.AllocatedStorage) {
+		input.AllocatedStorage = aws.Int64(v.Spec.Size)
+		changes = append(changes, "size")
+	}
+	if v.Spec.Iops > 0 && v.Spec.Iops != int64Value(pending.Iops, instance.Iops) {
+		input.Iops = aws.Int64(v.Spec.Iops)
+		changes = append(changes, "iops")
+	}
+	if v.Spec.MultiAZ != boolValue(pending.MultiAZ, instance.MultiAZ) {
+		input.MultiAZ = aws.Bool(v.Spec.MultiAZ)
+		changes = append(changes, "multiaz")
+	}
+	if v.Spec.StorageType != "" && v.Spec.StorageType != stringValue(pending.StorageType, instance.StorageType) {
+		input.StorageType = aws.String(v.Spec.StorageType)
+		changes = append(changes, "storageType")
+	}
+	// Zero is the spec default, so only an explicit retention period is enforced
+	if v.Spec.BackupRetentionPeriod > 0 && v.Spec.BackupRetentionPeriod != int64Value(pending.BackupRetentionPeriod, instance.BackupRetentionPeriod) {
+		input.BackupRetentionPeriod = aws.Int64(v.Spec.BackupRetentionPeriod)
+		changes = append(changes, "backupRetentionPeriod")
+	}
+	if v.Spec.DBParameterGroupName != "" && !hasParameterGroup(instance, v.Spec.DBParameterGroupName) {
+		input.DBParameterGroupName = aws.String(v.Spec.DBParameterGroupName)
+		changes = append(changes, "parameterGroup")
+	}
+
+	// Iops must be sent along a storage type change to io1
+	if input.StorageType != nil && input.Iops == nil && v.Spec.Iops > 0 {
+		input.Iops = aws.Int64(v.Spec.Iops)
+	}
+
+	return input, changes
+}
+
+func hasParameterGroup(instance *rds.DBInstance, name string) bool {
+	for _, pg := range instance.DBParameterGroups {
+		if pg.DBParameterGroupName != nil && *pg.DBParameterGroupName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// stringValue returns the pending value when there is one, the current value otherwise
+func stringValue(pending *string, current *string) string {
+	if pending != nil {
+		return *pending
+	}
+	if current != nil {
+		return *current
+	}
+	return ""
+}
+
+func int64Value(pending *int64, current *int64) int64 {
+	if pending != nil {
+		return *pending
+	}
+	if current != nil {
+		return *current
+	}
+	return 0
+}
+
+func boolValue(pending *bool, current *bool) bool {
+	if pending != nil {
+		return *pending
+	}
+	if current != nil {
+		return *current
+	}
+	return false
+}
+
 func createTags(t map[string]string) []rds.Tag {
 	var tags []rds.Tag
 
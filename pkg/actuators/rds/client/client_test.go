@@ -2,16 +2,20 @@ package client
 
 import (
 	"testing"
+	"time"
 
-	"github.com/cloud104/k8s-rds/crd"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestConvertSpecToInput(t *testing.T) {
-	db := &crd.Database{
-		Spec: crd.DatabaseSpec{
+	db := &databasesv1.Rds{
+		Spec: databasesv1.RdsSpec{
 			DBName:             "mydb",
 			Engine:             "postgres",
 			Username:           "myuser",
@@ -25,8 +29,9 @@ func TestConvertSpecToInput(t *testing.T) {
 			Password:           v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "password"}, Key: "mypassword"},
 		},
 	}
-	i := convertSpecToInputCreate(db, "mysubnet", []string{"sg-1234", "sg-4321"}, "mypassword")
+	i := convertSpecToInputCreate(db, "default-mydb", "mysubnet", []string{"sg-1234", "sg-4321"}, "mypassword")
 	assert.Equal(t, "mydb", *i.DBName)
+	assert.Equal(t, "default-mydb", *i.DBInstanceIdentifier)
 	assert.Equal(t, "postgres", *i.Engine)
 	assert.Equal(t, "mypassword", *i.MasterUserPassword)
 	assert.Equal(t, "myuser", *i.MasterUsername)
@@ -39,3 +44,90 @@ func TestConvertSpecToInput(t *testing.T) {
 	assert.Equal(t, "bad", *i.StorageType)
 	assert.Equal(t, int64(1000), *i.Iops)
 }
+
+func TestConvertSpecToInputModify(t *testing.T) {
+	db := &databasesv1.Rds{
+		Spec: databasesv1.RdsSpec{
+			ApplyImmediately:      true,
+			Class:                 "db.t2.large",
+			Size:                  200,
+			MultiAZ:               true,
+			StorageType:           "gp2",
+			BackupRetentionPeriod: 7,
+			DBParameterGroupName:  "custom-postgres10",
+		},
+	}
+	instance := &rds.DBInstance{
+		DBInstanceIdentifier:  aws.String("mydb"),
+		DBInstanceClass:       aws.String("db.t2.micro"),
+		AllocatedStorage:      aws.Int64(100),
+		MultiAZ:               aws.Bool(false),
+		StorageType:           aws.String("gp2"),
+		BackupRetentionPeriod: aws.Int64(7),
+		DBParameterGroups:     []rds.DBParameterGroupStatus{{DBParameterGroupName: aws.String("default.postgres10")}},
+	}
+
+	i, changes := convertSpecToInputModify(db, instance)
+	assert.Equal(t, []string{"class", "size", "multiaz", "parameterGroup"}, changes)
+	assert.Equal(t, "mydb", *i.DBInstanceIdentifier)
+	assert.Equal(t, true, *i.ApplyImmediately)
+	assert.Equal(t, "db.t2.large", *i.DBInstanceClass)
+	assert.Equal(t, int64(200), *i.AllocatedStorage)
+	assert.Equal(t, true, *i.MultiAZ)
+	assert.Equal(t, "custom-postgres10", *i.DBParameterGroupName)
+	assert.Nil(t, i.StorageType)
+	assert.Nil(t, i.BackupRetentionPeriod)
+
+	// Changes already pending at AWS are not requested again
+	instance.PendingModifiedValues = &rds.PendingModifiedValues{
+		DBInstanceClass:  aws.String("db.t2.large"),
+		AllocatedStorage: aws.Int64(200),
+		MultiAZ:          aws.Bool(true),
+	}
+	instance.DBParameterGroups = []rds.DBParameterGroupStatus{{DBParameterGroupName: aws.String("custom-postgres10")}}
+	_, changes = convertSpecToInputModify(db, instance)
+	assert.Empty(t, changes)
+
+	// Storage is never shrunk
+	db.Spec.Size = 50
+	_, changes = convertSpecToInputModify(db, instance)
+	assert.Empty(t, changes)
+}
+
+func TestConvertSpecToInputPointInTime(t *testing.T) {
+	db := &databasesv1.Rds{
+		Spec: databasesv1.RdsSpec{
+			Class:            "db.t2.micro",
+			AvailabilityZone: "us-east-1a",
+			RestoreFrom:      &databasesv1.RestoreSource{SourceIdentifier: "orders"},
+		},
+	}
+	i := convertSpecToInputPointInTime(db, "orders-copy", "orders", "mysubnet", []string{"sg-1234"})
+	assert.Equal(t, "orders-copy", *i.TargetDBInstanceIdentifier)
+	assert.Equal(t, "orders", *i.SourceDBInstanceIdentifier)
+	assert.Equal(t, "us-east-1a", *i.AvailabilityZone)
+	assert.Equal(t, true, *i.UseLatestRestorableTime)
+	assert.Nil(t, i.RestoreTime)
+
+	at := metav1.NewTime(time.Date(2019, 7, 1, 10, 42, 0, 0, time.FixedZone("BRT", -3*3600)))
+	db.Spec.RestoreFrom.RestoreTime = &at
+	i = convertSpecToInputPointInTime(db, "orders-copy", "orders", "mysubnet", nil)
+	assert.Nil(t, i.UseLatestRestorableTime)
+	assert.Equal(t, time.Date(2019, 7, 1, 13, 42, 0, 0, time.UTC), *i.RestoreTime)
+}
+
+func TestSubnetsAndSecurityGroups(t *testing.T) {
+	a := &AWS{Subnets: []string{"subnet-private"}, PublicSubnets: []string{"subnet-public"}, SecurityGroups: []string{"sg-nodes"}}
+
+	assert.Equal(t, []string{"subnet-private"}, a.subnets(nil, false))
+	assert.Equal(t, []string{"subnet-public"}, a.subnets(nil, true))
+	assert.Equal(t, []string{"subnet-1", "subnet-2"}, a.subnets([]string{"subnet-1", "subnet-2"}, true))
+
+	// Only --subnet-ids given
+	a.PublicSubnets = nil
+	assert.Equal(t, []string{"subnet-private"}, a.subnets(nil, true))
+
+	assert.Equal(t, []string{"sg-nodes"}, a.securityGroups(""))
+	assert.Equal(t, []string{"sg-1234"}, a.securityGroups("sg-1234"))
+	assert.Equal(t, []string{"sg-1234", "sg-4321"}, a.securityGroups("sg-1234, sg-4321"))
+}
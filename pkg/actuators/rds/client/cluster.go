@@ -0,0 +1,248 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/cloud104/kube-db/pkg/util"
+)
+
+// CreateCluster creates the aurora cluster, its instances are created by ReconcileClusterInstances
+func (a *AWS) CreateCluster(c *databasesv1.RdsCluster, password string) error {
+	tags := append([]rds.Tag{{Key: aws.String("DBName"), Value: aws.String(c.Spec.DBName)}}, a.OwnerTags(c)...)
+	subnetName, err := a.ensureSubnetGroup(c.Spec.DBSubnetGroupName, a.subnets(c.Spec.SubnetIDs, c.Spec.PubliclyAccessible), tags)
+	if err != nil {
+		return err
+	}
+
+	input := convertClusterSpecToInputCreate(c, a.ClusterIdentifier(c), subnetName, a.securityGroups(c.Spec.VpcSecurityGroupIds), password)
+	input.Tags = a.withOwnerTags(c.Spec.Tags, c)
+
+	log.Printf("Creating db cluster %v\n", *input.DBClusterIdentifier)
+	_, err = a.RDS.CreateDBClusterRequest(input).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "CreateDBCluster")
+	}
+	return nil
+}
+
+// DescribeCluster returns the cluster at AWS, nil if it does not exist
+func (a *AWS) DescribeCluster(c *databasesv1.RdsCluster) (*rds.DBCluster, error) {
+	identifier := a.ClusterIdentifier(c)
+	res, err := a.RDS.DescribeDBClustersRequest(&rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBClusterNotFoundFault {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to describe db cluster %v", identifier))
+	}
+	if len(res.DBClusters) == 0 {
+		return nil, nil
+	}
+	return &res.DBClusters[0], nil
+}
+
+// DescribeClusterInstances returns the instances of the cluster
+func (a *AWS) DescribeClusterInstances(c *databasesv1.RdsCluster) ([]rds.DBInstance, error) {
+	identifier := a.ClusterIdentifier(c)
+	res, err := a.RDS.DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{
+		Filters: []rds.Filter{{Name: aws.String("db-cluster-id"), Values: []string{identifier}}},
+	}).Send(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to describe the instances of db cluster %v", identifier))
+	}
+	return res.DBInstances, nil
+}
+
+// ReconcileClusterInstances creates the missing instances and deletes the ones above the
+// spec count, instances not named by the controller are left alone. The writer is never deleted,
+// it takes the place of the last instance when a failover made it one above the count. Returns
+// the identifiers of the instances and true when any was created or deleted
+func (a *AWS) ReconcileClusterInstances(c *databasesv1.RdsCluster, cluster *rds.DBCluster, instances []rds.DBInstance) ([]string, bool, error) {
+	ctx := context.Background()
+	identifier := a.ClusterIdentifier(c)
+	count := int(c.InstanceCount())
+
+	existing := map[string]string{}
+	for _, i := range instances {
+		existing[aws.StringValue(i.DBInstanceIdentifier)] = aws.StringValue(i.DBInstanceStatus)
+	}
+
+	var members []string
+	for i := 0; i < count; i++ {
+		members = append(members, memberIdentifier(identifier, i))
+	}
+	if writer := clusterWriter(cluster); writer != "" && !util.Contains(members, writer) {
+		members[count-1] = writer
+	}
+	keep := map[string]bool{}
+	for _, member := range members {
+		keep[member] = true
+	}
+
+	changed := false
+	for _, member := range members {
+		if _, ok := existing[member]; ok {
+			continue
+		}
+
+		log.Printf("Creating instance %v of db cluster %v\n", member, identifier)
+		_, err := a.RDS.CreateDBInstanceRequest(&rds.CreateDBInstanceInput{
+			DBClusterIdentifier:  aws.String(identifier),
+			DBInstanceClass:      aws.String(c.Spec.Class),
+			DBInstanceIdentifier: aws.String(member),
+			Engine:               aws.String(c.Spec.Engine),
+			PubliclyAccessible:   aws.Bool(c.Spec.PubliclyAccessible),
+			Tags:                 a.withOwnerTags(c.Spec.Tags, c),
+		}).Send(ctx)
+		if err != nil {
+			return nil, false, errors.Wrap(err, fmt.Sprintf("CreateDBInstance for db instance %v", member))
+		}
+		changed = true
+	}
+
+	for i := 0; i < count+len(instances); i++ {
+		member := memberIdentifier(identifier, i)
+		if status, ok := existing[member]; !ok || status == "deleting" || keep[member] {
+			continue
+		}
+
+		log.Printf("Deleting instance %v of db cluster %v\n", member, identifier)
+		err := a.deleteClusterInstance(member)
+		if err != nil {
+			return nil, false, err
+		}
+		changed = true
+	}
+
+	return members, changed, nil
+}
+
+// clusterWriter returns the identifier of the writer instance of the cluster, empty without one
+func clusterWriter(cluster *rds.DBCluster) string {
+	if cluster == nil {
+		return ""
+	}
+	for _, m := range cluster.DBClusterMembers {
+		if aws.BoolValue(m.IsClusterWriter) {
+			return aws.StringValue(m.DBInstanceIdentifier)
+		}
+	}
+	return ""
+}
+
+// DeleteClusterInstances deletes every instance of the cluster, returns true while some remain
+func (a *AWS) DeleteClusterInstances(c *databasesv1.RdsCluster, instances []rds.DBInstance) (bool, error) {
+	for _, i := range instances {
+		if aws.StringValue(i.DBInstanceStatus) == "deleting" {
+			continue
+		}
+		err := a.deleteClusterInstance(aws.StringValue(i.DBInstanceIdentifier))
+		if err != nil {
+			return true, err
+		}
+	}
+	return len(instances) > 0, nil
+}
+
+// DeleteCluster deletes the cluster, with the final snapshot unless finalSnapshotIdentifier is empty
+func (a *AWS) DeleteCluster(c *databasesv1.RdsCluster, finalSnapshotIdentifier string) error {
+	identifier := a.ClusterIdentifier(c)
+	input := &rds.DeleteDBClusterInput{DBClusterIdentifier: aws.String(identifier)}
+	if finalSnapshotIdentifier == "" {
+		log.Printf("DB cluster: %v to be deleted, without finalSnapshot\n", identifier)
+		input.SkipFinalSnapshot = aws.Bool(true)
+	} else {
+		log.Printf("DB cluster: %v to be deleted, with finalSnapshot: %v\n", identifier, finalSnapshotIdentifier)
+		input.FinalDBSnapshotIdentifier = aws.String(finalSnapshotIdentifier)
+	}
+
+	_, err := a.RDS.DeleteDBClusterRequest(input).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBClusterNotFoundFault {
+			return nil
+		}
+		return errors.Wrap(err, fmt.Sprintf("unable to delete db cluster %v", identifier))
+	}
+	return nil
+}
+
+// DescribeClusterSnapshot returns the cluster snapshot, nil when it does not exist
+func (a *AWS) DescribeClusterSnapshot(identifier string) (*rds.DBClusterSnapshot, error) {
+	res, err := a.RDS.DescribeDBClusterSnapshotsRequest(&rds.DescribeDBClusterSnapshotsInput{DBClusterSnapshotIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBClusterSnapshotNotFoundFault {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to describe cluster snapshot %v", identifier))
+	}
+	if len(res.DBClusterSnapshots) == 0 {
+		return nil, nil
+	}
+	return &res.DBClusterSnapshots[0], nil
+}
+
+func (a *AWS) deleteClusterInstance(identifier string) error {
+	// The data belongs to the cluster, its instances have no final snapshot
+	input := &rds.DeleteDBInstanceInput{DBInstanceIdentifier: aws.String(identifier), SkipFinalSnapshot: aws.Bool(true)}
+	_, err := a.RDS.DeleteDBInstanceRequest(input).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBInstanceNotFoundFault {
+			return nil
+		}
+		return errors.Wrap(err, fmt.Sprintf("unable to delete db instance %v", identifier))
+	}
+	return nil
+}
+
+// securityGroups returns the comma separated groups of the spec, the ones of the configuration by default
+func (a *AWS) securityGroups(groups string) []string {
+	var result []string
+	for _, g := range strings.Split(groups, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			result = append(result, g)
+		}
+	}
+	if len(result) > 0 {
+		return result
+	}
+	return a.SecurityGroups
+}
+
+// memberIdentifier names the instances of a cluster, the first one is the initial writer
+func memberIdentifier(cluster string, i int) string {
+	return actuators.SanitizeIdentifier(fmt.Sprintf("%v-%v", cluster, i))
+}
+
+func convertClusterSpecToInputCreate(v *databasesv1.RdsCluster, identifier string, subnetName string, securityGroups []string, password string) *rds.CreateDBClusterInput {
+	input := &rds.CreateDBClusterInput{
+		DBClusterIdentifier: aws.String(identifier),
+		DBSubnetGroupName:   aws.String(subnetName),
+		DatabaseName:        aws.String(v.Spec.DBName),
+		Engine:              aws.String(v.Spec.Engine),
+		MasterUserPassword:  aws.String(password),
+		MasterUsername:      aws.String(v.Spec.Username),
+		StorageEncrypted:    aws.Bool(v.Spec.StorageEncrypted),
+		Tags:                createTags(v.Spec.Tags),
+		VpcSecurityGroupIds: securityGroups,
+	}
+	if v.Spec.EngineVersion != "" {
+		input.EngineVersion = aws.String(v.Spec.EngineVersion)
+	}
+	if v.Spec.BackupRetentionPeriod > 0 {
+		input.BackupRetentionPeriod = aws.Int64(v.Spec.BackupRetentionPeriod)
+	}
+	if v.Spec.DBClusterParameterGroupName != "" {
+		input.DBClusterParameterGroupName = aws.String(v.Spec.DBClusterParameterGroupName)
+	}
+	return input
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertClusterSpecToInputCreate(t *testing.T) {
+	c := &databasesv1.RdsCluster{
+		Spec: databasesv1.RdsClusterSpec{
+			DBName:           "mydb",
+			Engine:           "aurora-postgresql",
+			Username:         "myuser",
+			StorageEncrypted: true,
+		},
+	}
+	i := convertClusterSpecToInputCreate(c, "default-mydb", "mysubnet", []string{"sg-1234"}, "mypassword")
+	assert.Equal(t, "default-mydb", *i.DBClusterIdentifier)
+	assert.Equal(t, "mydb", *i.DatabaseName)
+	assert.Equal(t, "aurora-postgresql", *i.Engine)
+	assert.Equal(t, "mypassword", *i.MasterUserPassword)
+	assert.Equal(t, "mysubnet", *i.DBSubnetGroupName)
+	assert.Equal(t, true, *i.StorageEncrypted)
+	assert.Nil(t, i.EngineVersion)
+	assert.Nil(t, i.BackupRetentionPeriod)
+	assert.Nil(t, i.DBClusterParameterGroupName)
+}
+
+func TestMemberIdentifier(t *testing.T) {
+	assert.Equal(t, "orders-0", memberIdentifier("orders", 0))
+
+	long := memberIdentifier(strings.Repeat("a", 63), 1)
+	assert.Len(t, long, 63)
+	assert.NotEqual(t, long, memberIdentifier(strings.Repeat("a", 63), 2))
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// Region returns the region of the controller
+func (a *AWS) Region() string {
+	return a.Config.Region
+}
+
+// NeedsCopy is false when the copy only shares the snapshot, which is then shared as is
+func (a *AWS) NeedsCopy(c databasesv1.SnapshotCopy) bool {
+	return !a.isLocal(c) || c.KmsKeyID != ""
+}
+
+// CopyIdentifier names the copy of a snapshot, copies in the source region can't reuse its name
+func (a *AWS) CopyIdentifier(identifier string, c databasesv1.SnapshotCopy) string {
+	if a.NeedsCopy(c) && a.isLocal(c) {
+		return actuators.SanitizeIdentifier(identifier + "-copy")
+	}
+	return identifier
+}
+
+// CopySnapshot copies the snapshot to the region of the copy, tagged as owned by the object.
+// Returns the copy once it exists, nil while it is being created
+func (a *AWS) CopySnapshot(snapshot *rds.DBSnapshot, c databasesv1.SnapshotCopy, o metav1.Object) (*rds.DBSnapshot, error) {
+	identifier := a.CopyIdentifier(aws.StringValue(snapshot.DBSnapshotIdentifier), c)
+	existing, err := a.describeSnapshotIn(c.Region, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, a.verifySnapshotOwnershipIn(c.Region, existing, o)
+	}
+
+	input := convertCopyToInput(snapshot, c, identifier, a.Region())
+	input.Tags = a.withOwnerTags(nil, o)
+
+	log.Printf("Copying snapshot %v to %v in %v\n", aws.StringValue(snapshot.DBSnapshotIdentifier), identifier, a.copyRegion(c))
+	_, err = a.regionRDS(c.Region).CopyDBSnapshotRequest(input).Send(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("CopyDBSnapshot of snapshot %v to %v", aws.StringValue(snapshot.DBSnapshotIdentifier), a.copyRegion(c)))
+	}
+	return nil, nil
+}
+
+// ShareSnapshot allows the accounts to restore the snapshot of the region
+func (a *AWS) ShareSnapshot(region string, identifier string, accountIDs []string) error {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+	_, err := a.regionRDS(region).ModifyDBSnapshotAttributeRequest(&rds.ModifyDBSnapshotAttributeInput{
+		DBSnapshotIdentifier: aws.String(identifier),
+		AttributeName:        aws.String("restore"),
+		ValuesToAdd:          accountIDs,
+	}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to share snapshot %v", identifier))
+	}
+	return nil
+}
+
+// DeleteSnapshotIn is DeleteSnapshot for the snapshots of another region
+func (a *AWS) DeleteSnapshotIn(region string, identifier string) error {
+	log.Printf("Deleting snapshot %v in %v\n", identifier, region)
+	_, err := a.regionRDS(region).DeleteDBSnapshotRequest(&rds.DeleteDBSnapshotInput{DBSnapshotIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+			return nil
+		}
+		return errors.Wrap(err, fmt.Sprintf("unable to delete snapshot %v in %v", identifier, region))
+	}
+	return nil
+}
+
+func (a *AWS) describeSnapshotIn(region string, identifier string) (*rds.DBSnapshot, error) {
+	res, err := a.regionRDS(region).DescribeDBSnapshotsRequest(&rds.DescribeDBSnapshotsInput{DBSnapshotIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to describe snapshot %v in %v", identifier, region))
+	}
+	if len(res.DBSnapshots) == 0 {
+		return nil, nil
+	}
+	return &res.DBSnapshots[0], nil
+}
+
+// regionRDS returns a client of the region, the one of the controller when empty
+func (a *AWS) regionRDS(region string) RDSAPI {
+	if region == "" || region == a.Region() {
+		return a.RDS
+	}
+	cfg := a.Config.Copy()
+	cfg.Region = region
+	if a.NewRDS != nil {
+		return a.NewRDS(cfg)
+	}
+	return rds.New(cfg)
+}
+
+func (a *AWS) copyRegion(c databasesv1.SnapshotCopy) string {
+	if c.Region == "" {
+		return a.Region()
+	}
+	return c.Region
+}
+
+func (a *AWS) isLocal(c databasesv1.SnapshotCopy) bool {
+	return c.Region == "" || c.Region == a.Region()
+}
+
+func convertCopyToInput(snapshot *rds.DBSnapshot, c databasesv1.SnapshotCopy, identifier string, sourceRegion string) *rds.CopyDBSnapshotInput {
+	input := &rds.CopyDBSnapshotInput{
+		// Copies from another region need the ARN of the source
+		SourceDBSnapshotIdentifier: snapshot.DBSnapshotArn,
+		TargetDBSnapshotIdentifier: aws.String(identifier),
+	}
+	if c.Region != "" && c.Region != sourceRegion {
+		// The SDK presigns the request in the source region
+		input.SourceRegion = aws.String(sourceRegion)
+	}
+	if c.KmsKeyID != "" {
+		input.KmsKeyId = aws.String(c.KmsKeyID)
+	}
+	return input
+}
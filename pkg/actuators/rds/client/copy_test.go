@@ -0,0 +1,44 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/stretchr/testify/assert"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+)
+
+func TestCopyIdentifier(t *testing.T) {
+	a := &AWS{Config: aws.Config{Region: "us-east-1"}}
+
+	share := databasesv1.SnapshotCopy{AccountIDs: []string{"123456789012"}}
+	assert.False(t, a.NeedsCopy(share))
+	assert.Equal(t, "pgsql-nightly", a.CopyIdentifier("pgsql-nightly", share))
+
+	reencrypt := databasesv1.SnapshotCopy{Region: "us-east-1", KmsKeyID: "alias/shared"}
+	assert.True(t, a.NeedsCopy(reencrypt))
+	assert.Equal(t, "pgsql-nightly-copy", a.CopyIdentifier("pgsql-nightly", reencrypt))
+
+	offsite := databasesv1.SnapshotCopy{Region: "us-west-2"}
+	assert.True(t, a.NeedsCopy(offsite))
+	assert.Equal(t, "pgsql-nightly", a.CopyIdentifier("pgsql-nightly", offsite))
+}
+
+func TestConvertCopyToInput(t *testing.T) {
+	snapshot := &rds.DBSnapshot{
+		DBSnapshotIdentifier: aws.String("pgsql-nightly"),
+		DBSnapshotArn:        aws.String("arn:aws:rds:us-east-1:123456789012:snapshot:pgsql-nightly"),
+	}
+
+	input := convertCopyToInput(snapshot, databasesv1.SnapshotCopy{Region: "us-west-2", KmsKeyID: "alias/dr"}, "pgsql-nightly", "us-east-1")
+	assert.Equal(t, "arn:aws:rds:us-east-1:123456789012:snapshot:pgsql-nightly", *input.SourceDBSnapshotIdentifier)
+	assert.Equal(t, "pgsql-nightly", *input.TargetDBSnapshotIdentifier)
+	assert.Equal(t, "us-east-1", *input.SourceRegion)
+	assert.Equal(t, "alias/dr", *input.KmsKeyId)
+
+	input = convertCopyToInput(snapshot, databasesv1.SnapshotCopy{KmsKeyID: "alias/shared"}, "pgsql-nightly-copy", "us-east-1")
+	assert.Nil(t, input.SourceRegion)
+	assert.Equal(t, "pgsql-nightly-copy", *input.TargetDBSnapshotIdentifier)
+}
@@ -0,0 +1,26 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// GetMetricStatistics returns a datapoint of the ReplicaLag metric of the instance, none when
+// the backend has no lag for it
+func (b *Backend) GetMetricStatisticsRequest(input *cloudwatch.GetMetricStatisticsInput) cloudwatch.GetMetricStatisticsRequest {
+	output := &cloudwatch.GetMetricStatisticsOutput{Label: input.MetricName}
+	return cloudwatch.GetMetricStatisticsRequest{Input: input, Request: b.request("monitoring", "GetMetricStatistics", input, output, func() error {
+		if aws.StringValue(input.MetricName) != "ReplicaLag" {
+			return nil
+		}
+		for _, d := range input.Dimensions {
+			if aws.StringValue(d.Name) != "DBInstanceIdentifier" {
+				continue
+			}
+			if lag, ok := b.ReplicaLag[aws.StringValue(d.Value)]; ok {
+				output.Datapoints = []cloudwatch.Datapoint{{Maximum: aws.Float64(lag), Timestamp: aws.Time(b.now)}}
+			}
+		}
+		return nil
+	})}
+}
@@ -0,0 +1,79 @@
+package fake
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+func (b *Backend) ec2Request(operation string, params interface{}, data interface{}, handle func() error) *aws.Request {
+	return b.request("ec2", operation, params, data, handle)
+}
+
+func (b *Backend) DescribeSubnetsRequest(input *ec2.DescribeSubnetsInput) ec2.DescribeSubnetsRequest {
+	output := &ec2.DescribeSubnetsOutput{}
+	return ec2.DescribeSubnetsRequest{Input: input, Request: b.ec2Request("DescribeSubnets", input, output, func() error {
+		for _, s := range b.Subnets {
+			if matches(input.Filters, s.Tags, map[string]string{"vpc-id": aws.StringValue(s.VpcId), "subnet-id": aws.StringValue(s.SubnetId)}) {
+				output.Subnets = append(output.Subnets, s)
+			}
+		}
+		return nil
+	})}
+}
+
+func (b *Backend) DescribeSecurityGroupsRequest(input *ec2.DescribeSecurityGroupsInput) ec2.DescribeSecurityGroupsRequest {
+	output := &ec2.DescribeSecurityGroupsOutput{}
+	return ec2.DescribeSecurityGroupsRequest{Input: input, Request: b.ec2Request("DescribeSecurityGroups", input, output, func() error {
+		for _, g := range b.SecurityGroups {
+			if matches(input.Filters, g.Tags, map[string]string{"vpc-id": aws.StringValue(g.VpcId), "group-id": aws.StringValue(g.GroupId)}) {
+				output.SecurityGroups = append(output.SecurityGroups, g)
+			}
+		}
+		return nil
+	})}
+}
+
+// DescribeInstances returns a reservation per node
+func (b *Backend) DescribeInstancesRequest(input *ec2.DescribeInstancesInput) ec2.DescribeInstancesRequest {
+	output := &ec2.DescribeInstancesOutput{}
+	return ec2.DescribeInstancesRequest{Input: input, Request: b.ec2Request("DescribeInstances", input, output, func() error {
+		for _, i := range b.Nodes {
+			fields := map[string]string{
+				"vpc-id":           aws.StringValue(i.VpcId),
+				"instance-id":      aws.StringValue(i.InstanceId),
+				"private-dns-name": aws.StringValue(i.PrivateDnsName),
+			}
+			if matches(input.Filters, i.Tags, fields) {
+				output.Reservations = append(output.Reservations, ec2.Reservation{Instances: []ec2.Instance{i}})
+			}
+		}
+		return nil
+	})}
+}
+
+// matches tells if the resource passes every filter, by field or by tag:KEY. Unknown filters
+// match nothing, so tests notice the filters the fake lacks
+func matches(filters []ec2.Filter, tags []ec2.Tag, fields map[string]string) bool {
+	for _, f := range filters {
+		name := aws.StringValue(f.Name)
+		value, ok := fields[name]
+		if key := strings.TrimPrefix(name, "tag:"); key != name {
+			value, ok = tagValue(tags, key)
+		}
+		if !ok || !contains(f.Values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func tagValue(tags []ec2.Tag, key string) (string, bool) {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == key {
+			return aws.StringValue(t.Value), true
+		}
+	}
+	return "", false
+}
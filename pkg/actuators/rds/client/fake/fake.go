@@ -0,0 +1,282 @@
+// Package fake is an in-memory RDS and EC2 account for the tests of the controller. Instances,
+// clusters and snapshots go through the states of AWS one step per call to Advance, and the
+// missing resources fail with the error codes of AWS
+package fake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+)
+
+// Backend is an AWS account in a region, serving the RDS, the EC2 and the CloudWatch APIs of the client
+type Backend struct {
+	Region    string
+	AccountID string
+
+	Instances        map[string]*Instance
+	Clusters         map[string]*rds.DBCluster
+	Snapshots        map[string]*rds.DBSnapshot
+	ClusterSnapshots map[string]*rds.DBClusterSnapshot
+	SubnetGroups     map[string]*rds.DBSubnetGroup
+	// Tags of the resources, by ARN
+	Tags map[string][]rds.Tag
+	// SharedWith lists the accounts allowed to restore a snapshot, by snapshot identifier
+	SharedWith map[string][]string
+
+	// Subnets, SecurityGroups and Nodes are the EC2 side, Nodes are the instances of the cluster
+	Subnets        []ec2.Subnet
+	SecurityGroups []ec2.SecurityGroup
+	Nodes          []ec2.Instance
+
+	// ReplicaLag is the ReplicaLag metric of the instances in seconds, by identifier
+	ReplicaLag map[string]float64
+
+	// Errors fails every call of an operation, like CreateDBInstance, until removed
+	Errors map[string]error
+	// Calls lists the operations called, in order
+	Calls []string
+
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Instance is a db instance along what AWS never returns
+type Instance struct {
+	rds.DBInstance
+	Password string
+	// applyImmediately tells the pending values are applied when the modification ends, not
+	// in the maintenance window
+	applyImmediately bool
+}
+
+// NewBackend returns an empty account of the region
+func NewBackend(region string) *Backend {
+	return &Backend{
+		Region:           region,
+		AccountID:        "123456789012",
+		Instances:        map[string]*Instance{},
+		Clusters:         map[string]*rds.DBCluster{},
+		Snapshots:        map[string]*rds.DBSnapshot{},
+		ClusterSnapshots: map[string]*rds.DBClusterSnapshot{},
+		SubnetGroups:     map[string]*rds.DBSubnetGroup{},
+		Tags:             map[string][]rds.Tag{},
+		SharedWith:       map[string][]string{},
+		ReplicaLag:       map[string]float64{},
+		Errors:           map[string]error{},
+		now:              time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// AWS returns the clients of the controller on the backend. Clients of other regions get a
+// backend of their own, built by regions when not nil
+func (b *Backend) AWS(regions func(region string) *Backend) *k8srds.AWS {
+	return &k8srds.AWS{
+		RDS:        b,
+		EC2:        b,
+		CloudWatch: b,
+		Config:     aws.Config{Region: b.Region},
+		NewRDS: func(cfg aws.Config) k8srds.RDSAPI {
+			if regions == nil {
+				return NewBackend(cfg.Region)
+			}
+			return regions(cfg.Region)
+		},
+	}
+}
+
+// Now is the clock of the backend, it moves a minute on each Advance
+func (b *Backend) Now() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.now
+}
+
+// Advance moves every resource to its next state, like AWS does between two reconciliations
+func (b *Backend) Advance() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.now = b.now.Add(time.Minute)
+
+	for id, s := range b.Snapshots {
+		if aws.StringValue(s.Status) == "creating" {
+			s.Status = aws.String("available")
+			s.PercentProgress = aws.Int64(100)
+		}
+		if aws.StringValue(s.Status) == "deleting" {
+			delete(b.Snapshots, id)
+			delete(b.Tags, aws.StringValue(s.DBSnapshotArn))
+		}
+	}
+
+	for _, s := range b.ClusterSnapshots {
+		if aws.StringValue(s.Status) == "creating" {
+			s.Status = aws.String("available")
+			s.PercentProgress = aws.Int64(100)
+		}
+	}
+
+	for id, i := range b.Instances {
+		switch aws.StringValue(i.DBInstanceStatus) {
+		case "creating":
+			i.DBInstanceStatus = aws.String("backing-up")
+			i.Endpoint = &rds.Endpoint{Address: aws.String(b.hostname(id)), Port: i.DbInstancePort}
+			i.InstanceCreateTime = aws.Time(b.now)
+		case "backing-up", "rebooting", "resetting-master-credentials":
+			i.DBInstanceStatus = aws.String("available")
+			i.LatestRestorableTime = aws.Time(b.now)
+		case "modifying":
+			i.DBInstanceStatus = aws.String("available")
+			if i.applyImmediately {
+				applyPending(i)
+			}
+		case "deleting":
+			b.removeInstance(id)
+		}
+	}
+
+	for id, c := range b.Clusters {
+		switch aws.StringValue(c.Status) {
+		case "creating":
+			c.Status = aws.String("available")
+		case "deleting":
+			delete(b.Clusters, id)
+			delete(b.Tags, aws.StringValue(c.DBClusterArn))
+		}
+	}
+}
+
+// Maintenance applies the modifications waiting for the maintenance window
+func (b *Backend) Maintenance() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, i := range b.Instances {
+		applyPending(i)
+	}
+}
+
+// Operations returns the operations called since the last call, in order
+func (b *Backend) Operations() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	calls := b.Calls
+	b.Calls = nil
+	return calls
+}
+
+// request returns a request answered by handle instead of AWS, the backend is locked while it
+// runs. data is filled by handle
+func (b *Backend) request(service string, operation string, params interface{}, data interface{}, handle func() error) *aws.Request {
+	handlers := aws.Handlers{}
+	handlers.Send.PushBack(func(r *aws.Request) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.Calls = append(b.Calls, operation)
+		if err, ok := b.Errors[operation]; ok {
+			r.Error = err
+			return
+		}
+		r.Error = handle()
+	})
+
+	cfg := aws.Config{Region: b.Region, EndpointResolver: aws.ResolveWithEndpointURL("https://fake.invalid")}
+	return aws.New(cfg, aws.Metadata{ServiceName: service}, handlers, nil, &aws.Operation{Name: operation, HTTPMethod: "POST", HTTPPath: "/"}, params, data)
+}
+
+// Error returns an error of AWS with the code
+func Error(code string, format string, args ...interface{}) error {
+	return awserr.New(code, fmt.Sprintf(format, args...), nil)
+}
+
+func (b *Backend) arn(kind string, identifier string) string {
+	return fmt.Sprintf("arn:aws:rds:%v:%v:%v:%v", b.Region, b.AccountID, kind, identifier)
+}
+
+func (b *Backend) hostname(identifier string) string {
+	return fmt.Sprintf("%v.c0ffee.%v.rds.amazonaws.com", identifier, b.Region)
+}
+
+func (b *Backend) removeInstance(id string) {
+	i := b.Instances[id]
+	delete(b.Instances, id)
+	delete(b.Tags, aws.StringValue(i.DBInstanceArn))
+
+	if source, ok := b.Instances[aws.StringValue(i.ReadReplicaSourceDBInstanceIdentifier)]; ok {
+		source.ReadReplicaDBInstanceIdentifiers = without(source.ReadReplicaDBInstanceIdentifiers, id)
+	}
+	if c, ok := b.Clusters[aws.StringValue(i.DBClusterIdentifier)]; ok {
+		var members []rds.DBClusterMember
+		for _, m := range c.DBClusterMembers {
+			if aws.StringValue(m.DBInstanceIdentifier) != id {
+				members = append(members, m)
+			}
+		}
+		c.DBClusterMembers = members
+	}
+}
+
+// applyPending moves the pending values of the instance to the instance
+func applyPending(i *Instance) {
+	p := i.PendingModifiedValues
+	if p == nil {
+		return
+	}
+	if p.DBInstanceClass != nil {
+		i.DBInstanceClass = p.DBInstanceClass
+	}
+	if p.AllocatedStorage != nil {
+		i.AllocatedStorage = p.AllocatedStorage
+	}
+	if p.Iops != nil {
+		i.Iops = p.Iops
+	}
+	if p.MultiAZ != nil {
+		i.MultiAZ = p.MultiAZ
+	}
+	if p.StorageType != nil {
+		i.StorageType = p.StorageType
+	}
+	if p.BackupRetentionPeriod != nil {
+		i.BackupRetentionPeriod = p.BackupRetentionPeriod
+	}
+	if p.EngineVersion != nil {
+		i.EngineVersion = p.EngineVersion
+	}
+	i.PendingModifiedValues = nil
+	i.applyImmediately = false
+}
+
+// setTags adds the tags to the resource, replacing the values of the existing keys
+func (b *Backend) setTags(arn string, tags []rds.Tag) {
+	existing := b.Tags[arn]
+	for _, t := range tags {
+		replaced := false
+		for n := range existing {
+			if aws.StringValue(existing[n].Key) == aws.StringValue(t.Key) {
+				existing[n].Value = t.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			existing = append(existing, rds.Tag{Key: t.Key, Value: t.Value})
+		}
+	}
+	b.Tags[arn] = existing
+}
+
+func without(values []string, value string) []string {
+	var rest []string
+	for _, v := range values {
+		if v != value {
+			rest = append(rest, v)
+		}
+	}
+	return rest
+}
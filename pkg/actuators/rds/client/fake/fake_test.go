@@ -0,0 +1,147 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func code(err error) string {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code()
+	}
+	return ""
+}
+
+func TestInstanceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend("us-east-1")
+
+	_, err := b.DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String("pgsql")}).Send(ctx)
+	assert.Equal(t, rds.ErrCodeDBInstanceNotFoundFault, code(err))
+
+	create := &rds.CreateDBInstanceInput{
+		DBInstanceIdentifier: aws.String("pgsql"),
+		DBInstanceClass:      aws.String("db.t3.micro"),
+		Engine:               aws.String("postgres"),
+		AllocatedStorage:     aws.Int64(20),
+		DBSubnetGroupName:    aws.String("missing"),
+		Tags:                 []rds.Tag{{Key: aws.String("team"), Value: aws.String("a")}},
+	}
+	_, err = b.CreateDBInstanceRequest(create).Send(ctx)
+	assert.Equal(t, rds.ErrCodeDBSubnetGroupNotFoundFault, code(err))
+
+	_, err = b.CreateDBSubnetGroupRequest(&rds.CreateDBSubnetGroupInput{DBSubnetGroupName: aws.String("group"), SubnetIds: []string{"subnet-a"}}).Send(ctx)
+	assert.NoError(t, err)
+	create.DBSubnetGroupName = aws.String("group")
+	res, err := b.CreateDBInstanceRequest(create).Send(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "creating", aws.StringValue(res.DBInstance.DBInstanceStatus))
+	_, err = b.CreateDBInstanceRequest(create).Send(ctx)
+	assert.Equal(t, rds.ErrCodeDBInstanceAlreadyExistsFault, code(err))
+
+	tags, err := b.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: res.DBInstance.DBInstanceArn}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", aws.StringValue(tags.TagList[0].Value))
+
+	// Only available instances are modified
+	modify := &rds.ModifyDBInstanceInput{DBInstanceIdentifier: aws.String("pgsql"), AllocatedStorage: aws.Int64(50), ApplyImmediately: aws.Bool(true)}
+	_, err = b.ModifyDBInstanceRequest(modify).Send(ctx)
+	assert.Equal(t, rds.ErrCodeInvalidDBInstanceStateFault, code(err))
+
+	b.Advance()
+	b.Advance()
+	instance := b.Instances["pgsql"]
+	assert.Equal(t, "available", aws.StringValue(instance.DBInstanceStatus))
+	assert.Equal(t, "pgsql.c0ffee.us-east-1.rds.amazonaws.com", aws.StringValue(instance.Endpoint.Address))
+	assert.Equal(t, int64(5432), aws.Int64Value(instance.Endpoint.Port))
+
+	_, err = b.ModifyDBInstanceRequest(modify).Send(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), aws.Int64Value(instance.PendingModifiedValues.AllocatedStorage))
+	b.Advance()
+	assert.Equal(t, int64(50), aws.Int64Value(instance.AllocatedStorage))
+	assert.Nil(t, instance.PendingModifiedValues)
+
+	// Without ApplyImmediately the values wait for the maintenance window
+	modify = &rds.ModifyDBInstanceInput{DBInstanceIdentifier: aws.String("pgsql"), DBInstanceClass: aws.String("db.t3.large")}
+	_, err = b.ModifyDBInstanceRequest(modify).Send(ctx)
+	assert.NoError(t, err)
+	b.Advance()
+	assert.Equal(t, "db.t3.micro", aws.StringValue(instance.DBInstanceClass))
+	b.Maintenance()
+	assert.Equal(t, "db.t3.large", aws.StringValue(instance.DBInstanceClass))
+
+	// The final snapshot outlives the instance
+	_, err = b.DeleteDBInstanceRequest(&rds.DeleteDBInstanceInput{DBInstanceIdentifier: aws.String("pgsql"), FinalDBSnapshotIdentifier: aws.String("final")}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "creating", aws.StringValue(b.Snapshots["final"].Status))
+	b.Advance()
+	assert.Empty(t, b.Instances)
+	assert.Empty(t, b.Tags[aws.StringValue(res.DBInstance.DBInstanceArn)])
+
+	snapshots, err := b.DescribeDBSnapshotsRequest(&rds.DescribeDBSnapshotsInput{DBInstanceIdentifier: aws.String("pgsql")}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots.DBSnapshots, 1)
+	assert.Equal(t, "available", aws.StringValue(snapshots.DBSnapshots[0].Status))
+
+	// Restored instances come back with the data of the snapshot
+	_, err = b.RestoreDBInstanceFromDBSnapshotRequest(&rds.RestoreDBInstanceFromDBSnapshotInput{
+		DBInstanceIdentifier: aws.String("restored"),
+		DBSnapshotIdentifier: aws.String("final"),
+	}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), aws.Int64Value(b.Instances["restored"].AllocatedStorage))
+
+	assert.Equal(t, []string{
+		"DescribeDBInstances", "CreateDBInstance", "CreateDBSubnetGroup", "CreateDBInstance", "CreateDBInstance",
+		"ListTagsForResource", "ModifyDBInstance", "ModifyDBInstance", "ModifyDBInstance", "DeleteDBInstance",
+		"DescribeDBSnapshots", "RestoreDBInstanceFromDBSnapshot",
+	}, b.Operations())
+	assert.Empty(t, b.Operations())
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend("us-east-1")
+	b.Errors["DescribeDBInstances"] = Error("Throttling", "Rate exceeded")
+
+	_, err := b.DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{}).Send(ctx)
+	assert.Equal(t, "Throttling", code(err))
+
+	delete(b.Errors, "DescribeDBInstances")
+	res, err := b.DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, res.DBInstances)
+
+	_, err = b.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: aws.String(b.arn("snapshot", "missing"))}).Send(ctx)
+	assert.Equal(t, rds.ErrCodeDBSnapshotNotFoundFault, code(err))
+}
+
+func TestEC2Filters(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend("us-east-1")
+	b.Subnets = []ec2.Subnet{
+		{SubnetId: aws.String("subnet-a"), VpcId: aws.String("vpc-1"), Tags: []ec2.Tag{{Key: aws.String("tier"), Value: aws.String("db")}}},
+		{SubnetId: aws.String("subnet-b"), VpcId: aws.String("vpc-2")},
+	}
+	b.Nodes = []ec2.Instance{{InstanceId: aws.String("i-1"), PrivateDnsName: aws.String("node-1"), VpcId: aws.String("vpc-1")}}
+
+	subnets, err := b.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{Filters: []ec2.Filter{{Name: aws.String("tag:tier"), Values: []string{"db"}}}}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, subnets.Subnets, 1)
+	assert.Equal(t, "subnet-a", aws.StringValue(subnets.Subnets[0].SubnetId))
+
+	subnets, err = b.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{Filters: []ec2.Filter{{Name: aws.String("vpc-id"), Values: []string{"vpc-2"}}}}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "subnet-b", aws.StringValue(subnets.Subnets[0].SubnetId))
+
+	nodes, err := b.DescribeInstancesRequest(&ec2.DescribeInstancesInput{Filters: []ec2.Filter{{Name: aws.String("private-dns-name"), Values: []string{"node-1"}}}}).Send(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, nodes.Reservations, 1)
+}
@@ -0,0 +1,850 @@
+package fake
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// The operations follow the API reference of RDS, with the validations the controller relies on
+
+func (b *Backend) rdsRequest(operation string, params interface{}, data interface{}, handle func() error) *aws.Request {
+	return b.request("rds", operation, params, data, handle)
+}
+
+func (b *Backend) instance(identifier *string) (*Instance, error) {
+	i, ok := b.Instances[aws.StringValue(identifier)]
+	if !ok {
+		return nil, Error(rds.ErrCodeDBInstanceNotFoundFault, "DBInstance %v not found.", aws.StringValue(identifier))
+	}
+	return i, nil
+}
+
+func (b *Backend) available(identifier *string) (*Instance, error) {
+	i, err := b.instance(identifier)
+	if err != nil {
+		return nil, err
+	}
+	if status := aws.StringValue(i.DBInstanceStatus); status != "available" {
+		return nil, Error(rds.ErrCodeInvalidDBInstanceStateFault, "Database instance is not in available state, it is %v.", status)
+	}
+	return i, nil
+}
+
+// newInstance registers an instance in the creating state, failing like AWS for taken
+// identifiers and missing subnet groups
+func (b *Backend) newInstance(i *Instance, subnetGroup *string, securityGroups []string, tags []rds.Tag) error {
+	id := aws.StringValue(i.DBInstanceIdentifier)
+	if _, ok := b.Instances[id]; ok {
+		return Error(rds.ErrCodeDBInstanceAlreadyExistsFault, "DB instance already exists")
+	}
+	if name := aws.StringValue(subnetGroup); name != "" {
+		group, ok := b.SubnetGroups[name]
+		if !ok {
+			return Error(rds.ErrCodeDBSubnetGroupNotFoundFault, "DBSubnetGroup %v not found.", name)
+		}
+		i.DBSubnetGroup = group
+	}
+	for _, sg := range securityGroups {
+		i.VpcSecurityGroups = append(i.VpcSecurityGroups, rds.VpcSecurityGroupMembership{VpcSecurityGroupId: aws.String(sg), Status: aws.String("active")})
+	}
+	if i.DbInstancePort == nil {
+		i.DbInstancePort = aws.Int64(enginePort(aws.StringValue(i.Engine)))
+	}
+	i.DBInstanceArn = aws.String(b.arn("db", id))
+	i.DBInstanceStatus = aws.String("creating")
+	b.Instances[id] = i
+	b.setTags(aws.StringValue(i.DBInstanceArn), tags)
+	return nil
+}
+
+func enginePort(engine string) int64 {
+	if strings.Contains(engine, "postgres") {
+		return 5432
+	}
+	return 3306
+}
+
+func parameterGroups(name *string) []rds.DBParameterGroupStatus {
+	if aws.StringValue(name) == "" {
+		return nil
+	}
+	return []rds.DBParameterGroupStatus{{DBParameterGroupName: name, ParameterApplyStatus: aws.String("in-sync")}}
+}
+
+func (b *Backend) CreateDBInstanceRequest(input *rds.CreateDBInstanceInput) rds.CreateDBInstanceRequest {
+	output := &rds.CreateDBInstanceOutput{}
+	return rds.CreateDBInstanceRequest{Input: input, Request: b.rdsRequest("CreateDBInstance", input, output, func() error {
+		i := &Instance{
+			DBInstance: rds.DBInstance{
+				AllocatedStorage:      input.AllocatedStorage,
+				AvailabilityZone:      input.AvailabilityZone,
+				BackupRetentionPeriod: input.BackupRetentionPeriod,
+				DBClusterIdentifier:   input.DBClusterIdentifier,
+				DBInstanceClass:       input.DBInstanceClass,
+				DBInstanceIdentifier:  input.DBInstanceIdentifier,
+				DBName:                input.DBName,
+				DBParameterGroups:     parameterGroups(input.DBParameterGroupName),
+				Engine:                input.Engine,
+				EngineVersion:         input.EngineVersion,
+				Iops:                  input.Iops,
+				MasterUsername:        input.MasterUsername,
+				MultiAZ:               input.MultiAZ,
+				PubliclyAccessible:    input.PubliclyAccessible,
+				StorageEncrypted:      input.StorageEncrypted,
+				StorageType:           input.StorageType,
+			},
+			Password: aws.StringValue(input.MasterUserPassword),
+		}
+		if c := input.DBClusterIdentifier; c != nil {
+			cluster, ok := b.Clusters[aws.StringValue(c)]
+			if !ok {
+				return Error(rds.ErrCodeDBClusterNotFoundFault, "DBCluster %v not found.", aws.StringValue(c))
+			}
+			cluster.DBClusterMembers = append(cluster.DBClusterMembers, rds.DBClusterMember{
+				DBInstanceIdentifier: input.DBInstanceIdentifier,
+				IsClusterWriter:      aws.Bool(len(cluster.DBClusterMembers) == 0),
+			})
+		}
+		if err := b.newInstance(i, input.DBSubnetGroupName, input.VpcSecurityGroupIds, input.Tags); err != nil {
+			return err
+		}
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+func (b *Backend) RestoreDBInstanceFromDBSnapshotRequest(input *rds.RestoreDBInstanceFromDBSnapshotInput) rds.RestoreDBInstanceFromDBSnapshotRequest {
+	output := &rds.RestoreDBInstanceFromDBSnapshotOutput{}
+	return rds.RestoreDBInstanceFromDBSnapshotRequest{Input: input, Request: b.rdsRequest("RestoreDBInstanceFromDBSnapshot", input, output, func() error {
+		s, err := b.snapshot(input.DBSnapshotIdentifier)
+		if err != nil {
+			return err
+		}
+		if aws.StringValue(s.Status) != "available" {
+			return Error(rds.ErrCodeInvalidDBSnapshotStateFault, "Snapshot %v is not available.", aws.StringValue(s.DBSnapshotIdentifier))
+		}
+		i := &Instance{DBInstance: rds.DBInstance{
+			AllocatedStorage:     s.AllocatedStorage,
+			AvailabilityZone:     input.AvailabilityZone,
+			DBInstanceClass:      input.DBInstanceClass,
+			DBInstanceIdentifier: input.DBInstanceIdentifier,
+			DBName:               input.DBName,
+			DBParameterGroups:    parameterGroups(input.DBParameterGroupName),
+			Engine:               s.Engine,
+			EngineVersion:        s.EngineVersion,
+			MasterUsername:       s.MasterUsername,
+			MultiAZ:              input.MultiAZ,
+			PubliclyAccessible:   input.PubliclyAccessible,
+			StorageType:          input.StorageType,
+		}}
+		if err := b.newInstance(i, input.DBSubnetGroupName, input.VpcSecurityGroupIds, input.Tags); err != nil {
+			return err
+		}
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+func (b *Backend) RestoreDBInstanceToPointInTimeRequest(input *rds.RestoreDBInstanceToPointInTimeInput) rds.RestoreDBInstanceToPointInTimeRequest {
+	output := &rds.RestoreDBInstanceToPointInTimeOutput{}
+	return rds.RestoreDBInstanceToPointInTimeRequest{Input: input, Request: b.rdsRequest("RestoreDBInstanceToPointInTime", input, output, func() error {
+		source, err := b.instance(input.SourceDBInstanceIdentifier)
+		if err != nil {
+			return err
+		}
+		i := &Instance{
+			DBInstance: rds.DBInstance{
+				AllocatedStorage:     source.AllocatedStorage,
+				AvailabilityZone:     input.AvailabilityZone,
+				DBInstanceClass:      input.DBInstanceClass,
+				DBInstanceIdentifier: input.TargetDBInstanceIdentifier,
+				DBName:               source.DBName,
+				DBParameterGroups:    parameterGroups(input.DBParameterGroupName),
+				Engine:               source.Engine,
+				EngineVersion:        source.EngineVersion,
+				Iops:                 input.Iops,
+				MasterUsername:       source.MasterUsername,
+				MultiAZ:              input.MultiAZ,
+				PubliclyAccessible:   input.PubliclyAccessible,
+				StorageType:          input.StorageType,
+			},
+			Password: source.Password,
+		}
+		if err := b.newInstance(i, input.DBSubnetGroupName, input.VpcSecurityGroupIds, input.Tags); err != nil {
+			return err
+		}
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+func (b *Backend) CreateDBInstanceReadReplicaRequest(input *rds.CreateDBInstanceReadReplicaInput) rds.CreateDBInstanceReadReplicaRequest {
+	output := &rds.CreateDBInstanceReadReplicaOutput{}
+	return rds.CreateDBInstanceReadReplicaRequest{Input: input, Request: b.rdsRequest("CreateDBInstanceReadReplica", input, output, func() error {
+		source, err := b.available(input.SourceDBInstanceIdentifier)
+		if err != nil {
+			return err
+		}
+		i := &Instance{
+			DBInstance: rds.DBInstance{
+				AllocatedStorage:                      source.AllocatedStorage,
+				AvailabilityZone:                      input.AvailabilityZone,
+				DBInstanceClass:                       input.DBInstanceClass,
+				DBInstanceIdentifier:                  input.DBInstanceIdentifier,
+				DBName:                                source.DBName,
+				Engine:                                source.Engine,
+				EngineVersion:                         source.EngineVersion,
+				MasterUsername:                        source.MasterUsername,
+				MultiAZ:                               input.MultiAZ,
+				PubliclyAccessible:                    input.PubliclyAccessible,
+				ReadReplicaSourceDBInstanceIdentifier: source.DBInstanceIdentifier,
+				StorageType:                           input.StorageType,
+			},
+			Password: source.Password,
+		}
+		if i.DBInstanceClass == nil {
+			i.DBInstanceClass = source.DBInstanceClass
+		}
+		if err := b.newInstance(i, input.DBSubnetGroupName, input.VpcSecurityGroupIds, input.Tags); err != nil {
+			return err
+		}
+		source.ReadReplicaDBInstanceIdentifiers = append(source.ReadReplicaDBInstanceIdentifiers, aws.StringValue(input.DBInstanceIdentifier))
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+func (b *Backend) PromoteReadReplicaRequest(input *rds.PromoteReadReplicaInput) rds.PromoteReadReplicaRequest {
+	output := &rds.PromoteReadReplicaOutput{}
+	return rds.PromoteReadReplicaRequest{Input: input, Request: b.rdsRequest("PromoteReadReplica", input, output, func() error {
+		i, err := b.available(input.DBInstanceIdentifier)
+		if err != nil {
+			return err
+		}
+		sourceID := aws.StringValue(i.ReadReplicaSourceDBInstanceIdentifier)
+		if sourceID == "" {
+			return Error(rds.ErrCodeInvalidDBInstanceStateFault, "DB Instance %v is not a read replica.", aws.StringValue(input.DBInstanceIdentifier))
+		}
+		if source, ok := b.Instances[sourceID]; ok {
+			source.ReadReplicaDBInstanceIdentifiers = without(source.ReadReplicaDBInstanceIdentifiers, aws.StringValue(input.DBInstanceIdentifier))
+		}
+		i.ReadReplicaSourceDBInstanceIdentifier = nil
+		i.DBInstanceStatus = aws.String("modifying")
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+func (b *Backend) DescribeDBInstancesRequest(input *rds.DescribeDBInstancesInput) rds.DescribeDBInstancesRequest {
+	output := &rds.DescribeDBInstancesOutput{}
+	return rds.DescribeDBInstancesRequest{Input: input, Request: b.rdsRequest("DescribeDBInstances", input, output, func() error {
+		if input.DBInstanceIdentifier != nil {
+			i, err := b.instance(input.DBInstanceIdentifier)
+			if err != nil {
+				return err
+			}
+			output.DBInstances = []rds.DBInstance{*copyInstance(i)}
+			return nil
+		}
+
+		var ids []string
+		for id, i := range b.Instances {
+			if matchesInstance(i, input.Filters) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			output.DBInstances = append(output.DBInstances, *copyInstance(b.Instances[id]))
+		}
+		return nil
+	})}
+}
+
+// matchesInstance supports the db-cluster-id and db-instance-id filters
+func matchesInstance(i *Instance, filters []rds.Filter) bool {
+	for _, f := range filters {
+		var value string
+		switch aws.StringValue(f.Name) {
+		case "db-cluster-id":
+			value = aws.StringValue(i.DBClusterIdentifier)
+		case "db-instance-id":
+			value = aws.StringValue(i.DBInstanceIdentifier)
+		default:
+			continue
+		}
+		if !contains(f.Values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// copyInstance returns what DescribeDBInstances returns, the pending values are copied so
+// callers never change the backend
+func copyInstance(i *Instance) *rds.DBInstance {
+	instance := i.DBInstance
+	if p := i.PendingModifiedValues; p != nil {
+		pending := *p
+		instance.PendingModifiedValues = &pending
+	}
+	return &instance
+}
+
+// ModifyDBInstance keeps the changes pending until the modification ends, or until the
+// maintenance window without ApplyImmediately. The password and the parameter group are applied
+// right away
+func (b *Backend) ModifyDBInstanceRequest(input *rds.ModifyDBInstanceInput) rds.ModifyDBInstanceRequest {
+	output := &rds.ModifyDBInstanceOutput{}
+	return rds.ModifyDBInstanceRequest{Input: input, Request: b.rdsRequest("ModifyDBInstance", input, output, func() error {
+		i, err := b.available(input.DBInstanceIdentifier)
+		if err != nil {
+			return err
+		}
+
+		if input.MasterUserPassword != nil {
+			i.Password = aws.StringValue(input.MasterUserPassword)
+			i.DBInstanceStatus = aws.String("resetting-master-credentials")
+		}
+		if input.DBParameterGroupName != nil {
+			i.DBParameterGroups = []rds.DBParameterGroupStatus{{DBParameterGroupName: input.DBParameterGroupName, ParameterApplyStatus: aws.String("pending-reboot")}}
+		}
+
+		pending := rds.PendingModifiedValues{}
+		if i.PendingModifiedValues != nil {
+			pending = *i.PendingModifiedValues
+		}
+		changed := false
+		set := func(field **string, value *string) {
+			if value != nil {
+				*field = value
+				changed = true
+			}
+		}
+		setInt := func(field **int64, value *int64) {
+			if value != nil {
+				*field = value
+				changed = true
+			}
+		}
+		set(&pending.DBInstanceClass, input.DBInstanceClass)
+		set(&pending.StorageType, input.StorageType)
+		set(&pending.EngineVersion, input.EngineVersion)
+		setInt(&pending.AllocatedStorage, input.AllocatedStorage)
+		setInt(&pending.Iops, input.Iops)
+		setInt(&pending.BackupRetentionPeriod, input.BackupRetentionPeriod)
+		if input.MultiAZ != nil {
+			pending.MultiAZ = input.MultiAZ
+			changed = true
+		}
+		if changed {
+			i.PendingModifiedValues = &pending
+			if aws.BoolValue(input.ApplyImmediately) {
+				i.applyImmediately = true
+				i.DBInstanceStatus = aws.String("modifying")
+			}
+		}
+
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+func (b *Backend) RebootDBInstanceRequest(input *rds.RebootDBInstanceInput) rds.RebootDBInstanceRequest {
+	output := &rds.RebootDBInstanceOutput{}
+	return rds.RebootDBInstanceRequest{Input: input, Request: b.rdsRequest("RebootDBInstance", input, output, func() error {
+		i, err := b.available(input.DBInstanceIdentifier)
+		if err != nil {
+			return err
+		}
+		for n := range i.DBParameterGroups {
+			i.DBParameterGroups[n].ParameterApplyStatus = aws.String("in-sync")
+		}
+		i.DBInstanceStatus = aws.String("rebooting")
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+// DeleteDBInstance takes the final snapshot right away, the instance is gone on the next Advance
+func (b *Backend) DeleteDBInstanceRequest(input *rds.DeleteDBInstanceInput) rds.DeleteDBInstanceRequest {
+	output := &rds.DeleteDBInstanceOutput{}
+	return rds.DeleteDBInstanceRequest{Input: input, Request: b.rdsRequest("DeleteDBInstance", input, output, func() error {
+		i, err := b.instance(input.DBInstanceIdentifier)
+		if err != nil {
+			return err
+		}
+		if status := aws.StringValue(i.DBInstanceStatus); status == "deleting" || status == "creating" {
+			return Error(rds.ErrCodeInvalidDBInstanceStateFault, "Instance %v is %v.", aws.StringValue(i.DBInstanceIdentifier), status)
+		}
+
+		if !aws.BoolValue(input.SkipFinalSnapshot) {
+			if input.FinalDBSnapshotIdentifier == nil {
+				return Error("InvalidParameterCombination", "FinalDBSnapshotIdentifier is required unless SkipFinalSnapshot is specified.")
+			}
+			if err := b.newSnapshot(input.FinalDBSnapshotIdentifier, i, "manual", nil); err != nil {
+				return err
+			}
+		}
+		i.DBInstanceStatus = aws.String("deleting")
+		output.DBInstance = copyInstance(i)
+		return nil
+	})}
+}
+
+func (b *Backend) snapshot(identifier *string) (*rds.DBSnapshot, error) {
+	id := aws.StringValue(identifier)
+	// Snapshots are named by identifier or by ARN
+	for _, s := range b.Snapshots {
+		if aws.StringValue(s.DBSnapshotArn) == id {
+			return s, nil
+		}
+	}
+	s, ok := b.Snapshots[id]
+	if !ok {
+		return nil, Error(rds.ErrCodeDBSnapshotNotFoundFault, "DBSnapshot %v not found.", id)
+	}
+	return s, nil
+}
+
+// newSnapshot registers a snapshot of the instance in the creating state
+func (b *Backend) newSnapshot(identifier *string, i *Instance, snapshotType string, tags []rds.Tag) error {
+	id := aws.StringValue(identifier)
+	if _, ok := b.Snapshots[id]; ok {
+		return Error(rds.ErrCodeDBSnapshotAlreadyExistsFault, "Cannot create the snapshot because a snapshot with the identifier %v already exists.", id)
+	}
+	s := &rds.DBSnapshot{
+		AllocatedStorage:     i.AllocatedStorage,
+		DBInstanceIdentifier: i.DBInstanceIdentifier,
+		DBSnapshotArn:        aws.String(b.arn("snapshot", id)),
+		DBSnapshotIdentifier: aws.String(id),
+		Engine:               i.Engine,
+		EngineVersion:        i.EngineVersion,
+		MasterUsername:       i.MasterUsername,
+		PercentProgress:      aws.Int64(0),
+		Port:                 i.DbInstancePort,
+		SnapshotCreateTime:   aws.Time(b.now),
+		SnapshotType:         aws.String(snapshotType),
+		Status:               aws.String("creating"),
+	}
+	b.Snapshots[id] = s
+	b.setTags(aws.StringValue(s.DBSnapshotArn), tags)
+	return nil
+}
+
+func (b *Backend) CreateDBSnapshotRequest(input *rds.CreateDBSnapshotInput) rds.CreateDBSnapshotRequest {
+	output := &rds.CreateDBSnapshotOutput{}
+	return rds.CreateDBSnapshotRequest{Input: input, Request: b.rdsRequest("CreateDBSnapshot", input, output, func() error {
+		i, err := b.available(input.DBInstanceIdentifier)
+		if err != nil {
+			return err
+		}
+		if err := b.newSnapshot(input.DBSnapshotIdentifier, i, "manual", input.Tags); err != nil {
+			return err
+		}
+		i.DBInstanceStatus = aws.String("backing-up")
+		s := *b.Snapshots[aws.StringValue(input.DBSnapshotIdentifier)]
+		output.DBSnapshot = &s
+		return nil
+	})}
+}
+
+// CopyDBSnapshot copies snapshots of the backend, or of another region by ARN
+func (b *Backend) CopyDBSnapshotRequest(input *rds.CopyDBSnapshotInput) rds.CopyDBSnapshotRequest {
+	output := &rds.CopyDBSnapshotOutput{}
+	return rds.CopyDBSnapshotRequest{Input: input, Request: b.rdsRequest("CopyDBSnapshot", input, output, func() error {
+		source := aws.StringValue(input.SourceDBSnapshotIdentifier)
+		i := &Instance{}
+		if s, err := b.snapshot(input.SourceDBSnapshotIdentifier); err == nil {
+			i.DBInstance = rds.DBInstance{
+				AllocatedStorage:     s.AllocatedStorage,
+				DBInstanceIdentifier: s.DBInstanceIdentifier,
+				Engine:               s.Engine,
+				EngineVersion:        s.EngineVersion,
+				MasterUsername:       s.MasterUsername,
+				DbInstancePort:       s.Port,
+			}
+		} else if !strings.HasPrefix(source, "arn:") || strings.Contains(source, ":"+b.Region+":") {
+			return err
+		}
+		if err := b.newSnapshot(input.TargetDBSnapshotIdentifier, i, "manual", input.Tags); err != nil {
+			return err
+		}
+		s := *b.Snapshots[aws.StringValue(input.TargetDBSnapshotIdentifier)]
+		output.DBSnapshot = &s
+		return nil
+	})}
+}
+
+func (b *Backend) DescribeDBSnapshotsRequest(input *rds.DescribeDBSnapshotsInput) rds.DescribeDBSnapshotsRequest {
+	output := &rds.DescribeDBSnapshotsOutput{}
+	return rds.DescribeDBSnapshotsRequest{Input: input, Request: b.rdsRequest("DescribeDBSnapshots", input, output, func() error {
+		if input.DBSnapshotIdentifier != nil {
+			s, err := b.snapshot(input.DBSnapshotIdentifier)
+			if err != nil {
+				return err
+			}
+			output.DBSnapshots = []rds.DBSnapshot{*s}
+			return nil
+		}
+
+		var ids []string
+		for id, s := range b.Snapshots {
+			if input.DBInstanceIdentifier != nil && aws.StringValue(s.DBInstanceIdentifier) != aws.StringValue(input.DBInstanceIdentifier) {
+				continue
+			}
+			if input.SnapshotType != nil && aws.StringValue(s.SnapshotType) != aws.StringValue(input.SnapshotType) {
+				continue
+			}
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			output.DBSnapshots = append(output.DBSnapshots, *b.Snapshots[id])
+		}
+		return nil
+	})}
+}
+
+func (b *Backend) DeleteDBSnapshotRequest(input *rds.DeleteDBSnapshotInput) rds.DeleteDBSnapshotRequest {
+	output := &rds.DeleteDBSnapshotOutput{}
+	return rds.DeleteDBSnapshotRequest{Input: input, Request: b.rdsRequest("DeleteDBSnapshot", input, output, func() error {
+		s, err := b.snapshot(input.DBSnapshotIdentifier)
+		if err != nil {
+			return err
+		}
+		if aws.StringValue(s.Status) != "available" {
+			return Error(rds.ErrCodeInvalidDBSnapshotStateFault, "Snapshot %v is not available.", aws.StringValue(s.DBSnapshotIdentifier))
+		}
+		s.Status = aws.String("deleting")
+		copied := *s
+		output.DBSnapshot = &copied
+		return nil
+	})}
+}
+
+func (b *Backend) ModifyDBSnapshotAttributeRequest(input *rds.ModifyDBSnapshotAttributeInput) rds.ModifyDBSnapshotAttributeRequest {
+	output := &rds.ModifyDBSnapshotAttributeOutput{}
+	return rds.ModifyDBSnapshotAttributeRequest{Input: input, Request: b.rdsRequest("ModifyDBSnapshotAttribute", input, output, func() error {
+		s, err := b.snapshot(input.DBSnapshotIdentifier)
+		if err != nil {
+			return err
+		}
+		id := aws.StringValue(s.DBSnapshotIdentifier)
+		for _, account := range input.ValuesToAdd {
+			if !contains(b.SharedWith[id], account) {
+				b.SharedWith[id] = append(b.SharedWith[id], account)
+			}
+		}
+		for _, account := range input.ValuesToRemove {
+			b.SharedWith[id] = without(b.SharedWith[id], account)
+		}
+		return nil
+	})}
+}
+
+func (b *Backend) CreateDBSubnetGroupRequest(input *rds.CreateDBSubnetGroupInput) rds.CreateDBSubnetGroupRequest {
+	output := &rds.CreateDBSubnetGroupOutput{}
+	return rds.CreateDBSubnetGroupRequest{Input: input, Request: b.rdsRequest("CreateDBSubnetGroup", input, output, func() error {
+		name := aws.StringValue(input.DBSubnetGroupName)
+		if _, ok := b.SubnetGroups[name]; ok {
+			return Error(rds.ErrCodeDBSubnetGroupAlreadyExistsFault, "The DB subnet group %v already exists.", name)
+		}
+		if len(input.SubnetIds) == 0 {
+			return Error("InvalidParameterValue", "Some input subnets are invalid.")
+		}
+		group := &rds.DBSubnetGroup{
+			DBSubnetGroupArn:         aws.String(b.arn("subgrp", name)),
+			DBSubnetGroupDescription: input.DBSubnetGroupDescription,
+			DBSubnetGroupName:        input.DBSubnetGroupName,
+			SubnetGroupStatus:        aws.String("Complete"),
+		}
+		for _, id := range input.SubnetIds {
+			group.Subnets = append(group.Subnets, rds.Subnet{SubnetIdentifier: aws.String(id), SubnetStatus: aws.String("Active")})
+		}
+		b.SubnetGroups[name] = group
+		b.setTags(aws.StringValue(group.DBSubnetGroupArn), input.Tags)
+		copied := *group
+		output.DBSubnetGroup = &copied
+		return nil
+	})}
+}
+
+func (b *Backend) DescribeDBSubnetGroupsRequest(input *rds.DescribeDBSubnetGroupsInput) rds.DescribeDBSubnetGroupsRequest {
+	output := &rds.DescribeDBSubnetGroupsOutput{}
+	return rds.DescribeDBSubnetGroupsRequest{Input: input, Request: b.rdsRequest("DescribeDBSubnetGroups", input, output, func() error {
+		if input.DBSubnetGroupName != nil {
+			group, ok := b.SubnetGroups[aws.StringValue(input.DBSubnetGroupName)]
+			if !ok {
+				return Error(rds.ErrCodeDBSubnetGroupNotFoundFault, "DBSubnetGroup %v not found.", aws.StringValue(input.DBSubnetGroupName))
+			}
+			output.DBSubnetGroups = []rds.DBSubnetGroup{*group}
+			return nil
+		}
+		var names []string
+		for name := range b.SubnetGroups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			output.DBSubnetGroups = append(output.DBSubnetGroups, *b.SubnetGroups[name])
+		}
+		return nil
+	})}
+}
+
+func (b *Backend) ModifyDBSubnetGroupRequest(input *rds.ModifyDBSubnetGroupInput) rds.ModifyDBSubnetGroupRequest {
+	output := &rds.ModifyDBSubnetGroupOutput{}
+	return rds.ModifyDBSubnetGroupRequest{Input: input, Request: b.rdsRequest("ModifyDBSubnetGroup", input, output, func() error {
+		name := aws.StringValue(input.DBSubnetGroupName)
+		group, ok := b.SubnetGroups[name]
+		if !ok {
+			return Error(rds.ErrCodeDBSubnetGroupNotFoundFault, "DBSubnetGroup %v not found.", name)
+		}
+		if len(input.SubnetIds) == 0 {
+			return Error("InvalidParameterValue", "Some input subnets are invalid.")
+		}
+		if input.DBSubnetGroupDescription != nil {
+			group.DBSubnetGroupDescription = input.DBSubnetGroupDescription
+		}
+		group.Subnets = nil
+		for _, id := range input.SubnetIds {
+			group.Subnets = append(group.Subnets, rds.Subnet{SubnetIdentifier: aws.String(id), SubnetStatus: aws.String("Active")})
+		}
+		copied := *group
+		output.DBSubnetGroup = &copied
+		return nil
+	})}
+}
+
+func (b *Backend) DeleteDBSubnetGroupRequest(input *rds.DeleteDBSubnetGroupInput) rds.DeleteDBSubnetGroupRequest {
+	output := &rds.DeleteDBSubnetGroupOutput{}
+	return rds.DeleteDBSubnetGroupRequest{Input: input, Request: b.rdsRequest("DeleteDBSubnetGroup", input, output, func() error {
+		name := aws.StringValue(input.DBSubnetGroupName)
+		group, ok := b.SubnetGroups[name]
+		if !ok {
+			return Error(rds.ErrCodeDBSubnetGroupNotFoundFault, "DBSubnetGroup %v not found.", name)
+		}
+		for _, i := range b.Instances {
+			if i.DBSubnetGroup != nil && aws.StringValue(i.DBSubnetGroup.DBSubnetGroupName) == name {
+				return Error("InvalidDBSubnetGroupStateFault", "Cannot delete the subnet group %v because at least one database instance is still using it.", name)
+			}
+		}
+		delete(b.SubnetGroups, name)
+		delete(b.Tags, aws.StringValue(group.DBSubnetGroupArn))
+		return nil
+	})}
+}
+
+func (b *Backend) CreateDBClusterRequest(input *rds.CreateDBClusterInput) rds.CreateDBClusterRequest {
+	output := &rds.CreateDBClusterOutput{}
+	return rds.CreateDBClusterRequest{Input: input, Request: b.rdsRequest("CreateDBCluster", input, output, func() error {
+		id := aws.StringValue(input.DBClusterIdentifier)
+		if _, ok := b.Clusters[id]; ok {
+			return Error(rds.ErrCodeDBClusterAlreadyExistsFault, "DB Cluster already exists")
+		}
+		if name := aws.StringValue(input.DBSubnetGroupName); name != "" {
+			if _, ok := b.SubnetGroups[name]; !ok {
+				return Error(rds.ErrCodeDBSubnetGroupNotFoundFault, "DBSubnetGroup %v not found.", name)
+			}
+		}
+		port := input.Port
+		if port == nil {
+			port = aws.Int64(enginePort(aws.StringValue(input.Engine)))
+		}
+		c := &rds.DBCluster{
+			DBClusterArn:        aws.String(b.arn("cluster", id)),
+			DBClusterIdentifier: input.DBClusterIdentifier,
+			DatabaseName:        input.DatabaseName,
+			Endpoint:            aws.String(b.hostname(id + ".cluster")),
+			Engine:              input.Engine,
+			EngineVersion:       input.EngineVersion,
+			MasterUsername:      input.MasterUsername,
+			Port:                port,
+			ReaderEndpoint:      aws.String(b.hostname(id + ".cluster-ro")),
+			Status:              aws.String("creating"),
+		}
+		b.Clusters[id] = c
+		b.setTags(aws.StringValue(c.DBClusterArn), input.Tags)
+		copied := *c
+		output.DBCluster = &copied
+		return nil
+	})}
+}
+
+func (b *Backend) DescribeDBClustersRequest(input *rds.DescribeDBClustersInput) rds.DescribeDBClustersRequest {
+	output := &rds.DescribeDBClustersOutput{}
+	return rds.DescribeDBClustersRequest{Input: input, Request: b.rdsRequest("DescribeDBClusters", input, output, func() error {
+		if input.DBClusterIdentifier != nil {
+			c, ok := b.Clusters[aws.StringValue(input.DBClusterIdentifier)]
+			if !ok {
+				return Error(rds.ErrCodeDBClusterNotFoundFault, "DBCluster %v not found.", aws.StringValue(input.DBClusterIdentifier))
+			}
+			output.DBClusters = []rds.DBCluster{*c}
+			return nil
+		}
+		var ids []string
+		for id := range b.Clusters {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			output.DBClusters = append(output.DBClusters, *b.Clusters[id])
+		}
+		return nil
+	})}
+}
+
+// DeleteDBCluster fails while the cluster has instances, like AWS. The final snapshot is
+// registered in the creating state
+func (b *Backend) DeleteDBClusterRequest(input *rds.DeleteDBClusterInput) rds.DeleteDBClusterRequest {
+	output := &rds.DeleteDBClusterOutput{}
+	return rds.DeleteDBClusterRequest{Input: input, Request: b.rdsRequest("DeleteDBCluster", input, output, func() error {
+		id := aws.StringValue(input.DBClusterIdentifier)
+		c, ok := b.Clusters[id]
+		if !ok {
+			return Error(rds.ErrCodeDBClusterNotFoundFault, "DBCluster %v not found.", id)
+		}
+		if len(c.DBClusterMembers) > 0 {
+			return Error("InvalidDBClusterStateFault", "Cluster cannot be deleted, it still contains DB instances in non-deleting state.")
+		}
+		snapshot := aws.StringValue(input.FinalDBSnapshotIdentifier)
+		if !aws.BoolValue(input.SkipFinalSnapshot) && snapshot == "" {
+			return Error("InvalidParameterCombination", "FinalDBSnapshotIdentifier is required unless SkipFinalSnapshot is specified.")
+		}
+		if snapshot != "" {
+			if _, ok := b.ClusterSnapshots[snapshot]; ok {
+				return Error(rds.ErrCodeDBClusterSnapshotAlreadyExistsFault, "Cannot create the cluster snapshot because one with the identifier %v already exists.", snapshot)
+			}
+			b.ClusterSnapshots[snapshot] = &rds.DBClusterSnapshot{
+				DBClusterIdentifier:         c.DBClusterIdentifier,
+				DBClusterSnapshotArn:        aws.String(b.arn("cluster-snapshot", snapshot)),
+				DBClusterSnapshotIdentifier: aws.String(snapshot),
+				Engine:                      c.Engine,
+				EngineVersion:               c.EngineVersion,
+				PercentProgress:             aws.Int64(0),
+				Port:                        c.Port,
+				SnapshotCreateTime:          aws.Time(b.now),
+				SnapshotType:                aws.String("manual"),
+				Status:                      aws.String("creating"),
+			}
+		}
+		c.Status = aws.String("deleting")
+		copied := *c
+		output.DBCluster = &copied
+		return nil
+	})}
+}
+
+func (b *Backend) DescribeDBClusterSnapshotsRequest(input *rds.DescribeDBClusterSnapshotsInput) rds.DescribeDBClusterSnapshotsRequest {
+	output := &rds.DescribeDBClusterSnapshotsOutput{}
+	return rds.DescribeDBClusterSnapshotsRequest{Input: input, Request: b.rdsRequest("DescribeDBClusterSnapshots", input, output, func() error {
+		id := aws.StringValue(input.DBClusterSnapshotIdentifier)
+		s, ok := b.ClusterSnapshots[id]
+		if !ok {
+			return Error(rds.ErrCodeDBClusterSnapshotNotFoundFault, "DBClusterSnapshot %v not found.", id)
+		}
+		output.DBClusterSnapshots = []rds.DBClusterSnapshot{*s}
+		return nil
+	})}
+}
+
+// exists tells if the ARN names a resource of the backend
+func (b *Backend) exists(arn string) bool {
+	for _, i := range b.Instances {
+		if aws.StringValue(i.DBInstanceArn) == arn {
+			return true
+		}
+	}
+	for _, s := range b.Snapshots {
+		if aws.StringValue(s.DBSnapshotArn) == arn {
+			return true
+		}
+	}
+	for _, c := range b.Clusters {
+		if aws.StringValue(c.DBClusterArn) == arn {
+			return true
+		}
+	}
+	for _, s := range b.ClusterSnapshots {
+		if aws.StringValue(s.DBClusterSnapshotArn) == arn {
+			return true
+		}
+	}
+	for _, g := range b.SubnetGroups {
+		if aws.StringValue(g.DBSubnetGroupArn) == arn {
+			return true
+		}
+	}
+	return false
+}
+
+// notFound is the error of AWS for an ARN naming no resource
+func notFound(arn string) error {
+	code := rds.ErrCodeDBInstanceNotFoundFault
+	switch {
+	case strings.Contains(arn, ":cluster-snapshot:"):
+		code = rds.ErrCodeDBClusterSnapshotNotFoundFault
+	case strings.Contains(arn, ":snapshot:"):
+		code = rds.ErrCodeDBSnapshotNotFoundFault
+	case strings.Contains(arn, ":cluster:"):
+		code = rds.ErrCodeDBClusterNotFoundFault
+	case strings.Contains(arn, ":subgrp:"):
+		code = rds.ErrCodeDBSubnetGroupNotFoundFault
+	}
+	return Error(code, "%v not found.", arn)
+}
+
+func (b *Backend) ListTagsForResourceRequest(input *rds.ListTagsForResourceInput) rds.ListTagsForResourceRequest {
+	output := &rds.ListTagsForResourceOutput{}
+	return rds.ListTagsForResourceRequest{Input: input, Request: b.rdsRequest("ListTagsForResource", input, output, func() error {
+		arn := aws.StringValue(input.ResourceName)
+		if !b.exists(arn) {
+			return notFound(arn)
+		}
+		output.TagList = append([]rds.Tag{}, b.Tags[arn]...)
+		return nil
+	})}
+}
+
+func (b *Backend) AddTagsToResourceRequest(input *rds.AddTagsToResourceInput) rds.AddTagsToResourceRequest {
+	output := &rds.AddTagsToResourceOutput{}
+	return rds.AddTagsToResourceRequest{Input: input, Request: b.rdsRequest("AddTagsToResource", input, output, func() error {
+		arn := aws.StringValue(input.ResourceName)
+		if !b.exists(arn) {
+			return notFound(arn)
+		}
+		b.setTags(arn, input.Tags)
+		return nil
+	})}
+}
+
+func (b *Backend) RemoveTagsFromResourceRequest(input *rds.RemoveTagsFromResourceInput) rds.RemoveTagsFromResourceRequest {
+	output := &rds.RemoveTagsFromResourceOutput{}
+	return rds.RemoveTagsFromResourceRequest{Input: input, Request: b.rdsRequest("RemoveTagsFromResource", input, output, func() error {
+		arn := aws.StringValue(input.ResourceName)
+		if !b.exists(arn) {
+			return notFound(arn)
+		}
+		var tags []rds.Tag
+		for _, t := range b.Tags[arn] {
+			if !contains(input.TagKeys, aws.StringValue(t.Key)) {
+				tags = append(tags, t)
+			}
+		}
+		b.Tags[arn] = tags
+		return nil
+	})}
+}
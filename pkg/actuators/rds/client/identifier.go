@@ -0,0 +1,48 @@
+package client
+
+import (
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InstanceIdentifier returns the identifier persisted in the status, rendering the template for
+// objects that were never created. Objects created before the identifier was persisted keep the
+// name of the object
+func (a *AWS) InstanceIdentifier(db *databasesv1.Rds) string {
+	if db.Status.InstanceIdentifier != "" {
+		return db.Status.InstanceIdentifier
+	}
+	if isLegacy(db) {
+		return db.Name
+	}
+	return a.renderIdentifier(db)
+}
+
+// isLegacy tells if the status was written before the identifier was persisted: it has an ARN,
+// or a state without the conditions every newer status carries
+func isLegacy(db *databasesv1.Rds) bool {
+	return db.Status.ARN != "" || (db.Status.State != "" && len(db.Status.Conditions) == 0)
+}
+
+// ClusterIdentifier is InstanceIdentifier for clusters
+func (a *AWS) ClusterIdentifier(c *databasesv1.RdsCluster) string {
+	if c.Status.ClusterIdentifier != "" {
+		return c.Status.ClusterIdentifier
+	}
+	return a.renderIdentifier(c)
+}
+
+func (a *AWS) renderIdentifier(o metav1.Object) string {
+	identifier, err := actuators.RenderIdentifier(a.IdentifierTemplate, actuators.IdentifierData{
+		ClusterID: a.ClusterID,
+		Namespace: o.GetNamespace(),
+		Name:      o.GetName(),
+		UID:       string(o.GetUID()),
+	})
+	if err != nil {
+		// The template is validated on start up
+		return actuators.SanitizeIdentifier(o.GetName())
+	}
+	return identifier
+}
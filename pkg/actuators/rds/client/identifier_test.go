@@ -0,0 +1,31 @@
+package client
+
+import (
+	"testing"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInstanceIdentifier(t *testing.T) {
+	a := &AWS{ClusterID: "prod", IdentifierTemplate: "{{.ClusterID}}-{{.Namespace}}-{{.Name}}"}
+	db := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a"}}
+	assert.Equal(t, "prod-team-a-orders", a.InstanceIdentifier(db))
+
+	// The persisted identifier always wins
+	db.Status.InstanceIdentifier = "orders"
+	assert.Equal(t, "orders", a.InstanceIdentifier(db))
+
+	// Objects created before the identifier was persisted keep their name
+	legacy := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a"}}
+	legacy.Status = databasesv1.RdsStatus{State: databasesv1.StateAvailable}
+	assert.Equal(t, "orders", a.InstanceIdentifier(legacy))
+	legacy.Status = databasesv1.RdsStatus{ARN: "arn:aws:rds:us-east-1:123456789012:db:orders", Conditions: []databasesv1.Condition{{Type: "Ready"}}}
+	assert.Equal(t, "orders", a.InstanceIdentifier(legacy))
+
+	// A failure reported before the first creation is not a legacy status
+	failed := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a"}}
+	failed.Status = databasesv1.RdsStatus{State: databasesv1.StateError, Conditions: []databasesv1.Condition{{Type: "Ready"}}}
+	assert.Equal(t, "prod-team-a-orders", a.InstanceIdentifier(failed))
+}
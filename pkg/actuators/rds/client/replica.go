@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+)
+
+// ReplicaIdentifier is InstanceIdentifier for read replicas
+func (a *AWS) ReplicaIdentifier(r *databasesv1.RdsReadReplica) string {
+	if r.Status.InstanceIdentifier != "" {
+		return r.Status.InstanceIdentifier
+	}
+	return a.renderIdentifier(r)
+}
+
+// CreateReadReplica creates the replica of the source instance
+func (a *AWS) CreateReadReplica(r *databasesv1.RdsReadReplica, sourceIdentifier string) error {
+	input := convertReplicaSpecToInputCreate(r, a.ReplicaIdentifier(r), sourceIdentifier)
+	input.Tags = a.withOwnerTags(r.Spec.Tags, r)
+
+	log.Printf("Creating read replica %v of db instance %v\n", *input.DBInstanceIdentifier, sourceIdentifier)
+	_, err := a.RDS.CreateDBInstanceReadReplicaRequest(input).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "CreateDBInstanceReadReplica")
+	}
+	return nil
+}
+
+// DescribeReplica returns the replica at AWS, nil if it does not exist
+func (a *AWS) DescribeReplica(r *databasesv1.RdsReadReplica) (*rds.DBInstance, error) {
+	return a.describeInstance(a.ReplicaIdentifier(r))
+}
+
+// VerifyReplicaOwnership is VerifyOwnership for read replicas
+func (a *AWS) VerifyReplicaOwnership(r *databasesv1.RdsReadReplica) error {
+	instance, err := a.DescribeReplica(r)
+	if err != nil || instance == nil {
+		return err
+	}
+	return a.verifyInstanceOwnership(instance, r)
+}
+
+// PromoteReadReplica turns the replica into a standalone instance
+func (a *AWS) PromoteReadReplica(r *databasesv1.RdsReadReplica) error {
+	identifier := a.ReplicaIdentifier(r)
+	log.Printf("Promoting read replica %v\n", identifier)
+	_, err := a.RDS.PromoteReadReplicaRequest(&rds.PromoteReadReplicaInput{DBInstanceIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("PromoteReadReplica for db instance %v", identifier))
+	}
+	return nil
+}
+
+// DeleteReplica deletes the replica, a final snapshot can only be taken once it is promoted.
+// Returns the identifier of the final snapshot
+func (a *AWS) DeleteReplica(r *databasesv1.RdsReadReplica, skipFinalSnapshot bool) (string, error) {
+	identifier := a.ReplicaIdentifier(r)
+	if skipFinalSnapshot {
+		return a.deleteInstance(identifier, "")
+	}
+	return a.deleteInstance(identifier, FinalSnapshotIdentifier(identifier, time.Now()))
+}
+
+// ReplicaLag returns the latest ReplicaLag metric in seconds, nil when there is no datapoint yet
+func (a *AWS) ReplicaLag(r *databasesv1.RdsReadReplica) (*int64, error) {
+	identifier := a.ReplicaIdentifier(r)
+	now := time.Now()
+	res, err := a.CloudWatch.GetMetricStatisticsRequest(&cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String("AWS/RDS"),
+		MetricName: aws.String("ReplicaLag"),
+		Dimensions: []cloudwatch.Dimension{{Name: aws.String("DBInstanceIdentifier"), Value: aws.String(identifier)}},
+		StartTime:  aws.Time(now.Add(-10 * time.Minute)),
+		EndTime:    aws.Time(now),
+		Period:     aws.Int64(60),
+		Statistics: []cloudwatch.Statistic{cloudwatch.StatisticMaximum},
+	}).Send(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get the replica lag of db instance %v", identifier))
+	}
+	return latestDatapoint(res.Datapoints), nil
+}
+
+// latestDatapoint returns the maximum of the most recent datapoint
+func latestDatapoint(datapoints []cloudwatch.Datapoint) *int64 {
+	var latest *cloudwatch.Datapoint
+	for i, d := range datapoints {
+		if d.Timestamp == nil || d.Maximum == nil {
+			continue
+		}
+		if latest == nil || d.Timestamp.After(*latest.Timestamp) {
+			latest = &datapoints[i]
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	return aws.Int64(int64(*latest.Maximum))
+}
+
+// describeInstance returns the instance with the given identifier, nil if it does not exist
+func (a *AWS) describeInstance(identifier string) (*rds.DBInstance, error) {
+	res, err := a.RDS.DescribeDBInstancesRequest(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBInstanceNotFoundFault {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to describe db instance %v", identifier))
+	}
+	if len(res.DBInstances) == 0 {
+		return nil, nil
+	}
+	return &res.DBInstances[0], nil
+}
+
+func (a *AWS) verifyInstanceOwnership(instance *rds.DBInstance, o metav1.Object) error {
+	res, err := a.RDS.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: instance.DBInstanceArn}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to list the tags of db instance %v", aws.StringValue(instance.DBInstanceIdentifier)))
+	}
+	return checkOwnership(aws.StringValue(instance.DBInstanceIdentifier), res.TagList, a.ClusterID, o)
+}
+
+func convertReplicaSpecToInputCreate(v *databasesv1.RdsReadReplica, identifier string, sourceIdentifier string) *rds.CreateDBInstanceReadReplicaInput {
+	input := &rds.CreateDBInstanceReadReplicaInput{
+		DBInstanceIdentifier:       aws.String(identifier),
+		MultiAZ:                    aws.Bool(v.Spec.MultiAZ),
+		PubliclyAccessible:         aws.Bool(v.Spec.PubliclyAccessible),
+		SourceDBInstanceIdentifier: aws.String(sourceIdentifier),
+		Tags:                       createTags(v.Spec.Tags),
+	}
+	// The replica inherits the settings of the source unless the spec sets them
+	if v.Spec.Class != "" {
+		input.DBInstanceClass = aws.String(v.Spec.Class)
+	}
+	if v.Spec.AvailabilityZone != "" {
+		input.AvailabilityZone = aws.String(v.Spec.AvailabilityZone)
+	}
+	if v.Spec.StorageType != "" {
+		input.StorageType = aws.String(v.Spec.StorageType)
+	}
+	return input
+}
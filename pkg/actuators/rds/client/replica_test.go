@@ -0,0 +1,39 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertReplicaSpecToInputCreate(t *testing.T) {
+	r := &databasesv1.RdsReadReplica{
+		Spec: databasesv1.RdsReadReplicaSpec{
+			Class:   "db.t2.large",
+			MultiAZ: true,
+		},
+	}
+	i := convertReplicaSpecToInputCreate(r, "reports", "orders")
+	assert.Equal(t, "reports", *i.DBInstanceIdentifier)
+	assert.Equal(t, "orders", *i.SourceDBInstanceIdentifier)
+	assert.Equal(t, "db.t2.large", *i.DBInstanceClass)
+	assert.Equal(t, true, *i.MultiAZ)
+	assert.Nil(t, i.AvailabilityZone)
+	assert.Nil(t, i.StorageType)
+}
+
+func TestLatestDatapoint(t *testing.T) {
+	assert.Nil(t, latestDatapoint(nil))
+
+	now := time.Now()
+	lag := latestDatapoint([]cloudwatch.Datapoint{
+		{Timestamp: aws.Time(now.Add(-2 * time.Minute)), Maximum: aws.Float64(30)},
+		{Timestamp: aws.Time(now), Maximum: aws.Float64(4.6)},
+		{Timestamp: aws.Time(now.Add(-time.Minute)), Maximum: aws.Float64(12)},
+	})
+	assert.Equal(t, int64(4), *lag)
+}
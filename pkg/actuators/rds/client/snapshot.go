@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// SnapshotIdentifier names the snapshot of an RdsSnapshot after the instance it was taken from
+func (a *AWS) SnapshotIdentifier(s *databasesv1.RdsSnapshot, instanceIdentifier string) string {
+	if s.Status.SnapshotIdentifier != "" {
+		return s.Status.SnapshotIdentifier
+	}
+	return actuators.SanitizeIdentifier(fmt.Sprintf("%v-%v", instanceIdentifier, s.Name))
+}
+
+// CreateSnapshot takes a manual snapshot of the instance, tagged as owned by the object
+func (a *AWS) CreateSnapshot(identifier string, instanceIdentifier string, userTags map[string]string, o metav1.Object) error {
+	log.Printf("Creating snapshot %v of db instance %v\n", identifier, instanceIdentifier)
+	_, err := a.RDS.CreateDBSnapshotRequest(&rds.CreateDBSnapshotInput{
+		DBInstanceIdentifier: aws.String(instanceIdentifier),
+		DBSnapshotIdentifier: aws.String(identifier),
+		Tags:                 a.withOwnerTags(userTags, o),
+	}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("CreateDBSnapshot for db instance %v", instanceIdentifier))
+	}
+	return nil
+}
+
+// DeleteSnapshot deletes the snapshot, a missing snapshot is fine
+func (a *AWS) DeleteSnapshot(identifier string) error {
+	log.Printf("Deleting snapshot %v\n", identifier)
+	_, err := a.RDS.DeleteDBSnapshotRequest(&rds.DeleteDBSnapshotInput{DBSnapshotIdentifier: aws.String(identifier)}).Send(context.Background())
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+			return nil
+		}
+		return errors.Wrap(err, fmt.Sprintf("unable to delete snapshot %v", identifier))
+	}
+	return nil
+}
+
+// VerifySnapshotOwnership fails with an OwnershipError when the snapshot is not tagged as owned by the object
+func (a *AWS) VerifySnapshotOwnership(snapshot *rds.DBSnapshot, o metav1.Object) error {
+	return a.verifySnapshotOwnershipIn("", snapshot, o)
+}
+
+func (a *AWS) verifySnapshotOwnershipIn(region string, snapshot *rds.DBSnapshot, o metav1.Object) error {
+	res, err := a.regionRDS(region).ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: snapshot.DBSnapshotArn}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to list the tags of snapshot %v", aws.StringValue(snapshot.DBSnapshotIdentifier)))
+	}
+	return checkOwnership(aws.StringValue(snapshot.DBSnapshotIdentifier), res.TagList, a.ClusterID, o)
+}
+
+// LatestSnapshot returns the newest available snapshot of the instance with the given type and
+// tags that is allowed, nil when there is none
+func (a *AWS) LatestSnapshot(sourceIdentifier string, snapshotType string, tags map[string]string, allowed func(*rds.DBSnapshot) bool) (*rds.DBSnapshot, error) {
+	ctx := context.Background()
+	input := &rds.DescribeDBSnapshotsInput{DBInstanceIdentifier: aws.String(sourceIdentifier)}
+	if snapshotType != "" {
+		input.SnapshotType = aws.String(snapshotType)
+	}
+
+	var snapshots []rds.DBSnapshot
+	for {
+		res, err := a.RDS.DescribeDBSnapshotsRequest(input).Send(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("unable to describe the snapshots of %v", sourceIdentifier))
+		}
+		snapshots = append(snapshots, res.DBSnapshots...)
+		if aws.StringValue(res.Marker) == "" {
+			break
+		}
+		input.Marker = res.Marker
+	}
+
+	// Tags are not a filter of DescribeDBSnapshots, they are checked from the newest snapshot on
+	for _, s := range availableSnapshots(snapshots) {
+		s := s
+		if len(tags) > 0 {
+			res, err := a.RDS.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: s.DBSnapshotArn}).Send(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("unable to list the tags of snapshot %v", aws.StringValue(s.DBSnapshotIdentifier)))
+			}
+			if !hasTags(res.TagList, tags) {
+				continue
+			}
+		}
+		if allowed(&s) {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+// availableSnapshots returns the available snapshots, the newest first
+func availableSnapshots(snapshots []rds.DBSnapshot) []rds.DBSnapshot {
+	var available []rds.DBSnapshot
+	for _, s := range snapshots {
+		if aws.StringValue(s.Status) == "available" && s.SnapshotCreateTime != nil {
+			available = append(available, s)
+		}
+	}
+	sort.SliceStable(available, func(i, j int) bool {
+		return available[i].SnapshotCreateTime.After(*available[j].SnapshotCreateTime)
+	})
+	return available
+}
+
+func hasTags(tags []rds.Tag, wanted map[string]string) bool {
+	values := map[string]string{}
+	for _, t := range tags {
+		values[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	for k, v := range wanted {
+		if value, ok := values[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvailableSnapshots(t *testing.T) {
+	now := time.Now()
+	snapshots := availableSnapshots([]rds.DBSnapshot{
+		{DBSnapshotIdentifier: aws.String("old"), Status: aws.String("available"), SnapshotCreateTime: aws.Time(now.Add(-48 * time.Hour))},
+		{DBSnapshotIdentifier: aws.String("creating"), Status: aws.String("creating"), SnapshotCreateTime: aws.Time(now)},
+		{DBSnapshotIdentifier: aws.String("new"), Status: aws.String("available"), SnapshotCreateTime: aws.Time(now.Add(-time.Hour))},
+	})
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, "new", *snapshots[0].DBSnapshotIdentifier)
+	assert.Equal(t, "old", *snapshots[1].DBSnapshotIdentifier)
+}
+
+func TestHasTags(t *testing.T) {
+	tags := []rds.Tag{{Key: aws.String("env"), Value: aws.String("prod")}, {Key: aws.String("team"), Value: aws.String("a")}}
+	assert.True(t, hasTags(tags, nil))
+	assert.True(t, hasTags(tags, map[string]string{"env": "prod"}))
+	assert.False(t, hasTags(tags, map[string]string{"env": "staging"}))
+	assert.False(t, hasTags(tags, map[string]string{"owner": "a"}))
+}
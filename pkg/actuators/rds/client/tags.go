@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+)
+
+// Tags stamped by the controller on everything it creates at AWS
+const (
+	TagClusterID = "databases.tks.sh/cluster-id"
+	TagNamespace = "databases.tks.sh/namespace"
+	TagName      = "databases.tks.sh/name"
+	TagUID       = "databases.tks.sh/uid"
+)
+
+// TagAdoptNamespaces lists the namespaces allowed to adopt an instance, separated by spaces since
+// tag values can't hold commas. Instances without it are never adopted
+const TagAdoptNamespaces = "databases.tks.sh/adopt-namespaces"
+
+// OwnershipError tells the instance at AWS belongs to someone else
+type OwnershipError struct {
+	Identifier string
+	Reason     string
+}
+
+func (e *OwnershipError) Error() string {
+	return fmt.Sprintf("%v is not managed by this object: %v", e.Identifier, e.Reason)
+}
+
+// IsOwnershipError tells if the error is an OwnershipError
+func IsOwnershipError(err error) bool {
+	_, ok := errors.Cause(err).(*OwnershipError)
+	return ok
+}
+
+// OwnerTags returns the tags identifying the object owning a resource
+func (a *AWS) OwnerTags(o metav1.Object) []rds.Tag {
+	tags := []rds.Tag{
+		{Key: aws.String(TagNamespace), Value: aws.String(o.GetNamespace())},
+		{Key: aws.String(TagName), Value: aws.String(o.GetName())},
+		{Key: aws.String(TagUID), Value: aws.String(string(o.GetUID()))},
+	}
+	if a.ClusterID != "" {
+		tags = append(tags, rds.Tag{Key: aws.String(TagClusterID), Value: aws.String(a.ClusterID)})
+	}
+	return tags
+}
+
+// VerifyOwnership fails with an OwnershipError when the instance exists and its tags don't
+// point to this object, a missing instance is fine. Instances created before the tags existed
+// are tagged when the status already binds the object to them
+func (a *AWS) VerifyOwnership(db *databasesv1.Rds) error {
+	instance, err := a.DescribeDatabase(db)
+	if err != nil || instance == nil {
+		return err
+	}
+
+	identifier := aws.StringValue(instance.DBInstanceIdentifier)
+	res, err := a.RDS.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: instance.DBInstanceArn}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to list the tags of db instance %v", identifier))
+	}
+	err = checkOwnership(identifier, res.TagList, a.ClusterID, db)
+	if err == nil || !a.boundTo(db, instance) || checkAdoptable(identifier, res.TagList, a.ClusterID, db) != nil {
+		return err
+	}
+
+	log.Printf("Tagging db instance %v managed before the ownership tags\n", identifier)
+	return a.TagResource(aws.StringValue(instance.DBInstanceArn), db)
+}
+
+// boundTo tells if the status of the object already points to the instance, from a previous
+// reconciliation. A conflict, an error or a pending creation never binds, the instance may be someone else's
+func (a *AWS) boundTo(db *databasesv1.Rds, instance *rds.DBInstance) bool {
+	if db.Status.ARN != "" {
+		return db.Status.ARN == aws.StringValue(instance.DBInstanceArn)
+	}
+	switch db.Status.State {
+	case "", databasesv1.StateConflict, databasesv1.StateError, databasesv1.StatePending:
+		return false
+	}
+	return a.InstanceIdentifier(db) == aws.StringValue(instance.DBInstanceIdentifier)
+}
+
+// VerifyClusterOwnership is VerifyOwnership for clusters
+func (a *AWS) VerifyClusterOwnership(c *databasesv1.RdsCluster) error {
+	cluster, err := a.DescribeCluster(c)
+	if err != nil || cluster == nil {
+		return err
+	}
+
+	res, err := a.RDS.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: cluster.DBClusterArn}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to list the tags of db cluster %v", aws.StringValue(cluster.DBClusterIdentifier)))
+	}
+
+	return checkOwnership(aws.StringValue(cluster.DBClusterIdentifier), res.TagList, a.ClusterID, c)
+}
+
+// AdoptDatabase tags the instance as owned by the object, returning its ARN. Only instances
+// with owner tags of this object, or without owner tags and allowing the namespace in the
+// TagAdoptNamespaces tag, are adopted
+func (a *AWS) AdoptDatabase(db *databasesv1.Rds) (string, error) {
+	instance, err := a.getInstance(db)
+	if err != nil {
+		return "", err
+	}
+
+	arn := aws.StringValue(instance.DBInstanceArn)
+	res, err := a.RDS.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: instance.DBInstanceArn}).Send(context.Background())
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to list the tags of db instance %v", aws.StringValue(instance.DBInstanceIdentifier)))
+	}
+	err = checkAdoptable(aws.StringValue(instance.DBInstanceIdentifier), res.TagList, a.ClusterID, db)
+	if err != nil {
+		return "", err
+	}
+	values := tagValues(res.TagList)
+	if values[TagUID] != string(db.UID) && !allowsAdoptionIn(values[TagAdoptNamespaces], db.Namespace) {
+		return "", &OwnershipError{
+			Identifier: aws.StringValue(instance.DBInstanceIdentifier),
+			Reason:     fmt.Sprintf("its %v tag does not allow adoptions in namespace %v", TagAdoptNamespaces, db.Namespace),
+		}
+	}
+
+	log.Printf("Adopting db instance %v\n", arn)
+	return arn, a.TagResource(arn, db)
+}
+
+// TagResource stamps the owner tags on a resource, like the final snapshot
+func (a *AWS) TagResource(arn string, o metav1.Object) error {
+	_, err := a.RDS.AddTagsToResourceRequest(&rds.AddTagsToResourceInput{
+		ResourceName: aws.String(arn),
+		Tags:         a.OwnerTags(o),
+	}).Send(context.Background())
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to tag %v", arn))
+	}
+	return nil
+}
+
+// ResourceTags returns the tags of a resource by ARN
+func (a *AWS) ResourceTags(arn string) (map[string]string, error) {
+	res, err := a.RDS.ListTagsForResourceRequest(&rds.ListTagsForResourceInput{ResourceName: aws.String(arn)}).Send(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to list the tags of %v", arn))
+	}
+	return tagValues(res.TagList), nil
+}
+
+// InstanceTags returns the tags of the instance, nil when it does not exist
+func (a *AWS) InstanceTags(identifier string) (map[string]string, error) {
+	instance, err := a.describeInstance(identifier)
+	if err != nil || instance == nil {
+		return nil, err
+	}
+	return a.ResourceTags(aws.StringValue(instance.DBInstanceArn))
+}
+
+func tagValues(tags []rds.Tag) map[string]string {
+	values := map[string]string{}
+	for _, t := range tags {
+		values[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return values
+}
+
+func checkOwnership(identifier string, tags []rds.Tag, clusterID string, o metav1.Object) error {
+	values := map[string]string{}
+	for _, t := range tags {
+		values[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	uid, ok := values[TagUID]
+	if !ok {
+		return &OwnershipError{Identifier: identifier, Reason: fmt.Sprintf("the %v tag is missing", TagUID)}
+	}
+	if uid != string(o.GetUID()) {
+		return &OwnershipError{Identifier: identifier, Reason: fmt.Sprintf("it belongs to %v/%v (%v)", values[TagNamespace], values[TagName], uid)}
+	}
+	if values[TagClusterID] != clusterID {
+		return &OwnershipError{Identifier: identifier, Reason: fmt.Sprintf("it belongs to the cluster %q", values[TagClusterID])}
+	}
+	return nil
+}
+
+// checkAdoptable fails with an OwnershipError when an owner tag points to another object or cluster,
+// the instance of another owner is never taken over
+func checkAdoptable(identifier string, tags []rds.Tag, clusterID string, o metav1.Object) error {
+	ours := map[string]string{
+		TagNamespace: o.GetNamespace(),
+		TagName:      o.GetName(),
+		TagUID:       string(o.GetUID()),
+		TagClusterID: clusterID,
+	}
+	values := map[string]string{}
+	for _, t := range tags {
+		values[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	if uid, ok := values[TagUID]; ok && uid != ours[TagUID] {
+		return &OwnershipError{Identifier: identifier, Reason: fmt.Sprintf("it belongs to %v/%v (%v)", values[TagNamespace], values[TagName], uid)}
+	}
+	if cluster, ok := values[TagClusterID]; ok && cluster != ours[TagClusterID] {
+		return &OwnershipError{Identifier: identifier, Reason: fmt.Sprintf("it belongs to the cluster %q", cluster)}
+	}
+	for _, key := range []string{TagNamespace, TagName} {
+		if value, ok := values[key]; ok && value != ours[key] {
+			return &OwnershipError{Identifier: identifier, Reason: fmt.Sprintf("it is tagged %v=%v", key, value)}
+		}
+	}
+	return nil
+}
+
+// withOwnerTags adds the owner tags to the user ones, the owner tags win
+func (a *AWS) withOwnerTags(userTags map[string]string, o metav1.Object) []rds.Tag {
+	var tags []rds.Tag
+	for _, t := range createTags(userTags) {
+		if !isOwnerTag(aws.StringValue(t.Key)) {
+			tags = append(tags, t)
+		}
+	}
+	return append(tags, a.OwnerTags(o)...)
+}
+
+// allowsAdoptionIn tells if the value of the TagAdoptNamespaces tag lists the namespace
+func allowsAdoptionIn(value string, namespace string) bool {
+	for _, ns := range strings.Fields(value) {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func isOwnerTag(key string) bool {
+	return key == TagClusterID || key == TagNamespace || key == TagName || key == TagUID
+}
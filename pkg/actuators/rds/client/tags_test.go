@@ -0,0 +1,52 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckOwnership(t *testing.T) {
+	a := &AWS{ClusterID: "prod"}
+	db := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a", UID: "1234"}}
+
+	assert.NoError(t, checkOwnership("orders", a.OwnerTags(db), "prod", db))
+
+	err := checkOwnership("orders", []rds.Tag{{Key: aws.String("team"), Value: aws.String("legacy")}}, "prod", db)
+	assert.True(t, IsOwnershipError(err))
+
+	other := db.DeepCopy()
+	other.UID = "5678"
+	assert.True(t, IsOwnershipError(checkOwnership("orders", a.OwnerTags(other), "prod", db)))
+	assert.True(t, IsOwnershipError(checkOwnership("orders", a.OwnerTags(db), "staging", db)))
+}
+
+func TestWithOwnerTags(t *testing.T) {
+	a := &AWS{}
+	db := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a", UID: "1234"}}
+	db.Spec.Tags = map[string]string{"team": "a", TagUID: "forged"}
+
+	tags := map[string]string{}
+	for _, t := range a.withOwnerTags(db.Spec.Tags, db) {
+		tags[*t.Key] = *t.Value
+	}
+	assert.Equal(t, map[string]string{"team": "a", TagNamespace: "team-a", TagName: "orders", TagUID: "1234"}, tags)
+}
+
+func TestCheckAdoptable(t *testing.T) {
+	a := &AWS{ClusterID: "prod"}
+	db := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a", UID: "1234"}}
+
+	assert.NoError(t, checkAdoptable("orders", nil, "prod", db))
+	assert.NoError(t, checkAdoptable("orders", []rds.Tag{{Key: aws.String("team"), Value: aws.String("legacy")}}, "prod", db))
+	assert.NoError(t, checkAdoptable("orders", a.OwnerTags(db), "prod", db))
+
+	other := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-b", UID: "5678"}}
+	assert.True(t, IsOwnershipError(checkAdoptable("orders", a.OwnerTags(other), "prod", db)))
+	assert.True(t, IsOwnershipError(checkAdoptable("orders", []rds.Tag{{Key: aws.String(TagClusterID), Value: aws.String("staging")}}, "prod", db)))
+	assert.True(t, IsOwnershipError(checkAdoptable("orders", []rds.Tag{{Key: aws.String(TagClusterID), Value: aws.String("prod")}}, "", db)))
+}
@@ -0,0 +1,320 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ClusterActuator manages aurora clusters with the clients of the Rds actuator
+type ClusterActuator struct {
+	*Actuator
+}
+
+// Cluster returns the actuator of the RdsCluster objects
+func (a *Actuator) Cluster() *ClusterActuator {
+	return &ClusterActuator{Actuator: a}
+}
+
+func (a *ClusterActuator) Reconcile(c *databasesv1.RdsCluster, client *controllers.RdsClusterReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsClusterStatus, err error) {
+	scoped, err := a.forConfig(providerConfigName(c.Spec.ProviderConfigRef), c.Namespace, client.Client, ctx)
+	if err != nil {
+		return a.unscoped(c, databasesv1.NewClusterStatus(err.Error(), databasesv1.StateError), err), err
+	}
+	clusters := scoped.Cluster()
+	status, err = clusters.reconcile(c, client)
+	return clusters.observe(c, status, err), err
+}
+
+func (a *ClusterActuator) reconcile(c *databasesv1.RdsCluster, client *controllers.RdsClusterReconciler) (status databasesv1.RdsClusterStatus, err error) {
+	log := a.log.WithValues("reconcilingCluster", c.Name)
+	log.Info("Start reconciling")
+
+	// Persist the identifier before anything gets created, so it never changes afterwards
+	if c.Status.ClusterIdentifier == "" {
+		c.Status.ClusterIdentifier = a.k8srds.ClusterIdentifier(c)
+	}
+
+	cluster, err := a.k8srds.DescribeCluster(c)
+	if err != nil {
+		return databasesv1.NewClusterStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// CREATE
+	if cluster == nil {
+		log.Info("creating")
+		password, err := a.secretPassword(c.Namespace, c.Spec.Password, c.Spec.GeneratePassword, c.Spec.Engine, clusterOwnerReference(c))
+		if err != nil {
+			return databasesv1.NewClusterStatus("Failing Geting Secret", databasesv1.StatePending), err
+		}
+		err = a.k8srds.CreateCluster(c, password)
+		if err != nil {
+			return databasesv1.NewClusterStatus(err.Error(), databasesv1.StatePending), err
+		}
+		return databasesv1.NewClusterStatus("Creating Cluster", "creating"), nil
+	}
+
+	// OWNERSHIP
+	if err := a.k8srds.VerifyClusterOwnership(c); err != nil {
+		return a.conflict(c, client, err)
+	}
+
+	currentStatus := aws.StringValue(cluster.Status)
+	if currentStatus != databasesv1.StateAvailable {
+		return databasesv1.NewClusterStatus("Cluster not in a reconcilable state, will wait", currentStatus), nil
+	}
+
+	// INSTANCES
+	// The cluster has no compute until its instances exist
+	instances, err := a.k8srds.DescribeClusterInstances(c)
+	if err != nil {
+		return databasesv1.NewClusterStatus("Failed To Describe Instances", currentStatus), err
+	}
+	members, changed, err := a.k8srds.ReconcileClusterInstances(c, cluster, instances)
+	if err != nil {
+		return databasesv1.NewClusterStatus("Failed To Reconcile Instances", currentStatus), err
+	}
+	c.Status.Members = members
+	if changed {
+		log.Info("Scaling instances", "members", members)
+		return databasesv1.NewClusterStatus(fmt.Sprintf("Scaling to %v instances", len(members)), membersState(instances)), nil
+	}
+	if waiting := unavailableMembers(instances); len(waiting) > 0 {
+		return databasesv1.NewClusterStatus(fmt.Sprintf("Waiting for instances %v", strings.Join(waiting, ", ")), membersState(instances)), nil
+	}
+
+	// SERVICES
+	log.Info("Reconciling services", "writer", aws.StringValue(cluster.Endpoint), "reader", aws.StringValue(cluster.ReaderEndpoint))
+	err = a.kubeClient.ReconcileService(c.Namespace, aws.StringValue(cluster.Endpoint), c.Name, clusterOwnerReference(c))
+	if err != nil {
+		return databasesv1.NewClusterStatus("Failing Reconciled Service", currentStatus), err
+	}
+	err = a.kubeClient.ReconcileService(c.Namespace, aws.StringValue(cluster.ReaderEndpoint), c.ReaderService(), clusterOwnerReference(c))
+	if err != nil {
+		return databasesv1.NewClusterStatus("Failing Reconciled Reader Service", currentStatus), err
+	}
+
+	err = a.reconcileClusterConnectionSecret(c, cluster)
+	if err != nil {
+		return databasesv1.NewClusterStatus("Failing Reconciled Connection Secret", currentStatus), err
+	}
+
+	log.Info("cluster reconciliation done")
+	return databasesv1.NewClusterStatus("Cluster reconciled", currentStatus), nil
+}
+
+func (a *ClusterActuator) Delete(c *databasesv1.RdsCluster, client *controllers.RdsClusterReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsClusterStatus, err error) {
+	scoped, err := a.forConfig(providerConfigName(c.Spec.ProviderConfigRef), c.Namespace, client.Client, ctx)
+	if err != nil {
+		return a.unscoped(c, databasesv1.NewClusterStatus(err.Error(), databasesv1.StateError), err), err
+	}
+	clusters := scoped.Cluster()
+	status, err = clusters.delete(c, client)
+	return clusters.observe(c, status, err), err
+}
+
+func (a *ClusterActuator) delete(c *databasesv1.RdsCluster, client *controllers.RdsClusterReconciler) (status databasesv1.RdsClusterStatus, err error) {
+	log := a.log.WithValues("deleteCluster", c.Name)
+	policy := c.GetDeletionPolicy()
+	if err := policy.Validate(); err != nil {
+		return databasesv1.NewClusterStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	cluster, err := a.k8srds.DescribeCluster(c)
+	if err != nil {
+		return databasesv1.NewClusterStatus("Error Getting Cluster", databasesv1.StateError), err
+	}
+
+	if cluster != nil && policy != databasesv1.DeletionPolicyRetain {
+		if err := a.k8srds.VerifyClusterOwnership(c); err != nil {
+			return a.conflict(c, client, err)
+		}
+
+		currentStatus := aws.StringValue(cluster.Status)
+		if currentStatus == "deleting" {
+			return databasesv1.NewClusterStatus("Deleting", currentStatus), nil
+		}
+
+		// The instances go first, a cluster can't be deleted while it has some
+		instances, err := a.k8srds.DescribeClusterInstances(c)
+		if err != nil {
+			return databasesv1.NewClusterStatus("Failed To Describe Instances", currentStatus), err
+		}
+		remaining, err := a.k8srds.DeleteClusterInstances(c, instances)
+		if err != nil || remaining {
+			return databasesv1.NewClusterStatus("Deleting Instances", "deleting"), err
+		}
+
+		// The identifier of the final snapshot is persisted before the deletion starts, so the
+		// snapshot is always checked once the cluster is gone
+		finalSnapshot := ""
+		if policy != databasesv1.DeletionPolicyDelete {
+			if c.Status.FinalSnapshotIdentifier == "" {
+				c.Status.FinalSnapshotIdentifier = k8srds.FinalSnapshotIdentifier(a.k8srds.ClusterIdentifier(c), time.Now())
+				return databasesv1.NewClusterStatus("Preparing final snapshot", currentStatus), nil
+			}
+			finalSnapshot = c.Status.FinalSnapshotIdentifier
+		}
+
+		log.Info("deleting cluster", "deletionPolicy", policy, "finalSnapshot", finalSnapshot)
+		err = a.k8srds.DeleteCluster(c, finalSnapshot)
+		if err != nil {
+			return databasesv1.NewClusterStatus(err.Error(), currentStatus), err
+		}
+		return databasesv1.NewClusterStatus("Deleting", "deleting"), nil
+	}
+
+	// FINAL SNAPSHOT
+	// The cluster is gone, the object only goes away once the final snapshot is available
+	if cluster == nil && policy == databasesv1.DeletionPolicySnapshot && c.Status.FinalSnapshotIdentifier != "" && c.Status.FinalSnapshotARN == "" {
+		status, err := a.verifyFinalSnapshot(c, client)
+		if err != nil || c.Status.FinalSnapshotARN == "" {
+			return status, err
+		}
+	}
+
+	if policy == databasesv1.DeletionPolicyRetain {
+		log.Info("releasing password secret")
+		err = a.kubeClient.RemoveOwnerReference(c.Namespace, c.Spec.Password.Name, c.UID)
+		if err != nil {
+			return databasesv1.NewClusterStatus("ERROR Releasing password secret", c.Status.State), err
+		}
+	}
+
+	log.Info("deleting svc")
+	for _, name := range []string{c.Name, c.ReaderService()} {
+		err = a.kubeClient.DeleteService(c.Namespace, name, c.UID)
+		if err != nil {
+			return databasesv1.NewClusterStatus("ERROR Deleting svc", c.Status.State), err
+		}
+	}
+
+	log.Info("Deletion of cluster done")
+	return databasesv1.NewClusterStatus("Deleted", databasesv1.StateDeleted), nil
+}
+
+// verifyFinalSnapshot waits for the final snapshot of the deleted cluster, setting its ARN once
+// it is available. A missing or failed snapshot blocks the deletion
+func (a *ClusterActuator) verifyFinalSnapshot(c *databasesv1.RdsCluster, client *controllers.RdsClusterReconciler) (databasesv1.RdsClusterStatus, error) {
+	identifier := c.Status.FinalSnapshotIdentifier
+
+	snapshot, err := a.k8srds.DescribeClusterSnapshot(identifier)
+	if err != nil {
+		return databasesv1.NewClusterStatus("Error Getting Final Snapshot", databasesv1.StatePending), err
+	}
+
+	if snapshot == nil || aws.StringValue(snapshot.Status) == "failed" {
+		err = fmt.Errorf("final snapshot %v failed, remove the finalizer %v by hand once the data is safe", identifier, databasesv1.RdsFinalizer)
+		a.clusterEvent(client, c, corev1.EventTypeWarning, "FinalSnapshotFailed", err.Error())
+		return databasesv1.NewClusterStatus(err.Error(), databasesv1.StateSnapshotFailed), err
+	}
+
+	if aws.StringValue(snapshot.Status) != "available" {
+		message := fmt.Sprintf("Waiting for final snapshot %v, %v%% done", identifier, aws.Int64Value(snapshot.PercentProgress))
+		return databasesv1.NewClusterStatus(message, databasesv1.StatePending), nil
+	}
+
+	err = a.k8srds.TagResource(aws.StringValue(snapshot.DBClusterSnapshotArn), c)
+	if err != nil {
+		return databasesv1.NewClusterStatus("Error Tagging Final Snapshot", databasesv1.StatePending), err
+	}
+
+	c.Status.FinalSnapshotARN = aws.StringValue(snapshot.DBClusterSnapshotArn)
+	a.clusterEvent(client, c, corev1.EventTypeNormal, "FinalSnapshot", fmt.Sprintf("Final snapshot %v available: %v", identifier, c.Status.FinalSnapshotARN))
+	return databasesv1.NewClusterStatus("Final snapshot available", databasesv1.StatePending), nil
+}
+
+// clusterEvent records a kubernetes event on the cluster, when the reconciler has a recorder
+func (a *ClusterActuator) clusterEvent(client *controllers.RdsClusterReconciler, c *databasesv1.RdsCluster, eventType string, reason string, message string) {
+	if client == nil || client.Recorder == nil {
+		return
+	}
+	client.Recorder.Event(c, eventType, reason, message)
+}
+
+// conflict reports a cluster owned by someone else
+func (a *ClusterActuator) conflict(c *databasesv1.RdsCluster, client *controllers.RdsClusterReconciler, err error) (databasesv1.RdsClusterStatus, error) {
+	if !k8srds.IsOwnershipError(err) {
+		return databasesv1.NewClusterStatus("Error Verifying Ownership", databasesv1.StateError), err
+	}
+	a.clusterEvent(client, c, corev1.EventTypeWarning, "Conflict", err.Error())
+	return databasesv1.NewClusterStatus(err.Error(), databasesv1.StateConflict), err
+}
+
+// reconcileClusterConnectionSecret writes the connection secret of the writer, with the reader host
+func (a *ClusterActuator) reconcileClusterConnectionSecret(c *databasesv1.RdsCluster, cluster *rds.DBCluster) error {
+	password, err := a.kubeClient.GetSecret(c.Namespace, c.Spec.Password.Name, c.Spec.Password.Key)
+	if err != nil {
+		a.log.Info("connection secret without password", "name", c.Name, "error", err.Error())
+	}
+
+	data := actuators.ConnectionData(c.Spec.Engine, c.Spec.Username, c.Spec.DBName, aws.StringValue(cluster.Endpoint), aws.Int64Value(cluster.Port), password)
+	data["readerHost"] = []byte(aws.StringValue(cluster.ReaderEndpoint))
+	return a.kubeClient.ReconcileSecret(c.Namespace, c.ConnectionSecret(), data, clusterOwnerReference(c))
+}
+
+// unscoped sets the state and the conditions while the account of the cluster is unknown
+func (a *ClusterActuator) unscoped(c *databasesv1.RdsCluster, status databasesv1.RdsClusterStatus, err error) databasesv1.RdsClusterStatus {
+	observed := *c.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+	observed.Conditions = conditionsFor(observed.Conditions, observed.State, observed.Message, c, err)
+	return observed
+}
+
+// observe completes the status with the cluster endpoints and the conditions
+func (a *ClusterActuator) observe(c *databasesv1.RdsCluster, status databasesv1.RdsClusterStatus, err error) databasesv1.RdsClusterStatus {
+	observed := *c.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+
+	if status.State != databasesv1.StateConflict {
+		cluster, cerr := a.k8srds.DescribeCluster(c)
+		if cerr != nil {
+			a.log.Info("unable to describe cluster", "name", c.Name, "error", cerr)
+		} else if cluster == nil {
+			observed.WriterEndpoint = ""
+			observed.ReaderEndpoint = ""
+			observed.Port = 0
+		} else {
+			observed.ARN = aws.StringValue(cluster.DBClusterArn)
+			observed.WriterEndpoint = aws.StringValue(cluster.Endpoint)
+			observed.ReaderEndpoint = aws.StringValue(cluster.ReaderEndpoint)
+			observed.Port = aws.Int64Value(cluster.Port)
+			observed.EngineVersion = aws.StringValue(cluster.EngineVersion)
+		}
+	}
+
+	observed.Conditions = conditionsFor(observed.Conditions, observed.State, observed.Message, c, err)
+	return observed
+}
+
+// membersState is creating while no instance is available yet, modifying afterwards
+func membersState(instances []rds.DBInstance) string {
+	for _, i := range instances {
+		if aws.StringValue(i.DBInstanceStatus) == databasesv1.StateAvailable {
+			return databasesv1.StateModifying
+		}
+	}
+	return "creating"
+}
+
+func unavailableMembers(instances []rds.DBInstance) []string {
+	var waiting []string
+	for _, i := range instances {
+		if aws.StringValue(i.DBInstanceStatus) != databasesv1.StateAvailable {
+			waiting = append(waiting, aws.StringValue(i.DBInstanceIdentifier))
+		}
+	}
+	return waiting
+}
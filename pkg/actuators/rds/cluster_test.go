@@ -0,0 +1,187 @@
+package rds
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators/rds/client/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testCluster() *databasesv1.RdsCluster {
+	return &databasesv1.RdsCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default", UID: "5b7e4c1a"},
+		Spec: databasesv1.RdsClusterSpec{
+			Class:             "db.r5.large",
+			DBName:            "app",
+			DBSubnetGroupName: "orders",
+			Engine:            "aurora-postgresql",
+			Instances:         1,
+			Password:          corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "pgsql-password"}, Key: "password"},
+			Username:          "app",
+		},
+	}
+}
+
+func testClusterReconciler(t *testing.T) *controllers.RdsClusterReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	return &controllers.RdsClusterReconciler{
+		Client:   ctrlfake.NewFakeClientWithScheme(scheme),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+// reconcileCluster reconciles the cluster until it is reconciled, advancing the backend in between
+func reconcileCluster(t *testing.T, a *ClusterActuator, backend *fake.Backend, c *databasesv1.RdsCluster) {
+	client := testClusterReconciler(t)
+	key := types.NamespacedName{Namespace: c.Namespace, Name: c.Name}
+	for i := 0; i < 10; i++ {
+		status, err := a.Reconcile(c, client, context.Background(), key)
+		assert.NoError(t, err)
+		c.Status = status
+		if status.Message == "Cluster reconciled" {
+			return
+		}
+		backend.Advance()
+	}
+	t.Fatalf("cluster not reconciled: %v", c.Status.Message)
+}
+
+// clusterInstances returns the identifiers of the instances of the cluster in the backend
+func clusterInstances(backend *fake.Backend) []string {
+	var ids []string
+	for id, i := range backend.Instances {
+		if aws.StringValue(i.DBClusterIdentifier) == "orders" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestClusterScaling(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret()).Cluster()
+	c := testCluster()
+
+	reconcileCluster(t, a, backend, c)
+	assert.Equal(t, []string{"orders-0"}, c.Status.Members)
+	assert.Equal(t, []string{"orders-0"}, clusterInstances(backend))
+	assert.Equal(t, databasesv1.StateAvailable, c.Status.State)
+
+	// Scale up
+	c.Spec.Instances = 3
+	reconcileCluster(t, a, backend, c)
+	assert.Equal(t, []string{"orders-0", "orders-1", "orders-2"}, c.Status.Members)
+	assert.Equal(t, []string{"orders-0", "orders-1", "orders-2"}, clusterInstances(backend))
+
+	// Scale down
+	c.Spec.Instances = 2
+	reconcileCluster(t, a, backend, c)
+	assert.Equal(t, []string{"orders-0", "orders-1"}, c.Status.Members)
+	assert.Equal(t, []string{"orders-0", "orders-1"}, clusterInstances(backend))
+}
+
+func TestClusterScaleDownKeepsWriter(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret()).Cluster()
+	c := testCluster()
+	c.Spec.Instances = 3
+	reconcileCluster(t, a, backend, c)
+
+	// A failover made the last instance the writer
+	for i := range backend.Clusters["orders"].DBClusterMembers {
+		m := &backend.Clusters["orders"].DBClusterMembers[i]
+		m.IsClusterWriter = aws.Bool(aws.StringValue(m.DBInstanceIdentifier) == "orders-2")
+	}
+
+	c.Spec.Instances = 2
+	reconcileCluster(t, a, backend, c)
+	assert.Equal(t, []string{"orders-0", "orders-2"}, c.Status.Members)
+	assert.Equal(t, []string{"orders-0", "orders-2"}, clusterInstances(backend))
+
+	c.Spec.Instances = 1
+	reconcileCluster(t, a, backend, c)
+	assert.Equal(t, []string{"orders-2"}, c.Status.Members)
+	assert.Equal(t, []string{"orders-2"}, clusterInstances(backend))
+}
+
+func TestClusterDeleteFinalSnapshot(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret()).Cluster()
+	c := testCluster()
+	reconcileCluster(t, a, backend, c)
+	client := testClusterReconciler(t)
+	key := types.NamespacedName{Namespace: c.Namespace, Name: c.Name}
+	remove := func() databasesv1.RdsClusterStatus {
+		status, err := a.Delete(c, client, context.Background(), key)
+		assert.NoError(t, err)
+		c.Status = status
+		backend.Advance()
+		return status
+	}
+
+	// The instances go first
+	remove()
+	assert.Empty(t, clusterInstances(backend))
+
+	// The identifier of the final snapshot is persisted before the cluster is deleted
+	status := remove()
+	assert.Equal(t, "Preparing final snapshot", status.Message)
+	assert.NotEmpty(t, status.FinalSnapshotIdentifier)
+	assert.NotContains(t, backend.Operations(), "DeleteDBCluster")
+
+	status = remove()
+	assert.Equal(t, "deleting", status.State)
+	assert.Contains(t, backend.Operations(), "DeleteDBCluster")
+	assert.Contains(t, backend.ClusterSnapshots, c.Status.FinalSnapshotIdentifier)
+
+	// The object waits for the final snapshot once the cluster is gone
+	assert.Empty(t, backend.Clusters)
+	backend.ClusterSnapshots[c.Status.FinalSnapshotIdentifier].Status = aws.String("creating")
+	status = remove()
+	assert.Equal(t, databasesv1.StatePending, status.State)
+	assert.Contains(t, status.Message, "Waiting for final snapshot")
+
+	status = remove()
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.Equal(t, "arn:aws:rds:us-east-1:123456789012:cluster-snapshot:"+c.Status.FinalSnapshotIdentifier, status.FinalSnapshotARN)
+	assert.NoError(t, checkTags(backend, status.FinalSnapshotARN, "databases.tks.sh/uid", "5b7e4c1a"))
+}
+
+func TestClusterDeleteFinalSnapshotFailed(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret()).Cluster()
+	c := testCluster()
+	reconcileCluster(t, a, backend, c)
+	client := testClusterReconciler(t)
+	key := types.NamespacedName{Namespace: c.Namespace, Name: c.Name}
+
+	var status databasesv1.RdsClusterStatus
+	var err error
+	for i := 0; i < 10 && len(backend.Clusters) > 0; i++ {
+		status, err = a.Delete(c, client, context.Background(), key)
+		assert.NoError(t, err)
+		c.Status = status
+		backend.Advance()
+	}
+	backend.ClusterSnapshots[c.Status.FinalSnapshotIdentifier].Status = aws.String("failed")
+
+	// A failed snapshot blocks the deletion
+	status, err = a.Delete(c, client, context.Background(), key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateSnapshotFailed, status.State)
+	assert.Empty(t, status.FinalSnapshotARN)
+}
@@ -0,0 +1,35 @@
+package rds
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// reconcileConnectionSecret keeps the connection secret in sync with the endpoint and the password
+func (a *Actuator) reconcileConnectionSecret(db *databasesv1.Rds) error {
+	instance, err := a.k8srds.DescribeDatabase(db)
+	if err != nil {
+		return err
+	}
+	if instance == nil || instance.Endpoint == nil {
+		return fmt.Errorf("endpoint is not available yet")
+	}
+
+	// Restored databases keep the password of the snapshot, the secret may not exist
+	password, err := a.kubeClient.GetSecret(db.Namespace, db.Spec.Password.Name, db.Spec.Password.Key)
+	if err != nil {
+		a.log.Info("connection secret without password", "name", db.Name, "error", err.Error())
+	}
+
+	data := connectionData(db, aws.StringValue(instance.Endpoint.Address), aws.Int64Value(instance.Endpoint.Port), password)
+	return a.kubeClient.ReconcileSecret(db.Namespace, db.ConnectionSecret(), data, ownerReference(db))
+}
+
+// connectionData builds the content of the connection secret, with the engine specific
+// connection URIs
+func connectionData(db *databasesv1.Rds, host string, port int64, password string) map[string][]byte {
+	return actuators.ConnectionData(db.Spec.Engine, db.Spec.Username, db.Spec.DBName, host, port, password)
+}
@@ -0,0 +1,143 @@
+package rds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators/rds/client/fake"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// testController returns the controller of the Rds objects driving the actuator, the objects
+// are the ones of its fake client
+func testController(t *testing.T, a *Actuator, db *databasesv1.Rds) *controllers.RdsReconciler {
+	controller := testReconciler(t, db)
+	controller.Log = zap.Logger(true)
+	controller.Actuator = a
+	return controller
+}
+
+// storedDatabase returns the database as persisted by the controller
+func storedDatabase(t *testing.T, controller *controllers.RdsReconciler) *databasesv1.Rds {
+	db := &databasesv1.Rds{}
+	assert.NoError(t, controller.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "pgsql"}, db))
+	return db
+}
+
+func TestControllerLifecycle(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	db := testDatabase()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicyDelete
+	controller := testController(t, a, db)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pgsql"}}
+
+	// The finalizer is added before anything gets created
+	result, err := controller.Reconcile(req)
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Equal(t, []string{databasesv1.RdsFinalizer}, storedDatabase(t, controller).Finalizers)
+	assert.Empty(t, backend.Instances)
+
+	// Requeued while the instance is created, the status is persisted on every pass
+	result, err = controller.Reconcile(req)
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{Requeue: true, RequeueAfter: 100}, result)
+	stored := storedDatabase(t, controller)
+	assert.Equal(t, "pgsql", stored.Status.InstanceIdentifier)
+	assert.Equal(t, databasesv1.StatePending, stored.Status.State)
+	assert.Contains(t, backend.Instances, "pgsql")
+
+	for i := 0; i < 10 && result.Requeue; i++ {
+		backend.Advance()
+		result, err = controller.Reconcile(req)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, ctrl.Result{}, result)
+	stored = storedDatabase(t, controller)
+	assert.Equal(t, databasesv1.StateAvailable, stored.Status.State)
+	assert.NotEmpty(t, stored.Status.ARN)
+	assert.True(t, databasesv1.IsConditionTrue(stored.Status.Conditions, databasesv1.ConditionReady))
+
+	// Deleting keeps the finalizer until the instance is gone
+	now := metav1.Now()
+	stored.DeletionTimestamp = &now
+	assert.NoError(t, controller.Update(context.Background(), stored))
+	backend.Operations()
+	result, err = controller.Reconcile(req)
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{Requeue: true, RequeueAfter: 100}, result)
+	assert.Contains(t, backend.Operations(), "DeleteDBInstance")
+	assert.Equal(t, []string{databasesv1.RdsFinalizer}, storedDatabase(t, controller).Finalizers)
+
+	for i := 0; i < 10 && result.Requeue; i++ {
+		backend.Advance()
+		result, err = controller.Reconcile(req)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, ctrl.Result{}, result)
+	stored = storedDatabase(t, controller)
+	assert.Equal(t, databasesv1.StateDeleted, stored.Status.State)
+	assert.Empty(t, stored.Finalizers)
+	assert.Empty(t, backend.Instances)
+
+	// Without the finalizer there is nothing left to do
+	backend.Operations()
+	result, err = controller.Reconcile(req)
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Empty(t, backend.Operations())
+}
+
+func TestControllerError(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	backend.Errors["CreateDBInstance"] = fake.Error("InsufficientDBInstanceCapacity", "No capacity")
+	a := testActuator(backend, testSecret())
+	db := testDatabase()
+	db.Finalizers = []string{databasesv1.RdsFinalizer}
+	controller := testController(t, a, db)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pgsql"}}
+
+	// The error is returned for the backoff of the manager, and persisted for the users
+	result, err := controller.Reconcile(req)
+	assert.Error(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	stored := storedDatabase(t, controller)
+	assert.Contains(t, stored.Status.Message, "InsufficientDBInstanceCapacity")
+	assert.True(t, databasesv1.IsConditionTrue(stored.Status.Conditions, databasesv1.ConditionDegraded))
+
+	delete(backend.Errors, "CreateDBInstance")
+	result, err = controller.Reconcile(req)
+	assert.NoError(t, err)
+	assert.True(t, result.Requeue)
+	assert.Contains(t, backend.Instances, "pgsql")
+}
+
+func TestControllerPasswordRotation(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	db := testDatabase()
+	db.Finalizers = []string{databasesv1.RdsFinalizer}
+	db.Spec.PasswordRotationInterval = &metav1.Duration{Duration: time.Hour}
+	controller := testController(t, a, db)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pgsql"}}
+
+	result, err := controller.Reconcile(req)
+	for i := 0; i < 10 && result.Requeue; i++ {
+		backend.Advance()
+		result, err = controller.Reconcile(req)
+	}
+	assert.NoError(t, err)
+
+	// An available database comes back when its password is due
+	assert.False(t, result.Requeue)
+	assert.True(t, result.RequeueAfter > 59*time.Minute && result.RequeueAfter <= time.Hour, result.RequeueAfter)
+	assert.NotNil(t, storedDatabase(t, controller).Status.PasswordRotatedAt)
+}
@@ -0,0 +1,61 @@
+package rds
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// replicateSnapshot copies and shares an available snapshot. Returns the state of the copies and
+// whether they are all available and shared
+func (a *Actuator) replicateSnapshot(snapshot *rds.DBSnapshot, copies []databasesv1.SnapshotCopy, o metav1.Object) ([]databasesv1.SnapshotCopyStatus, bool, error) {
+	var statuses []databasesv1.SnapshotCopyStatus
+	done := true
+	for _, c := range copies {
+		status := databasesv1.SnapshotCopyStatus{
+			Region:             c.Region,
+			SnapshotIdentifier: a.k8srds.CopyIdentifier(aws.StringValue(snapshot.DBSnapshotIdentifier), c),
+			State:              "creating",
+		}
+		if status.Region == "" {
+			status.Region = a.k8srds.Region()
+		}
+
+		target := snapshot
+		if a.k8srds.NeedsCopy(c) {
+			var err error
+			target, err = a.k8srds.CopySnapshot(snapshot, c, o)
+			if err != nil {
+				return append(statuses, status), false, err
+			}
+		}
+		if target != nil {
+			status.ARN = aws.StringValue(target.DBSnapshotArn)
+			status.State = aws.StringValue(target.Status)
+		}
+
+		// Copies are shared once available
+		if status.State != databasesv1.StateAvailable {
+			done = false
+		} else if err := a.k8srds.ShareSnapshot(c.Region, status.SnapshotIdentifier, c.AccountIDs); err != nil {
+			return append(statuses, status), false, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, done, nil
+}
+
+// deleteSnapshotCopies deletes the copies made of a snapshot, skipping the snapshot itself when it was
+// only shared
+func (a *Actuator) deleteSnapshotCopies(identifier string, copies []databasesv1.SnapshotCopyStatus) error {
+	for _, c := range copies {
+		if c.Region == a.k8srds.Region() && c.SnapshotIdentifier == identifier {
+			continue
+		}
+		if err := a.k8srds.DeleteSnapshotIn(c.Region, c.SnapshotIdentifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,14 +2,32 @@ package rds
 
 import (
 	"fmt"
+	"reflect"
 
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
 	"github.com/k0kubun/pp"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// ownerReference makes the Rds object the controller of the objects created for it
+func ownerReference(db *databasesv1.Rds) metav1.OwnerReference {
+	return *metav1.NewControllerRef(db, databasesv1.GroupVersion.WithKind("Rds"))
+}
+
+// replicaOwnerReference is ownerReference for read replicas
+func replicaOwnerReference(r *databasesv1.RdsReadReplica) metav1.OwnerReference {
+	return *metav1.NewControllerRef(r, databasesv1.GroupVersion.WithKind("RdsReadReplica"))
+}
+
+// clusterOwnerReference is ownerReference for clusters
+func clusterOwnerReference(c *databasesv1.RdsCluster) metav1.OwnerReference {
+	return *metav1.NewControllerRef(c, databasesv1.GroupVersion.WithKind("RdsCluster"))
+}
+
 // create an External named service object for Kubernetes
 func (k *Kube) createServiceObj(s *v1.Service, namespace string, hostname string, internalname string) *v1.Service {
 	s.Spec.Type = "ExternalName"
@@ -21,55 +39,174 @@ func (k *Kube) createServiceObj(s *v1.Service, namespace string, hostname string
 	return s
 }
 
-// CreateService Creates or updates a service in Kubernetes with the new information
-func (k *Kube) ReconcileService(namespace string, hostname string, internalName string) (err error) {
+// ReconcileService creates or updates the service pointing to the host, owned by the given object.
+// A service controlled by anything else is never touched
+func (k *Kube) ReconcileService(namespace string, hostname string, internalName string, owner metav1.OwnerReference) error {
 	// create a service in kubernetes that points to the AWS RDS instance
 	serviceInterface := k.Client.CoreV1().Services(namespace)
 
 	s, err := serviceInterface.Get(internalName, metav1.GetOptions{})
-
-	s = k.createServiceObj(s, namespace, hostname, internalName)
-
-	if err == nil {
-		_, err = serviceInterface.Update(s)
-	}
-
-	if err != nil && metav1.Status(err.(k8s_errors.APIStatus).Status()).Code == 404 {
+	if k8s_errors.IsNotFound(err) {
+		s = k.createServiceObj(&v1.Service{}, namespace, hostname, internalName)
+		s.OwnerReferences = []metav1.OwnerReference{owner}
 		_, err = serviceInterface.Create(s)
+		return errors.Wrap(err, fmt.Sprintf("unable to create service %v", internalName))
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to fetch service %v", internalName))
+	}
+	if !controlsService(s, owner.UID) {
+		return fmt.Errorf("service %v already exists and is not controlled by %v %v", internalName, owner.Kind, owner.Name)
 	}
 
-	return err
+	if metav1.GetControllerOf(s) == nil {
+		s.OwnerReferences = append(s.OwnerReferences, owner)
+	}
+	s = k.createServiceObj(s, namespace, hostname, internalName)
+	_, err = serviceInterface.Update(s)
+	return errors.Wrap(err, fmt.Sprintf("unable to update service %v", internalName))
 }
 
-// HasService
-func (k *Kube) HasService(namespace string, internalName string) bool {
-	_, err := k.Client.CoreV1().Services(namespace).Get(internalName, metav1.GetOptions{})
-	return err == nil
+// HasService is true when the service exists and belongs to the object
+func (k *Kube) HasService(namespace string, internalName string, uid types.UID) bool {
+	s, err := k.Client.CoreV1().Services(namespace).Get(internalName, metav1.GetOptions{})
+	return err == nil && controlsService(s, uid)
 }
 
-func (k *Kube) DeleteService(namespace string, dbname string) error {
+// DeleteService deletes the service of the object, a service controlled by anything else is left alone
+func (k *Kube) DeleteService(namespace string, dbname string, uid types.UID) error {
 	serviceInterface := k.Client.CoreV1().Services(namespace)
-	err := serviceInterface.Delete(dbname, &metav1.DeleteOptions{})
+	s, err := serviceInterface.Get(dbname, metav1.GetOptions{})
+	if k8s_errors.IsNotFound(err) {
+		return nil
+	}
 	if err != nil {
-		// @TODO: Refactor, cuz ugly as fuck
-		code := metav1.Status(err.(k8s_errors.APIStatus).Status()).Code
-		if code == 404 {
-			return nil
-		}
+		return errors.Wrap(err, fmt.Sprintf("unable to fetch service %v", dbname))
+	}
+	if !controlsService(s, uid) {
+		return nil
+	}
+
+	err = serviceInterface.Delete(dbname, &metav1.DeleteOptions{})
+	if err != nil && !k8s_errors.IsNotFound(err) {
 		return errors.Wrap(err, fmt.Sprintf("delete of service %v failed in namespace %v", dbname, namespace))
 	}
 	return nil
 }
 
+// controlsService tells if the service belongs to the object. Services created before they had owner
+// references carry the origin annotation only, they belong to the object of the same name
+func controlsService(s *v1.Service, uid types.UID) bool {
+	if controller := metav1.GetControllerOf(s); controller != nil {
+		return controller.UID == uid
+	}
+	return s.Annotations["origin"] == "rds"
+}
+
+// GetSecret returns the value of a key of a secret, a not found error if the secret or the key are missing
 func (k *Kube) GetSecret(namespace string, name string, key string) (string, error) {
 	secret, err := k.Client.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
 	if err != nil {
 		return "", errors.Wrap(err, fmt.Sprintf("unable to fetch secret %v", name))
 	}
-	password := secret.Data[key]
+	password, ok := secret.Data[key]
+	if !ok {
+		return "", k8s_errors.NewNotFound(v1.Resource("secrets"), fmt.Sprintf("%v/%v", name, key))
+	}
 	return string(password), nil
 }
 
+// SetSecretKey sets a key of a secret, the secret is created owned by the given object when missing
+func (k *Kube) SetSecretKey(namespace string, name string, key string, value string, owner metav1.OwnerReference) error {
+	secretInterface := k.Client.CoreV1().Secrets(namespace)
+
+	s, err := secretInterface.Get(name, metav1.GetOptions{})
+	if k8s_errors.IsNotFound(err) {
+		s = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				Annotations:     map[string]string{"origin": "rds"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: map[string][]byte{key: []byte(value)},
+		}
+		_, err = secretInterface.Create(s)
+		return errors.Wrap(err, fmt.Sprintf("unable to create secret %v", name))
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to fetch secret %v", name))
+	}
+
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+	s.Data[key] = []byte(value)
+	_, err = secretInterface.Update(s)
+	return errors.Wrap(err, fmt.Sprintf("unable to update secret %v", name))
+}
+
+// ReconcileSecret Creates or updates a secret owned by the given object
+func (k *Kube) ReconcileSecret(namespace string, name string, data map[string][]byte, owner metav1.OwnerReference) error {
+	secretInterface := k.Client.CoreV1().Secrets(namespace)
+
+	s, err := secretInterface.Get(name, metav1.GetOptions{})
+	if k8s_errors.IsNotFound(err) {
+		s = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				Annotations:     map[string]string{"origin": "rds"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: data,
+		}
+		_, err = secretInterface.Create(s)
+		return errors.Wrap(err, fmt.Sprintf("unable to create secret %v", name))
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to fetch secret %v", name))
+	}
+	if controller := metav1.GetControllerOf(s); controller == nil || controller.UID != owner.UID {
+		return fmt.Errorf("secret %v already exists and is not controlled by %v %v", name, owner.Kind, owner.Name)
+	}
+
+	if reflect.DeepEqual(s.Data, data) {
+		return nil
+	}
+	s.Data = data
+	_, err = secretInterface.Update(s)
+	return errors.Wrap(err, fmt.Sprintf("unable to update secret %v", name))
+}
+
+// RemoveOwnerReference releases a secret from its owner, missing secrets are ignored
+func (k *Kube) RemoveOwnerReference(namespace string, name string, uid types.UID) error {
+	secretInterface := k.Client.CoreV1().Secrets(namespace)
+
+	s, err := secretInterface.Get(name, metav1.GetOptions{})
+	if k8s_errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to fetch secret %v", name))
+	}
+
+	var owners []metav1.OwnerReference
+	for _, o := range s.OwnerReferences {
+		if o.UID != uid {
+			owners = append(owners, o)
+		}
+	}
+	if len(owners) == len(s.OwnerReferences) {
+		return nil
+	}
+	s.OwnerReferences = owners
+	_, err = secretInterface.Update(s)
+	return errors.Wrap(err, fmt.Sprintf("unable to update secret %v", name))
+}
+
 func (k *Kube) hasService(namespace string, hostname string, internalname string) bool {
 	serviceInterface := k.Client.CoreV1().Services(namespace)
 	pp.Println(serviceInterface)
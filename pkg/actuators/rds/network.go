@@ -0,0 +1,125 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+)
+
+// Network selects the subnets and the security groups by IDs or by tags. Without any, the subnets of
+// the VPC and the security groups of a node are used
+type Network struct {
+	SubnetIDs         []string
+	PublicSubnetIDs   []string
+	SubnetTags        map[string]string
+	SecurityGroupIDs  []string
+	SecurityGroupTags map[string]string
+}
+
+// resolveSubnets returns the private and the public subnets, a subnet is public when it maps public IPs on launch
+func resolveSubnets(svc k8srds.EC2API, kubectl kubernetes.Interface, n Network) ([]string, []string, error) {
+	if len(n.SubnetIDs) > 0 || len(n.PublicSubnetIDs) > 0 {
+		return n.SubnetIDs, n.PublicSubnetIDs, nil
+	}
+
+	filters := tagFilters(n.SubnetTags)
+	if len(filters) == 0 {
+		vpcID, _, err := nodeNetwork(svc, kubectl)
+		if err != nil {
+			return nil, nil, err
+		}
+		filters = []ec2.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}}
+	}
+
+	res, err := svc.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{Filters: filters}).Send(context.Background())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to describe subnets")
+	}
+	private, public := splitSubnets(res.Subnets)
+	return private, public, nil
+}
+
+// resolveSecurityGroups returns the security groups of new instances
+func resolveSecurityGroups(svc k8srds.EC2API, kubectl kubernetes.Interface, n Network) ([]string, error) {
+	if len(n.SecurityGroupIDs) > 0 {
+		return n.SecurityGroupIDs, nil
+	}
+
+	filters := tagFilters(n.SecurityGroupTags)
+	if len(filters) == 0 {
+		_, groups, err := nodeNetwork(svc, kubectl)
+		return groups, err
+	}
+
+	res, err := svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{Filters: filters}).Send(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to describe security groups")
+	}
+	var groups []string
+	for _, g := range res.SecurityGroups {
+		groups = append(groups, aws.StringValue(g.GroupId))
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// nodeNetwork returns the VPC and the security groups of the first node of the cluster
+func nodeNetwork(svc k8srds.EC2API, kubectl kubernetes.Interface) (string, []string, error) {
+	nodes, err := kubectl.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to get nodes")
+	}
+	if len(nodes.Items) == 0 {
+		return "", nil, fmt.Errorf("unable to find any nodes in the cluster, configure the subnets and the security groups")
+	}
+	// take the first one, we assume that all nodes are created in the same VPC
+	name := nodes.Items[0].Name
+
+	res, err := svc.DescribeInstancesRequest(&ec2.DescribeInstancesInput{
+		Filters: []ec2.Filter{{Name: aws.String("private-dns-name"), Values: []string{name}}},
+	}).Send(context.Background())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to describe AWS instance")
+	}
+	if len(res.Reservations) == 0 || len(res.Reservations[0].Instances) == 0 {
+		return "", nil, fmt.Errorf("unable to find the AWS instance of node %v", name)
+	}
+
+	instance := res.Reservations[0].Instances[0]
+	var groups []string
+	for _, g := range instance.SecurityGroups {
+		groups = append(groups, aws.StringValue(g.GroupId))
+	}
+	return aws.StringValue(instance.VpcId), groups, nil
+}
+
+// tagFilters matches the resources having all the tags
+func tagFilters(tags map[string]string) []ec2.Filter {
+	var filters []ec2.Filter
+	for k, v := range tags {
+		filters = append(filters, ec2.Filter{Name: aws.String("tag:" + k), Values: []string{v}})
+	}
+	sort.Slice(filters, func(i, j int) bool { return *filters[i].Name < *filters[j].Name })
+	return filters
+}
+
+func splitSubnets(subnets []ec2.Subnet) (private []string, public []string) {
+	for _, sn := range subnets {
+		if aws.BoolValue(sn.MapPublicIpOnLaunch) {
+			public = append(public, aws.StringValue(sn.SubnetId))
+		} else {
+			private = append(private, aws.StringValue(sn.SubnetId))
+		}
+	}
+	sort.Strings(private)
+	sort.Strings(public)
+	return
+}
@@ -0,0 +1,29 @@
+package rds
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSubnets(t *testing.T) {
+	private, public := splitSubnets([]ec2.Subnet{
+		{SubnetId: aws.String("subnet-b"), MapPublicIpOnLaunch: aws.Bool(false)},
+		{SubnetId: aws.String("subnet-c"), MapPublicIpOnLaunch: aws.Bool(true)},
+		{SubnetId: aws.String("subnet-a")},
+	})
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, private)
+	assert.Equal(t, []string{"subnet-c"}, public)
+}
+
+func TestTagFilters(t *testing.T) {
+	assert.Empty(t, tagFilters(nil))
+
+	filters := tagFilters(map[string]string{"tier": "db", "env": "prod"})
+	assert.Len(t, filters, 2)
+	assert.Equal(t, "tag:env", *filters[0].Name)
+	assert.Equal(t, []string{"prod"}, filters[0].Values)
+	assert.Equal(t, "tag:tier", *filters[1].Name)
+}
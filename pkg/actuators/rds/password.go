@@ -0,0 +1,116 @@
+package rds
+
+import (
+	"time"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// masterPassword returns the password referenced by the spec, when the secret or the key are
+// missing and the spec asks for it a password is generated and stored in the secret
+func (a *Actuator) masterPassword(db *databasesv1.Rds) (string, error) {
+	return a.secretPassword(db.Namespace, db.Spec.Password, db.Spec.GeneratePassword, db.Spec.Engine, ownerReference(db))
+}
+
+func (a *Actuator) secretPassword(namespace string, selector corev1.SecretKeySelector, generate bool, engine string, owner metav1.OwnerReference) (string, error) {
+	name, key := selector.Name, selector.Key
+	password, err := a.kubeClient.GetSecret(namespace, name, key)
+	if err == nil || !generate || !k8s_errors.IsNotFound(errors.Cause(err)) {
+		return password, err
+	}
+
+	a.log.Info("generating password", "name", name, "key", key)
+	password, err = actuators.GeneratePassword(engine)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to generate password")
+	}
+
+	err = a.kubeClient.SetSecretKey(namespace, name, key, password, owner)
+	if err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// reconcilePassword applies the password of the secret when it changed since the last time it was
+// applied, generating a new one first when the rotation interval expired. The hash and the rotation
+// time are kept in the status. Returns true when a new password was sent to AWS
+func (a *Actuator) reconcilePassword(db *databasesv1.Rds) (bool, error) {
+	name, key := db.Spec.Password.Name, db.Spec.Password.Key
+
+	if rotationDue(db, time.Now()) {
+		return a.rotatePassword(db)
+	}
+
+	password, err := a.kubeClient.GetSecret(db.Namespace, name, key)
+	if k8s_errors.IsNotFound(errors.Cause(err)) {
+		// Restored databases may not have a password secret, nothing to apply
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	hash := actuators.PasswordHash(db, password)
+	now := metav1.Now()
+	if db.Status.PasswordHash == "" {
+		// Created before the hash was tracked, assume the secret is in sync
+		db.Status.PasswordHash = hash
+		if db.Status.PasswordRotatedAt == nil {
+			db.Status.PasswordRotatedAt = &now
+		}
+		return false, nil
+	}
+	if db.Status.PasswordHash == hash {
+		return false, nil
+	}
+
+	err = a.k8srds.ModifyPassword(db, password)
+	if err != nil {
+		return false, err
+	}
+	db.Status.PasswordHash = hash
+	db.Status.PasswordRotatedAt = &now
+	return true, nil
+}
+
+// rotatePassword applies a new password to AWS before writing it to the secret, so the secret never
+// holds a password the instance refuses. When the secret can't be written the status keeps the hash of
+// the new password, the next pass applies the password of the secret again
+func (a *Actuator) rotatePassword(db *databasesv1.Rds) (bool, error) {
+	name, key := db.Spec.Password.Name, db.Spec.Password.Key
+	a.log.Info("rotating password", "name", name, "key", key)
+	password, err := actuators.GeneratePassword(db.Spec.Engine)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to generate password")
+	}
+
+	err = a.k8srds.ModifyPassword(db, password)
+	if err != nil {
+		return false, err
+	}
+	now := metav1.Now()
+	db.Status.PasswordHash = actuators.PasswordHash(db, password)
+	db.Status.PasswordRotatedAt = &now
+
+	err = a.kubeClient.SetSecretKey(db.Namespace, name, key, password, ownerReference(db))
+	if err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// rotationDue returns true when the spec asks for rotation and the password is older than the interval
+func rotationDue(db *databasesv1.Rds, now time.Time) bool {
+	interval := db.Spec.PasswordRotationInterval
+	rotatedAt := db.Status.PasswordRotatedAt
+	if interval == nil || interval.Duration <= 0 || rotatedAt == nil {
+		return false
+	}
+	return now.Sub(rotatedAt.Time) >= interval.Duration
+}
@@ -0,0 +1,60 @@
+package rds
+
+import (
+	"testing"
+	"time"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators/rds/client/fake"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRotationDue(t *testing.T) {
+	now := time.Now()
+	rotatedAt := metav1.NewTime(now.Add(-48 * time.Hour))
+	db := &databasesv1.Rds{}
+	assert.False(t, rotationDue(db, now))
+
+	db.Spec.PasswordRotationInterval = &metav1.Duration{Duration: 72 * time.Hour}
+	assert.False(t, rotationDue(db, now))
+
+	db.Status.PasswordRotatedAt = &rotatedAt
+	assert.False(t, rotationDue(db, now))
+	assert.True(t, rotationDue(db, now.Add(24*time.Hour)))
+}
+
+func TestRotationFailure(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend, testSecret())
+	db := testDatabase()
+	reconcileDatabase(t, a, backend, testReconciler(t), db)
+
+	rotatedAt := metav1.NewTime(time.Now().Add(-48 * time.Hour))
+	db.Spec.PasswordRotationInterval = &metav1.Duration{Duration: 24 * time.Hour}
+	db.Status.PasswordRotatedAt = &rotatedAt
+	hash := db.Status.PasswordHash
+
+	// The secret keeps the password of the instance while AWS refuses the new one
+	backend.Errors["ModifyDBInstance"] = fake.Error("InvalidDBInstanceState", "Instance is not available")
+	rotated, err := a.reconcilePassword(db)
+	assert.Error(t, err)
+	assert.False(t, rotated)
+	password, err := a.kubeClient.GetSecret("default", "pgsql-password", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-password", password)
+	assert.Equal(t, "secret-password", backend.Instances["pgsql"].Password)
+	assert.Equal(t, hash, db.Status.PasswordHash)
+
+	// The next pass applies a new password, then writes it
+	delete(backend.Errors, "ModifyDBInstance")
+	rotated, err = a.reconcilePassword(db)
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+	password, err = a.kubeClient.GetSecret("default", "pgsql-password", "password")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "secret-password", password)
+	assert.Equal(t, password, backend.Instances["pgsql"].Password)
+	assert.NotEqual(t, hash, db.Status.PasswordHash)
+	assert.False(t, rotationDue(db, time.Now()))
+}
@@ -0,0 +1,175 @@
+package rds
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// providers caches the AWS clients of the ProviderConfig objects by name, an entry is rebuilt
+// when the config or its credentials change
+type providers struct {
+	mu      sync.Mutex
+	clients map[string]providerClient
+}
+
+type providerClient struct {
+	version string
+	aws     *k8srds.AWS
+}
+
+func newProviders() *providers {
+	return &providers{clients: map[string]providerClient{}}
+}
+
+// get returns the cached clients of the config for the version, built when missing or stale
+func (p *providers) get(name string, version string, build func() (*k8srds.AWS, error)) (*k8srds.AWS, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cached, ok := p.clients[name]; ok && cached.version == version {
+		return cached.aws, nil
+	}
+	clients, err := build()
+	if err != nil {
+		return nil, err
+	}
+	p.clients[name] = providerClient{version: version, aws: clients}
+	return clients, nil
+}
+
+// forProvider returns the actuator managing the database in the account of its ProviderConfig,
+// the actuator itself when the database has none. The config is pinned in the status along the
+// instance identifier, the instance can't move to another account afterwards
+func (a *Actuator) forProvider(db *databasesv1.Rds, c client.Client, ctx context.Context) (*Actuator, error) {
+	name := providerConfigName(db.Spec.ProviderConfigRef)
+	if pinned := providerConfigOf(db); name != pinned {
+		return nil, fmt.Errorf("providerConfigRef can't change from %q to %q", pinned, name)
+	}
+	db.Status.ProviderConfig = name
+	return a.forConfig(name, db.Namespace, c, ctx)
+}
+
+// providerConfigOf is the name of the config of the account of the database, the one pinned in
+// the status once the instance identifier is
+func providerConfigOf(db *databasesv1.Rds) string {
+	if db.Status.InstanceIdentifier != "" {
+		return db.Status.ProviderConfig
+	}
+	return providerConfigName(db.Spec.ProviderConfigRef)
+}
+
+// providerConfigName is the name of the referenced config, empty for the account of the controller
+func providerConfigName(ref *corev1.LocalObjectReference) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.Name
+}
+
+// forConfig returns the actuator managing the objects of the namespace in the account of the named
+// ProviderConfig, the actuator itself when no config is named
+func (a *Actuator) forConfig(name string, namespace string, c client.Client, ctx context.Context) (*Actuator, error) {
+	if name == "" {
+		return a, nil
+	}
+
+	config := &databasesv1.ProviderConfig{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, config); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get the provider config %v", name))
+	}
+	if !config.Allows(namespace) {
+		return nil, fmt.Errorf("provider config %v does not allow namespace %v", name, namespace)
+	}
+
+	var accessKeyID, secretAccessKey string
+	if secretRef := config.Spec.CredentialsSecretRef; secretRef != nil {
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}
+		if err := c.Get(ctx, key, secret); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("unable to get the credentials of provider config %v", name))
+		}
+		accessKeyID = string(secret.Data[secretRef.AccessKeyID()])
+		secretAccessKey = string(secret.Data[secretRef.SecretAccessKey()])
+		if accessKeyID == "" || secretAccessKey == "" {
+			return nil, fmt.Errorf("secret %v lacks the %v or %v keys", key, secretRef.AccessKeyID(), secretRef.SecretAccessKey())
+		}
+	}
+
+	// Rotated keys rebuild the clients like a changed config
+	version := fmt.Sprintf("%v/%x", config.ResourceVersion, sha256.Sum256([]byte(accessKeyID+":"+secretAccessKey)))
+	clients, err := a.providers.get(config.Name, version, func() (*k8srds.AWS, error) {
+		a.log.Info("building clients", "providerConfig", config.Name, "region", config.Spec.Region)
+		return a.providerClients(config, accessKeyID, secretAccessKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := *a
+	scoped.k8srds = clients
+	return &scoped, nil
+}
+
+// providerClients builds the clients of the config from the configuration of the controller.
+// The network of the controller only exists in its own account and region, a config with
+// credentials, a role or a region of its own must set its subnets and security groups
+func (a *Actuator) providerClients(config *databasesv1.ProviderConfig, accessKeyID string, secretAccessKey string) (*k8srds.AWS, error) {
+	foreign := accessKeyID != "" || config.Spec.AssumeRole != nil ||
+		(config.Spec.Region != "" && config.Spec.Region != a.awsConfig.Region)
+	if foreign && (len(config.Spec.SubnetIDs) == 0 || len(config.Spec.SecurityGroupIDs) == 0) {
+		return nil, fmt.Errorf("provider config %v uses another account or region, it needs subnetIds and securityGroupIds", config.Name)
+	}
+
+	cfg := a.awsConfig.Copy()
+	if config.Spec.Region != "" {
+		cfg.Region = config.Spec.Region
+	}
+	if accessKeyID != "" {
+		cfg.Credentials = aws.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")
+	}
+	if role := config.Spec.AssumeRole; role != nil {
+		provider := stscreds.NewAssumeRoleProvider(sts.New(cfg), role.RoleARN)
+		if role.ExternalID != "" {
+			provider.ExternalID = aws.String(role.ExternalID)
+		}
+		provider.RoleSessionName = role.SessionName
+		if provider.RoleSessionName == "" {
+			provider.RoleSessionName = "kube-db-" + config.Name
+		}
+		cfg.Credentials = provider
+	}
+
+	clients := k8srds.NewAWS(cfg)
+	clients.Subnets = config.Spec.SubnetIDs
+	clients.PublicSubnets = config.Spec.PublicSubnetIDs
+	clients.SecurityGroups = config.Spec.SecurityGroupIDs
+	clients.ClusterID = a.k8srds.ClusterID
+	clients.IdentifierTemplate = a.k8srds.IdentifierTemplate
+	if len(clients.Subnets) == 0 {
+		clients.Subnets, clients.PublicSubnets = a.k8srds.Subnets, a.k8srds.PublicSubnets
+	}
+	if len(clients.SecurityGroups) == 0 {
+		clients.SecurityGroups = a.k8srds.SecurityGroups
+	}
+	return clients, nil
+}
+
+// providerFailure keeps the observed details, the account of the database is unknown
+func (a *Actuator) providerFailure(db *databasesv1.Rds, err error) databasesv1.RdsStatus {
+	observed := *db.Status.DeepCopy()
+	observed.State = databasesv1.StateError
+	observed.Message = err.Error()
+	setConditions(&observed, db, a.kubeClient.HasService(db.Namespace, db.Name, db.UID), err)
+	return observed
+}
@@ -0,0 +1,199 @@
+package rds
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	rdsfake "github.com/cloud104/kube-db/pkg/actuators/rds/client/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestForProvider(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+
+	config := &databasesv1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec: databasesv1.ProviderConfigSpec{
+			AllowedNamespaces:    []string{"team-a"},
+			AssumeRole:           &databasesv1.AssumeRole{RoleARN: "arn:aws:iam::123456789012:role/kube-db", ExternalID: "cluster"},
+			CredentialsSecretRef: &databasesv1.CredentialsSecretRef{Name: "aws", Namespace: "kube-db"},
+			Region:               "eu-west-1",
+			SubnetIDs:            []string{"subnet-a"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws", Namespace: "kube-db"},
+		Data:       map[string][]byte{"aws_access_key_id": []byte("AKID"), "aws_secret_access_key": []byte("secret")},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, config, secret)
+
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	a := &Actuator{
+		log:       zap.Logger(true),
+		k8srds:    &k8srds.AWS{Subnets: []string{"subnet-default"}, SecurityGroups: []string{"sg-default"}, ClusterID: "cluster"},
+		awsConfig: cfg,
+		providers: newProviders(),
+	}
+
+	// Without reference the default account is used
+	db := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "team-a"}}
+	scoped, err := a.forProvider(db, c, context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, a, scoped)
+
+	// Another account can't use the network of the controller
+	db.Spec.ProviderConfigRef = &corev1.LocalObjectReference{Name: "team-a"}
+	_, err = a.forProvider(db, c, context.Background())
+	assert.Error(t, err)
+
+	config.Spec.SecurityGroupIDs = []string{"sg-a"}
+	assert.NoError(t, c.Update(context.Background(), config))
+	scoped, err = a.forProvider(db, c, context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", scoped.k8srds.Region())
+	assert.Equal(t, []string{"subnet-a"}, scoped.k8srds.Subnets)
+	assert.Equal(t, []string{"sg-a"}, scoped.k8srds.SecurityGroups)
+	assert.Equal(t, "cluster", scoped.k8srds.ClusterID)
+	assert.Equal(t, "us-east-1", a.awsConfig.Region, "the default configuration is left alone")
+
+	// The clients are cached until the credentials change
+	again, err := a.forProvider(db, c, context.Background())
+	assert.NoError(t, err)
+	assert.True(t, scoped.k8srds == again.k8srds)
+
+	secret.Data["aws_secret_access_key"] = []byte("rotated")
+	assert.NoError(t, c.Update(context.Background(), secret))
+	rotated, err := a.forProvider(db, c, context.Background())
+	assert.NoError(t, err)
+	assert.False(t, scoped.k8srds == rotated.k8srds)
+
+	// Other namespaces are refused, as every namespace when none is listed
+	db.Namespace = "team-b"
+	_, err = a.forProvider(db, c, context.Background())
+	assert.Error(t, err)
+
+	db.Namespace = "team-a"
+	config.Spec.AllowedNamespaces = nil
+	assert.NoError(t, c.Update(context.Background(), config))
+	_, err = a.forProvider(db, c, context.Background())
+	assert.Error(t, err)
+
+	db.Spec.ProviderConfigRef.Name = "missing"
+	_, err = a.forProvider(db, c, context.Background())
+	assert.Error(t, err)
+
+	// A config in the account and region of the controller shares its network
+	local := &databasesv1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "local"},
+		Spec:       databasesv1.ProviderConfigSpec{AllowedNamespaces: []string{"*"}},
+	}
+	assert.NoError(t, c.Create(context.Background(), local))
+	db.Spec.ProviderConfigRef.Name = "local"
+	scoped, err = a.forProvider(db, c, context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subnet-default"}, scoped.k8srds.Subnets)
+	assert.Equal(t, []string{"sg-default"}, scoped.k8srds.SecurityGroups)
+}
+
+func TestProviderConfigPinned(t *testing.T) {
+	backend, other := rdsfake.NewBackend("us-east-1"), rdsfake.NewBackend("eu-west-1")
+	a := testActuator(backend, testSecret())
+	clients := other.AWS(nil)
+	clients.Subnets, clients.SecurityGroups = []string{"subnet-c"}, []string{"sg-c"}
+	clients.IdentifierTemplate = "{{.Name}}"
+	a.providers.clients["team-a"] = providerClient{version: fmt.Sprintf("/%x", sha256.Sum256([]byte(":"))), aws: clients}
+
+	config := &databasesv1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec:       databasesv1.ProviderConfigSpec{AllowedNamespaces: []string{"default"}},
+	}
+	db := testDatabase()
+	db.Spec.ProviderConfigRef = &corev1.LocalObjectReference{Name: "team-a"}
+	client := testReconciler(t, config, db)
+	key := types.NamespacedName{Namespace: "default", Name: "pgsql"}
+
+	// The config is recorded along the identifier on the first reconciliation
+	status, err := a.Reconcile(db, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "team-a", status.ProviderConfig)
+	assert.Equal(t, "pgsql", status.InstanceIdentifier)
+	assert.Contains(t, other.Instances, "pgsql")
+	assert.Empty(t, backend.Instances)
+
+	// and can't change afterwards, the instance stays in its account
+	db.Status = status
+	db.Spec.ProviderConfigRef = nil
+	status, err = a.Reconcile(db, client, context.Background(), key)
+	assert.EqualError(t, err, `providerConfigRef can't change from "team-a" to ""`)
+	assert.Equal(t, databasesv1.StateError, status.State)
+	assert.Equal(t, "team-a", status.ProviderConfig)
+	assert.Empty(t, backend.Instances)
+
+	// The instance is deleted from the pinned account whatever the spec says
+	other.Advance()
+	db.Spec.DeletionPolicy = databasesv1.DeletionPolicyDelete
+	_, err = a.Delete(db, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Contains(t, other.Operations(), "DeleteDBInstance")
+	assert.NotContains(t, backend.Operations(), "DeleteDBInstance")
+}
+
+func TestSnapshotInProviderAccount(t *testing.T) {
+	backend, other := rdsfake.NewBackend("us-east-1"), rdsfake.NewBackend("eu-west-1")
+	other.Instances["pgsql"] = &rdsfake.Instance{DBInstance: rds.DBInstance{
+		DBInstanceArn:        aws.String("arn:aws:rds:eu-west-1:123456789012:db:pgsql"),
+		DBInstanceIdentifier: aws.String("pgsql"),
+		DBInstanceStatus:     aws.String("available"),
+		Engine:               aws.String("postgres"),
+	}}
+	a := testActuator(backend)
+	a.providers.clients["team-a"] = providerClient{version: fmt.Sprintf("/%x", sha256.Sum256([]byte(":"))), aws: other.AWS(nil)}
+
+	config := &databasesv1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec:       databasesv1.ProviderConfigSpec{AllowedNamespaces: []string{"default"}},
+	}
+	db := testDatabase()
+	db.Spec.ProviderConfigRef = &corev1.LocalObjectReference{Name: "team-a"}
+	db.Status.State, db.Status.InstanceIdentifier, db.Status.ProviderConfig = databasesv1.StateAvailable, "pgsql", "team-a"
+	snapshot := &databasesv1.RdsSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: "default"},
+		Spec:       databasesv1.RdsSnapshotSpec{RdsRef: corev1.LocalObjectReference{Name: "pgsql"}},
+	}
+	client := &controllers.RdsSnapshotReconciler{Client: testReconciler(t, config, db).Client}
+	key := types.NamespacedName{Namespace: "default", Name: "manual"}
+
+	// The snapshot is taken in the account of the Rds
+	status, err := a.Snapshot().Reconcile(snapshot, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "creating", status.State)
+	assert.Equal(t, "team-a", status.ProviderConfig)
+	assert.Contains(t, other.Snapshots, "pgsql-manual")
+	assert.Empty(t, backend.Snapshots)
+
+	// and deleted from there, even once the Rds is gone
+	assert.NoError(t, client.Client.Delete(context.Background(), db))
+	other.Advance()
+	snapshot.Status = status
+	status, err = a.Snapshot().Delete(snapshot, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "deleting", status.State)
+	assert.Contains(t, other.Operations(), "DeleteDBSnapshot")
+}
@@ -1,81 +1,92 @@
 package rds
 
 import (
-	"context"
 	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/external"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/cloud104/kube-db/pkg/actuators"
 	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
 )
 
 const Failed = "Failed"
 const dryRun = true
 
-func NewActuator(log logr.Logger, config *rest.Config) (a *Actuator, err error) {
+func NewActuator(log logr.Logger, config *rest.Config, options Options) (a *Actuator, err error) {
+	_, err = actuators.RenderIdentifier(options.IdentifierTemplate, actuators.IdentifierData{})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid instance identifier template")
+	}
+
 	kubectl, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
-	awsConfig, err := configClient(kubectl)
+	awsConfig, err := configClient(kubectl, options)
 	if err != nil {
 		return nil, err
 	}
 
-	ec2client := ec2.New(awsConfig)
-	rdsclient := rds.New(awsConfig)
+	clients := k8srds.NewAWS(awsConfig)
 
-	// securityGroups := []string{}
-	securityGroups, err := getSecurityGroups(ec2client, kubectl)
+	subnets, publicSubnets, err := resolveSubnets(clients.EC2, kubectl, options.Network)
 	if err != nil {
 		return nil, err
 	}
 
-	// subnets := []string{}
-	subnets, err := getSubnets(ec2client, false, kubectl)
+	securityGroups, err := resolveSecurityGroups(clients.EC2, kubectl, options.Network)
 	if err != nil {
 		return nil, err
 	}
+	log.Info("network", "subnets", subnets, "publicSubnets", publicSubnets, "securityGroups", securityGroups)
+
+	clients.Subnets = subnets
+	clients.PublicSubnets = publicSubnets
+	clients.SecurityGroups = securityGroups
+	clients.ClusterID = options.ClusterID
+	clients.IdentifierTemplate = options.IdentifierTemplate
 
 	return &Actuator{
 		log:        log,
-		ec2client:  ec2client,
-		rdsclient:  rdsclient,
 		kubeClient: &Kube{Client: kubectl},
-		k8srds: &k8srds.AWS{
-			RDS:            rdsclient,
-			EC2:            ec2client,
-			Subnets:        subnets,
-			SecurityGroups: securityGroups,
-		},
+		k8srds:     clients,
+		awsConfig:  awsConfig,
+		providers:  newProviders(),
 	}, nil
 }
 
-func configClient(kubectl *kubernetes.Clientset) (aws.Config, error) {
-	nodes, err := kubectl.CoreV1().Nodes().List(metav1.ListOptions{})
+// Node labels holding the region, the deprecated one is still set by older clusters
+var regionLabels = []string{"topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"}
+
+func configClient(kubectl kubernetes.Interface, options Options) (aws.Config, error) {
+	var configs external.Configs
+	if options.Profile != "" {
+		configs = append(configs, external.WithSharedConfigProfile(options.Profile))
+	}
+	cfg, err := external.LoadDefaultAWSConfig(configs...)
 	if err != nil {
-		return aws.Config{}, errors.Wrap(err, "unable to get nodes")
+		return aws.Config{}, errors.Wrap(err, "unable to load the AWS configuration")
 	}
-	region := ""
 
-	if len(nodes.Items) > 0 {
-		// take the first one, we assume that all nodes are created in the same VPC
-		region = nodes.Items[0].Labels["failure-domain.beta.kubernetes.io/region"]
-	} else {
-		return aws.Config{}, fmt.Errorf("unable to find any nodes in the cluster")
+	// The nodes are only listed when the region is not configured
+	var nodes []corev1.Node
+	if options.Region == "" && cfg.Region == "" {
+		list, err := kubectl.CoreV1().Nodes().List(metav1.ListOptions{})
+		if err != nil {
+			return aws.Config{}, errors.Wrap(err, "unable to get nodes")
+		}
+		nodes = list.Items
 	}
-
-	cfg, err := external.LoadDefaultAWSConfig()
+	region, err := resolveRegion(options.Region, cfg.Region, nodes)
 	if err != nil {
 		return aws.Config{}, err
 	}
@@ -86,95 +97,21 @@ func configClient(kubectl *kubernetes.Clientset) (aws.Config, error) {
 	return cfg, nil
 }
 
-func getSecurityGroups(svc *ec2.Client, kubectl *kubernetes.Clientset) ([]string, error) {
-	ctx := context.Background()
-	nodes, err := kubectl.CoreV1().Nodes().List(metav1.ListOptions{})
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to get nodes")
-	}
-
-	name := ""
-	if len(nodes.Items) > 0 {
-		// take the first one, we assume that all nodes are created in the same VPC
-		name = nodes.Items[0].Name
-	} else {
-		return nil, fmt.Errorf("unable to find any nodes in the cluster")
-	}
-
-	params := &ec2.DescribeInstancesInput{
-		Filters: []ec2.Filter{
-			{
-				Name:   aws.String("private-dns-name"),
-				Values: []string{name},
-			},
-		},
-	}
-
-	req := svc.DescribeInstancesRequest(params)
-	res, err := req.Send(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to describe AWS instance")
-	}
-
-	var result []string
-	if len(res.Reservations) >= 1 {
-		for _, v := range res.Reservations[0].Instances[0].SecurityGroups {
-			result = append(result, *v.GroupId)
-		}
-	}
-
-	return result, nil
-}
-
-func getSubnets(svc *ec2.Client, public bool, kubectl *kubernetes.Clientset) ([]string, error) {
-	ctx := context.Background()
-	nodes, err := kubectl.CoreV1().Nodes().List(metav1.ListOptions{})
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to get nodes")
+// resolveRegion returns the configured region, the one of the AWS configuration (AWS_REGION, the
+// profile) or the region label of the nodes, in that order
+func resolveRegion(configured string, fromConfig string, nodes []corev1.Node) (string, error) {
+	if configured != "" {
+		return configured, nil
 	}
-	name := ""
-
-	if len(nodes.Items) > 0 {
-		// take the first one, we assume that all nodes are created in the same VPC
-		name = nodes.Items[0].Name
-	} else {
-		return nil, fmt.Errorf("unable to find any nodes in the cluster")
+	if fromConfig != "" {
+		return fromConfig, nil
 	}
-
-	params := &ec2.DescribeInstancesInput{
-		Filters: []ec2.Filter{
-			{
-				Name: aws.String("private-dns-name"),
-				Values: []string{
-					name,
-				},
-			},
-		},
-	}
-
-	req := svc.DescribeInstancesRequest(params)
-	res, err := req.Send(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to describe AWS instance")
-	}
-
-	var result []string
-	if len(res.Reservations) >= 1 {
-		vpcID := res.Reservations[0].Instances[0].VpcId
-
-		res := svc.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{Filters: []ec2.Filter{{Name: aws.String("vpc-id"), Values: []string{*vpcID}}}})
-		subnets, err := res.Send(ctx)
-
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("unable to describe subnet in VPC %v", *vpcID))
-		}
-		for _, sn := range subnets.Subnets {
-			if *sn.MapPublicIpOnLaunch == public {
-				result = append(result, *sn.SubnetId)
+	for _, label := range regionLabels {
+		for _, node := range nodes {
+			if region := node.Labels[label]; region != "" {
+				return region, nil
 			}
 		}
-
 	}
-
-	return result, nil
+	return "", fmt.Errorf("unable to resolve the AWS region: set --region (or KUBE_DB_REGION), AWS_REGION, or the %v label of the nodes", regionLabels[0])
 }
@@ -0,0 +1,40 @@
+package rds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveRegion(t *testing.T) {
+	node := func(labels map[string]string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+	}
+	nodes := []corev1.Node{
+		node(nil),
+		node(map[string]string{"failure-domain.beta.kubernetes.io/region": "us-east-1"}),
+		node(map[string]string{"topology.kubernetes.io/region": "us-east-2"}),
+	}
+
+	region, err := resolveRegion("eu-west-1", "sa-east-1", nodes)
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+
+	region, err = resolveRegion("", "sa-east-1", nodes)
+	assert.NoError(t, err)
+	assert.Equal(t, "sa-east-1", region)
+
+	region, err = resolveRegion("", "", nodes)
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-2", region)
+
+	region, err = resolveRegion("", "", nodes[:2])
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", region)
+
+	_, err = resolveRegion("", "", nodes[:1])
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--region")
+}
@@ -0,0 +1,253 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ReplicaActuator manages read replicas with the clients of the Rds actuator
+type ReplicaActuator struct {
+	*Actuator
+}
+
+// Replica returns the actuator of the RdsReadReplica objects
+func (a *Actuator) Replica() *ReplicaActuator {
+	return &ReplicaActuator{Actuator: a}
+}
+
+func (a *ReplicaActuator) Reconcile(r *databasesv1.RdsReadReplica, client *controllers.RdsReadReplicaReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsReadReplicaStatus, err error) {
+	// The replica is created in the account of the source, persisted before anything gets created
+	if r.Status.InstanceIdentifier == "" {
+		source, status, err := a.source(r, client, ctx)
+		if source == nil {
+			return a.unscoped(r, status, err), err
+		}
+		r.Status.ProviderConfig = providerConfigOf(source)
+	}
+
+	scoped, err := a.forConfig(r.Status.ProviderConfig, r.Namespace, client.Client, ctx)
+	if err != nil {
+		return a.unscoped(r, databasesv1.NewReplicaStatus(err.Error(), databasesv1.StateError), err), err
+	}
+	replicas := scoped.Replica()
+	status, err = replicas.reconcile(r, client, ctx)
+	return replicas.observe(r, status, err), err
+}
+
+func (a *ReplicaActuator) reconcile(r *databasesv1.RdsReadReplica, client *controllers.RdsReadReplicaReconciler, ctx context.Context) (status databasesv1.RdsReadReplicaStatus, err error) {
+	log := a.log.WithValues("reconcilingReplica", r.Name)
+	log.Info("Start reconciling")
+
+	// Persist the identifier before anything gets created, so it never changes afterwards
+	if r.Status.InstanceIdentifier == "" {
+		r.Status.InstanceIdentifier = a.k8srds.ReplicaIdentifier(r)
+	}
+
+	instance, err := a.k8srds.DescribeReplica(r)
+	if err != nil {
+		return databasesv1.NewReplicaStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// CREATE
+	if instance == nil {
+		if r.Status.Promoted {
+			err = fmt.Errorf("promoted db instance %v not found", r.Status.InstanceIdentifier)
+			return databasesv1.NewReplicaStatus(err.Error(), databasesv1.StateError), err
+		}
+
+		source, status, err := a.source(r, client, ctx)
+		if source == nil {
+			return status, err
+		}
+
+		log.Info("creating", "source", source.Status.InstanceIdentifier)
+		err = a.k8srds.CreateReadReplica(r, source.Status.InstanceIdentifier)
+		if err != nil {
+			return databasesv1.NewReplicaStatus(err.Error(), databasesv1.StatePending), err
+		}
+		r.Status.SourceIdentifier = source.Status.InstanceIdentifier
+		return databasesv1.NewReplicaStatus("Creating Replica", "creating"), nil
+	}
+
+	// OWNERSHIP
+	if err := a.k8srds.VerifyReplicaOwnership(r); err != nil {
+		return a.conflict(r, client, err)
+	}
+
+	currentStatus := aws.StringValue(instance.DBInstanceStatus)
+	if currentStatus != databasesv1.StateAvailable {
+		return databasesv1.NewReplicaStatus("Replica not in a reconcilable state, will wait", currentStatus), nil
+	}
+
+	// PROMOTE
+	// Promotion can't be undone, turning the field off afterwards changes nothing
+	r.Status.Promoted = aws.StringValue(instance.ReadReplicaSourceDBInstanceIdentifier) == ""
+	if r.Spec.Promote && !r.Status.Promoted {
+		err = a.k8srds.PromoteReadReplica(r)
+		if err != nil {
+			return databasesv1.NewReplicaStatus("Failed To Promote Replica", currentStatus), err
+		}
+		a.event(client, r, corev1.EventTypeNormal, "Promoting", fmt.Sprintf("Promoting %v to a standalone instance", r.Status.InstanceIdentifier))
+		return databasesv1.NewReplicaStatus("Promoting Replica", databasesv1.StateModifying), nil
+	}
+
+	r.Status.ReplicaLag = nil
+	if !r.Status.Promoted {
+		lag, err := a.k8srds.ReplicaLag(r)
+		if err != nil {
+			log.Info("unable to get the replica lag", "error", err.Error())
+		}
+		r.Status.ReplicaLag = lag
+	}
+
+	// SERVICE
+	if instance.Endpoint == nil {
+		return databasesv1.NewReplicaStatus("Waiting for endpoint to be available", currentStatus), nil
+	}
+	err = a.kubeClient.ReconcileService(r.Namespace, aws.StringValue(instance.Endpoint.Address), r.Service(), replicaOwnerReference(r))
+	if err != nil {
+		return databasesv1.NewReplicaStatus("Failing Reconciled Service", currentStatus), err
+	}
+
+	log.Info("replica reconciliation done")
+	return databasesv1.NewReplicaStatus("Replica reconciled", currentStatus), nil
+}
+
+// source returns the source Rds once it is available, along the status to report while it isn't
+func (a *ReplicaActuator) source(r *databasesv1.RdsReadReplica, client *controllers.RdsReadReplicaReconciler, ctx context.Context) (*databasesv1.Rds, databasesv1.RdsReadReplicaStatus, error) {
+	source := &databasesv1.Rds{}
+	key := types.NamespacedName{Namespace: r.SourceNamespace(), Name: r.Spec.Source.Name}
+	err := client.Get(ctx, key, source)
+	if k8s_errors.IsNotFound(err) {
+		return nil, databasesv1.NewReplicaStatus(fmt.Sprintf("Waiting for source %v", key), databasesv1.StatePending), nil
+	}
+	if err != nil {
+		return nil, databasesv1.NewReplicaStatus("Failing Getting Source", databasesv1.StatePending), err
+	}
+
+	// Replicas share the data and the credentials of the source, another namespace must be allowed
+	if !source.AllowsReplicaIn(r.Namespace) {
+		err = fmt.Errorf("source %v does not allow replicas in namespace %v, see the %v annotation", key, r.Namespace, databasesv1.ReplicaNamespacesAnnotation)
+		return nil, databasesv1.NewReplicaStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	if source.Status.State != databasesv1.StateAvailable || source.Status.InstanceIdentifier == "" {
+		return nil, databasesv1.NewReplicaStatus(fmt.Sprintf("Waiting for source %v to be available", key), databasesv1.StatePending), nil
+	}
+	return source, databasesv1.RdsReadReplicaStatus{}, nil
+}
+
+func (a *ReplicaActuator) Delete(r *databasesv1.RdsReadReplica, client *controllers.RdsReadReplicaReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsReadReplicaStatus, err error) {
+	scoped, err := a.forConfig(r.Status.ProviderConfig, r.Namespace, client.Client, ctx)
+	if err != nil {
+		return a.unscoped(r, databasesv1.NewReplicaStatus(err.Error(), databasesv1.StateError), err), err
+	}
+	replicas := scoped.Replica()
+	status, err = replicas.delete(r, client)
+	return replicas.observe(r, status, err), err
+}
+
+func (a *ReplicaActuator) delete(r *databasesv1.RdsReadReplica, client *controllers.RdsReadReplicaReconciler) (status databasesv1.RdsReadReplicaStatus, err error) {
+	log := a.log.WithValues("deleteReplica", r.Name)
+	policy := r.GetDeletionPolicy()
+	if err := policy.Validate(); err != nil {
+		return databasesv1.NewReplicaStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	instance, err := a.k8srds.DescribeReplica(r)
+	if err != nil {
+		return databasesv1.NewReplicaStatus("Error Getting Replica", databasesv1.StateError), err
+	}
+
+	if instance != nil && policy != databasesv1.DeletionPolicyRetain {
+		if err := a.k8srds.VerifyReplicaOwnership(r); err != nil {
+			return a.conflict(r, client, err)
+		}
+
+		currentStatus := aws.StringValue(instance.DBInstanceStatus)
+		if currentStatus == "creating" || currentStatus == "deleting" {
+			return databasesv1.NewReplicaStatus("Replica not in a deletable state, will wait", currentStatus), nil
+		}
+
+		// Only promoted replicas can take a final snapshot
+		promoted := aws.StringValue(instance.ReadReplicaSourceDBInstanceIdentifier) == ""
+		log.Info("deleting replica", "deletionPolicy", policy, "promoted", promoted)
+		snapshot, err := a.k8srds.DeleteReplica(r, policy == databasesv1.DeletionPolicyDelete || !promoted)
+		if err != nil {
+			return databasesv1.NewReplicaStatus(err.Error(), currentStatus), err
+		}
+		if snapshot != "" {
+			r.Status.FinalSnapshotIdentifier = snapshot
+		}
+		return databasesv1.NewReplicaStatus("Deleting", "deleting"), nil
+	}
+
+	log.Info("deleting svc")
+	err = a.kubeClient.DeleteService(r.Namespace, r.Service(), r.UID)
+	if err != nil {
+		return databasesv1.NewReplicaStatus("ERROR Deleting svc", r.Status.State), err
+	}
+
+	log.Info("Deletion of replica done")
+	return databasesv1.NewReplicaStatus("Deleted", databasesv1.StateDeleted), nil
+}
+
+// conflict reports a replica owned by someone else
+func (a *ReplicaActuator) conflict(r *databasesv1.RdsReadReplica, client *controllers.RdsReadReplicaReconciler, err error) (databasesv1.RdsReadReplicaStatus, error) {
+	if !k8srds.IsOwnershipError(err) {
+		return databasesv1.NewReplicaStatus("Error Verifying Ownership", databasesv1.StateError), err
+	}
+	a.event(client, r, corev1.EventTypeWarning, "Conflict", err.Error())
+	return databasesv1.NewReplicaStatus(err.Error(), databasesv1.StateConflict), err
+}
+
+// event records a kubernetes event on the object, when the reconciler has a recorder
+func (a *ReplicaActuator) event(client *controllers.RdsReadReplicaReconciler, r *databasesv1.RdsReadReplica, eventType string, reason string, message string) {
+	if client == nil || client.Recorder == nil {
+		return
+	}
+	client.Recorder.Event(r, eventType, reason, message)
+}
+
+// unscoped sets the state and the conditions while the account of the replica is unknown
+func (a *ReplicaActuator) unscoped(r *databasesv1.RdsReadReplica, status databasesv1.RdsReadReplicaStatus, err error) databasesv1.RdsReadReplicaStatus {
+	observed := *r.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+	observed.Conditions = conditionsFor(observed.Conditions, observed.State, observed.Message, r, err)
+	return observed
+}
+
+// observe completes the status with the replica endpoint and the conditions
+func (a *ReplicaActuator) observe(r *databasesv1.RdsReadReplica, status databasesv1.RdsReadReplicaStatus, err error) databasesv1.RdsReadReplicaStatus {
+	observed := *r.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+
+	if status.State != databasesv1.StateConflict {
+		instance, ierr := a.k8srds.DescribeReplica(r)
+		if ierr != nil {
+			a.log.Info("unable to describe replica", "name", r.Name, "error", ierr)
+		} else if instance == nil {
+			observed.Address = ""
+			observed.Port = 0
+		} else {
+			if instance.Endpoint != nil {
+				observed.Address = aws.StringValue(instance.Endpoint.Address)
+				observed.Port = aws.Int64Value(instance.Endpoint.Port)
+			}
+			observed.ARN = aws.StringValue(instance.DBInstanceArn)
+		}
+	}
+
+	observed.Conditions = conditionsFor(observed.Conditions, observed.State, observed.Message, r, err)
+	return observed
+}
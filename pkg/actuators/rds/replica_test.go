@@ -0,0 +1,169 @@
+package rds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/actuators/rds/client/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testReplica() *databasesv1.RdsReadReplica {
+	return &databasesv1.RdsReadReplica{
+		ObjectMeta: metav1.ObjectMeta{Name: "reports", Namespace: "default", UID: "7e1a2b3c"},
+		Spec:       databasesv1.RdsReadReplicaSpec{Source: databasesv1.RdsReference{Name: "pgsql"}},
+	}
+}
+
+func testReplicaReconciler(t *testing.T, objects ...runtime.Object) *controllers.RdsReadReplicaReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	return &controllers.RdsReadReplicaReconciler{
+		Client:   ctrlfake.NewFakeClientWithScheme(scheme, objects...),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+// testSource returns the actuator of the replicas along an available source database
+func testSource(t *testing.T, backend *fake.Backend) (*ReplicaActuator, *databasesv1.Rds) {
+	a := testActuator(backend, testSecret())
+	source := testDatabase()
+	reconcileDatabase(t, a, backend, testReconciler(t), source)
+	return a.Replica(), source
+}
+
+// reconcileReplica reconciles the replica until it is reconciled, advancing the backend in between
+func reconcileReplica(t *testing.T, a *ReplicaActuator, backend *fake.Backend, client *controllers.RdsReadReplicaReconciler, r *databasesv1.RdsReadReplica) {
+	key := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+	for i := 0; i < 10; i++ {
+		status, err := a.Reconcile(r, client, context.Background(), key)
+		assert.NoError(t, err)
+		r.Status = status
+		if status.Message == "Replica reconciled" {
+			return
+		}
+		backend.Advance()
+	}
+	t.Fatalf("replica not reconciled: %v", r.Status.Message)
+}
+
+// deleteReplica deletes the replica until it is deleted, advancing the backend in between
+func deleteReplica(t *testing.T, a *ReplicaActuator, backend *fake.Backend, client *controllers.RdsReadReplicaReconciler, r *databasesv1.RdsReadReplica) {
+	key := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+	for i := 0; i < 10; i++ {
+		status, err := a.Delete(r, client, context.Background(), key)
+		assert.NoError(t, err)
+		r.Status = status
+		if status.State == databasesv1.StateDeleted {
+			return
+		}
+		backend.Advance()
+	}
+	t.Fatalf("replica not deleted: %v", r.Status.Message)
+}
+
+func TestReplicaCreateAndDelete(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a, source := testSource(t, backend)
+	client := testReplicaReconciler(t, source)
+	r := testReplica()
+
+	backend.ReplicaLag["reports"] = 3
+	reconcileReplica(t, a, backend, client, r)
+	assert.Equal(t, databasesv1.StateAvailable, r.Status.State)
+	assert.Equal(t, "reports", r.Status.InstanceIdentifier)
+	assert.Equal(t, "pgsql", r.Status.SourceIdentifier)
+	assert.Equal(t, "reports.c0ffee.us-east-1.rds.amazonaws.com", r.Status.Address)
+	assert.Equal(t, aws.Int64(3), r.Status.ReplicaLag)
+	assert.Equal(t, "pgsql", aws.StringValue(backend.Instances["reports"].ReadReplicaSourceDBInstanceIdentifier))
+	assert.NoError(t, checkTags(backend, r.Status.ARN, "databases.tks.sh/uid", "7e1a2b3c"))
+
+	svc, err := a.kubeClient.Client.CoreV1().Services("default").Get("reports-ro", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "reports.c0ffee.us-east-1.rds.amazonaws.com", svc.Spec.ExternalName)
+	assert.Equal(t, r.UID, svc.OwnerReferences[0].UID)
+
+	// The lag follows the metric
+	backend.ReplicaLag["reports"] = 42
+	reconcileReplica(t, a, backend, client, r)
+	assert.Equal(t, aws.Int64(42), r.Status.ReplicaLag)
+
+	// Replicas can't take a final snapshot
+	backend.Operations()
+	deleteReplica(t, a, backend, client, r)
+	assert.Contains(t, backend.Operations(), "DeleteDBInstance")
+	assert.NotContains(t, backend.Instances, "reports")
+	assert.Empty(t, r.Status.FinalSnapshotIdentifier)
+	assert.Empty(t, backend.Snapshots)
+	_, err = a.kubeClient.Client.CoreV1().Services("default").Get("reports-ro", metav1.GetOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, backend.Instances, "pgsql")
+}
+
+func TestReplicaWaitsForSource(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend).Replica()
+	r := testReplica()
+	key := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+
+	status, err := a.Reconcile(r, testReplicaReconciler(t), context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StatePending, status.State)
+	assert.Contains(t, status.Message, "Waiting for source")
+
+	// A source of another namespace must allow the replica
+	source := testDatabase()
+	source.Namespace = "team-a"
+	source.Status = databasesv1.RdsStatus{State: databasesv1.StateAvailable, InstanceIdentifier: "pgsql"}
+	r.Spec.Source.Namespace = "team-a"
+	status, err = a.Reconcile(r, testReplicaReconciler(t, source), context.Background(), key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+	assert.NotContains(t, backend.Operations(), "CreateDBInstanceReadReplica")
+}
+
+func TestReplicaPromote(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a, source := testSource(t, backend)
+	client := testReplicaReconciler(t, source)
+	r := testReplica()
+	key := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+	backend.ReplicaLag["reports"] = 3
+	reconcileReplica(t, a, backend, client, r)
+	assert.False(t, r.Status.Promoted)
+
+	r.Spec.Promote = true
+	status, err := a.Reconcile(r, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "Promoting Replica", status.Message)
+	assert.Contains(t, backend.Operations(), "PromoteReadReplica")
+	r.Status = status
+	backend.Advance()
+
+	// A promoted replica is a standalone instance without lag
+	reconcileReplica(t, a, backend, client, r)
+	assert.True(t, r.Status.Promoted)
+	assert.Nil(t, r.Status.ReplicaLag)
+	assert.Empty(t, aws.StringValue(backend.Instances["reports"].ReadReplicaSourceDBInstanceIdentifier))
+	assert.NotContains(t, backend.Operations(), "PromoteReadReplica")
+
+	// Turning the field off can't undo the promotion
+	r.Spec.Promote = false
+	reconcileReplica(t, a, backend, client, r)
+	assert.True(t, r.Status.Promoted)
+
+	// Promoted replicas take a final snapshot
+	deleteReplica(t, a, backend, client, r)
+	assert.NotEmpty(t, r.Status.FinalSnapshotIdentifier)
+	assert.Contains(t, backend.Snapshots, r.Status.FinalSnapshotIdentifier)
+}
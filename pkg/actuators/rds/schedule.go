@@ -0,0 +1,180 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/cloud104/kube-db/pkg/schedule"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StateSuspended is the state of a suspended schedule
+const StateSuspended = "suspended"
+
+// ScheduleActuator takes the snapshots of the schedules as RdsSnapshot objects and prunes them
+type ScheduleActuator struct {
+	*Actuator
+	now func() time.Time
+}
+
+// Schedule returns the actuator of the SnapshotSchedule objects
+func (a *Actuator) Schedule() *ScheduleActuator {
+	return &ScheduleActuator{Actuator: a, now: time.Now}
+}
+
+func (a *ScheduleActuator) Reconcile(s *databasesv1.SnapshotSchedule, client *controllers.SnapshotScheduleReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.SnapshotScheduleStatus, err error) {
+	status, err = a.reconcile(s, client, ctx)
+	status.Conditions = conditionsFor(s.Status.Conditions, status.State, status.Message, s, err)
+	return status, err
+}
+
+func (a *ScheduleActuator) reconcile(s *databasesv1.SnapshotSchedule, client *controllers.SnapshotScheduleReconciler, ctx context.Context) (status databasesv1.SnapshotScheduleStatus, err error) {
+	log := a.log.WithValues("reconcilingSchedule", s.Name)
+	status = *s.Status.DeepCopy()
+
+	cron, err := schedule.Parse(s.Spec.Schedule)
+	if err != nil {
+		status.State, status.Message = databasesv1.StateError, err.Error()
+		status.NextScheduleTime = nil
+		return status, nil
+	}
+
+	now := a.now().UTC()
+	last := s.CreationTimestamp.Time
+	if s.Status.LastScheduleTime != nil {
+		last = s.Status.LastScheduleTime.Time
+	}
+
+	// Missed runs are skipped, only the latest due run takes snapshots
+	var due time.Time
+	for next := cron.Next(last); !next.IsZero() && !next.After(now); next = cron.Next(next) {
+		due = next
+	}
+	if !due.IsZero() && !s.Spec.Suspend {
+		scheduled := metav1.NewTime(due)
+		log.Info("taking scheduled snapshots", "scheduled", scheduled)
+		if err = a.takeSnapshots(s, scheduled, client, ctx); err != nil {
+			status.State, status.Message = databasesv1.StateError, err.Error()
+			return status, err
+		}
+		status.LastScheduleTime = &scheduled
+	}
+
+	kept, err := a.prune(s, client, ctx)
+	if err != nil {
+		status.State, status.Message = databasesv1.StateError, err.Error()
+		return status, err
+	}
+	status.Snapshots = kept
+
+	status.NextScheduleTime = nil
+	if next := cron.Next(now); !next.IsZero() {
+		status.NextScheduleTime = &metav1.Time{Time: next}
+	}
+	if s.Spec.Suspend {
+		status.State, status.Message = StateSuspended, "Schedule suspended"
+		return status, nil
+	}
+	status.State, status.Message = databasesv1.StateAvailable, fmt.Sprintf("%v snapshots kept", kept)
+	return status, nil
+}
+
+// takeSnapshots creates the RdsSnapshot of every selected Rds for the scheduled time. The names
+// only depend on the time, so a retried run never takes a snapshot twice
+func (a *ScheduleActuator) takeSnapshots(s *databasesv1.SnapshotSchedule, scheduled metav1.Time, c *controllers.SnapshotScheduleReconciler, ctx context.Context) error {
+	selector, err := metav1.LabelSelectorAsSelector(&s.Spec.Selector)
+	if err != nil {
+		return errors.Wrap(err, "invalid selector")
+	}
+	list := &databasesv1.RdsList{}
+	err = c.List(ctx, list, client.UseListOptions(&client.ListOptions{Namespace: s.Namespace, LabelSelector: selector}))
+	if err != nil {
+		return errors.Wrap(err, "unable to list the selected Rds")
+	}
+
+	var taken []string
+	for _, db := range list.Items {
+		if !db.DeletionTimestamp.IsZero() {
+			continue
+		}
+		snapshot := &databasesv1.RdsSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.SnapshotName(db.Name, scheduled),
+				Namespace: s.Namespace,
+				Labels: map[string]string{
+					databasesv1.ScheduleLabel: s.Name,
+					databasesv1.RdsLabel:      db.Name,
+				},
+			},
+			Spec: databasesv1.RdsSnapshotSpec{
+				Copies: s.Spec.Copies,
+				RdsRef: corev1.LocalObjectReference{Name: db.Name},
+				Tags:   s.Spec.Tags,
+			},
+		}
+		err = c.Create(ctx, snapshot)
+		if k8s_errors.IsAlreadyExists(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("unable to create the snapshot %v", snapshot.Name))
+		}
+		taken = append(taken, snapshot.Name)
+	}
+
+	if len(taken) > 0 && c.Recorder != nil {
+		c.Recorder.Event(s, corev1.EventTypeNormal, "Snapshotting", fmt.Sprintf("Created %v", strings.Join(taken, ", ")))
+	}
+	return nil
+}
+
+// prune deletes the available snapshots of the schedule no retention rule keeps, per Rds.
+// Returns the number of snapshots kept
+func (a *ScheduleActuator) prune(s *databasesv1.SnapshotSchedule, c *controllers.SnapshotScheduleReconciler, ctx context.Context) (int, error) {
+	list := &databasesv1.RdsSnapshotList{}
+	err := c.List(ctx, list, client.InNamespace(s.Namespace), client.MatchingLabels(map[string]string{databasesv1.ScheduleLabel: s.Name}))
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to list the snapshots of the schedule")
+	}
+
+	// Snapshots still in progress are neither counted nor pruned
+	byRds := map[string][]databasesv1.RdsSnapshot{}
+	for _, snapshot := range list.Items {
+		if snapshot.DeletionTimestamp.IsZero() && snapshot.Status.State == databasesv1.StateAvailable {
+			rds := snapshot.Labels[databasesv1.RdsLabel]
+			byRds[rds] = append(byRds[rds], snapshot)
+		}
+	}
+
+	retention := schedule.Retention{Last: s.Spec.KeepLast, Daily: s.Spec.KeepDaily, Weekly: s.Spec.KeepWeekly}
+	kept := 0
+	for _, snapshots := range byRds {
+		times := make([]time.Time, len(snapshots))
+		for i, snapshot := range snapshots {
+			times[i] = snapshot.CreationTimestamp.Time
+			if snapshot.Status.CreatedAt != nil {
+				times[i] = snapshot.Status.CreatedAt.Time
+			}
+		}
+
+		expired := retention.Expired(times)
+		kept += len(snapshots) - len(expired)
+		for _, i := range expired {
+			snapshot := snapshots[i]
+			a.log.Info("pruning snapshot", "schedule", s.Name, "snapshot", snapshot.Name)
+			if err := c.Delete(ctx, &snapshot); err != nil && !k8s_errors.IsNotFound(err) {
+				return kept, errors.Wrap(err, fmt.Sprintf("unable to prune the snapshot %v", snapshot.Name))
+			}
+		}
+	}
+	return kept, nil
+}
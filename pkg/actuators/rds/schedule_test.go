@@ -0,0 +1,75 @@
+package rds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestScheduleReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+
+	created := time.Date(2019, time.July, 1, 0, 30, 0, 0, time.UTC)
+	schedule := &databasesv1.SnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default", CreationTimestamp: metav1.NewTime(created)},
+		Spec: databasesv1.SnapshotScheduleSpec{
+			Schedule: "0 3 * * *",
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"backup": "nightly"}},
+			KeepLast: 2,
+		},
+	}
+	selected := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default", Labels: map[string]string{"backup": "nightly"}}}
+	other := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "default"}}
+
+	// Three snapshots of previous runs, the oldest one expires
+	var objects []runtime.Object
+	for day := 1; day <= 3; day++ {
+		s := &databasesv1.RdsSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      schedule.SnapshotName("pgsql", metav1.NewTime(created.AddDate(0, 0, day-1))),
+				Namespace: "default",
+				Labels:    map[string]string{databasesv1.ScheduleLabel: "nightly", databasesv1.RdsLabel: "pgsql"},
+			},
+			Status: databasesv1.RdsSnapshotStatus{State: databasesv1.StateAvailable, CreatedAt: &metav1.Time{Time: created.AddDate(0, 0, day-1)}},
+		}
+		objects = append(objects, s)
+	}
+	objects = append(objects, schedule, selected, other)
+
+	reconciler := &controllers.SnapshotScheduleReconciler{Client: fake.NewFakeClientWithScheme(scheme, objects...)}
+	now := time.Date(2019, time.July, 4, 5, 0, 0, 0, time.UTC)
+	a := &ScheduleActuator{Actuator: &Actuator{log: zap.Logger(true)}, now: func() time.Time { return now }}
+
+	status, err := a.Reconcile(schedule, reconciler, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateAvailable, status.State)
+	assert.Equal(t, time.Date(2019, time.July, 4, 3, 0, 0, 0, time.UTC), status.LastScheduleTime.Time)
+	assert.Equal(t, time.Date(2019, time.July, 5, 3, 0, 0, 0, time.UTC), status.NextScheduleTime.Time)
+	assert.Equal(t, 2, status.Snapshots)
+
+	list := &databasesv1.RdsSnapshotList{}
+	assert.NoError(t, reconciler.List(context.Background(), list, client.InNamespace("default")))
+	var names []string
+	for _, s := range list.Items {
+		names = append(names, s.Name)
+	}
+	// Only the latest missed run is taken, for the selected Rds
+	assert.ElementsMatch(t, []string{"nightly-pgsql-20190702-0030", "nightly-pgsql-20190703-0030", "nightly-pgsql-20190704-0300"}, names)
+
+	// Invalid expressions are reported without retries
+	schedule.Spec.Schedule = "every night"
+	status, err = a.Reconcile(schedule, reconciler, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+}
@@ -0,0 +1,208 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SnapshotActuator manages manual snapshots with the clients of the Rds actuator
+type SnapshotActuator struct {
+	*Actuator
+}
+
+// Snapshot returns the actuator of the RdsSnapshot objects
+func (a *Actuator) Snapshot() *SnapshotActuator {
+	return &SnapshotActuator{Actuator: a}
+}
+
+func (a *SnapshotActuator) Reconcile(s *databasesv1.RdsSnapshot, client *controllers.RdsSnapshotReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsSnapshotStatus, err error) {
+	// The snapshot is taken in the account of the Rds. The config is persisted along the identifier
+	// before the snapshot is taken, the Rds may change afterwards
+	var db *databasesv1.Rds
+	if s.Status.SnapshotIdentifier == "" {
+		db, status, err = a.rds(s, client, ctx)
+		if db == nil {
+			return a.unscoped(s, status, err), err
+		}
+		s.Status.ProviderConfig = providerConfigOf(db)
+	}
+
+	scoped, err := a.forConfig(s.Status.ProviderConfig, s.Namespace, client.Client, ctx)
+	if err != nil {
+		return a.unscoped(s, databasesv1.NewSnapshotStatus(err.Error(), databasesv1.StateError), err), err
+	}
+	snapshots := scoped.Snapshot()
+	if db != nil {
+		s.Status.SourceIdentifier = db.Status.InstanceIdentifier
+		s.Status.SnapshotIdentifier = scoped.k8srds.SnapshotIdentifier(s, db.Status.InstanceIdentifier)
+	}
+	status, err = snapshots.reconcile(s, client, ctx)
+	return snapshots.observe(s, status, err), err
+}
+
+// rds returns the snapshotted Rds once it is available, along the status to report while it isn't
+func (a *SnapshotActuator) rds(s *databasesv1.RdsSnapshot, client *controllers.RdsSnapshotReconciler, ctx context.Context) (*databasesv1.Rds, databasesv1.RdsSnapshotStatus, error) {
+	db := &databasesv1.Rds{}
+	key := types.NamespacedName{Namespace: s.Namespace, Name: s.Spec.RdsRef.Name}
+	err := client.Get(ctx, key, db)
+	if k8s_errors.IsNotFound(err) {
+		return nil, databasesv1.NewSnapshotStatus(fmt.Sprintf("Waiting for %v", key), databasesv1.StatePending), nil
+	}
+	if err != nil {
+		return nil, databasesv1.NewSnapshotStatus("Failing Getting Rds", databasesv1.StatePending), err
+	}
+	if db.Status.State != databasesv1.StateAvailable || db.Status.InstanceIdentifier == "" {
+		return nil, databasesv1.NewSnapshotStatus(fmt.Sprintf("Waiting for %v to be available", key), databasesv1.StatePending), nil
+	}
+	return db, databasesv1.RdsSnapshotStatus{}, nil
+}
+
+func (a *SnapshotActuator) reconcile(s *databasesv1.RdsSnapshot, client *controllers.RdsSnapshotReconciler, ctx context.Context) (status databasesv1.RdsSnapshotStatus, err error) {
+	log := a.log.WithValues("reconcilingSnapshot", s.Name)
+
+	snapshot, err := a.k8srds.DescribeSnapshot(s.Status.SnapshotIdentifier)
+	if err != nil {
+		return databasesv1.NewSnapshotStatus(err.Error(), databasesv1.StateError), err
+	}
+
+	// CREATE
+	if snapshot == nil {
+		if s.Status.ARN != "" {
+			err = fmt.Errorf("snapshot %v vanished", s.Status.SnapshotIdentifier)
+			return databasesv1.NewSnapshotStatus(err.Error(), databasesv1.StateError), err
+		}
+		log.Info("creating", "snapshot", s.Status.SnapshotIdentifier)
+		err = a.k8srds.CreateSnapshot(s.Status.SnapshotIdentifier, s.Status.SourceIdentifier, s.Spec.Tags, s)
+		if err != nil {
+			return databasesv1.NewSnapshotStatus(err.Error(), databasesv1.StatePending), err
+		}
+		return databasesv1.NewSnapshotStatus("Creating Snapshot", "creating"), nil
+	}
+
+	if err := a.k8srds.VerifySnapshotOwnership(snapshot, s); err != nil {
+		return a.conflict(s, client, err)
+	}
+
+	currentStatus := aws.StringValue(snapshot.Status)
+	if currentStatus == "failed" {
+		err = fmt.Errorf("snapshot %v failed", s.Status.SnapshotIdentifier)
+		return databasesv1.NewSnapshotStatus(err.Error(), currentStatus), err
+	}
+	if currentStatus != databasesv1.StateAvailable {
+		return databasesv1.NewSnapshotStatus(fmt.Sprintf("Snapshot %v%% done", aws.Int64Value(snapshot.PercentProgress)), currentStatus), nil
+	}
+
+	// COPIES
+	copies, done, err := a.replicateSnapshot(snapshot, s.Spec.Copies, s)
+	s.Status.Copies = copies
+	if err != nil {
+		return databasesv1.NewSnapshotStatus("Failed To Copy Snapshot", databasesv1.StateModifying), err
+	}
+	if !done {
+		return databasesv1.NewSnapshotStatus("Copying Snapshot", databasesv1.StateModifying), nil
+	}
+	return databasesv1.NewSnapshotStatus("Snapshot available", currentStatus), nil
+}
+
+func (a *SnapshotActuator) Delete(s *databasesv1.RdsSnapshot, client *controllers.RdsSnapshotReconciler, ctx context.Context, namespacedName types.NamespacedName) (status databasesv1.RdsSnapshotStatus, err error) {
+	if s.Status.SnapshotIdentifier == "" {
+		return a.unscoped(s, databasesv1.NewSnapshotStatus("Deleted", databasesv1.StateDeleted), nil), nil
+	}
+	scoped, err := a.forConfig(s.Status.ProviderConfig, s.Namespace, client.Client, ctx)
+	if err != nil {
+		return a.unscoped(s, databasesv1.NewSnapshotStatus(err.Error(), databasesv1.StateError), err), err
+	}
+	snapshots := scoped.Snapshot()
+	status, err = snapshots.delete(s, client)
+	return snapshots.observe(s, status, err), err
+}
+
+func (a *SnapshotActuator) delete(s *databasesv1.RdsSnapshot, client *controllers.RdsSnapshotReconciler) (status databasesv1.RdsSnapshotStatus, err error) {
+	if s.Spec.Retain || s.Status.SnapshotIdentifier == "" {
+		return databasesv1.NewSnapshotStatus("Deleted", databasesv1.StateDeleted), nil
+	}
+
+	snapshot, err := a.k8srds.DescribeSnapshot(s.Status.SnapshotIdentifier)
+	if err != nil {
+		return databasesv1.NewSnapshotStatus("Error Getting Snapshot", databasesv1.StateError), err
+	}
+	if snapshot == nil {
+		return databasesv1.NewSnapshotStatus("Deleted", databasesv1.StateDeleted), nil
+	}
+
+	if err := a.k8srds.VerifySnapshotOwnership(snapshot, s); err != nil {
+		return a.conflict(s, client, err)
+	}
+
+	// A snapshot can only be deleted once taken
+	currentStatus := aws.StringValue(snapshot.Status)
+	if currentStatus == "creating" || currentStatus == "deleting" {
+		return databasesv1.NewSnapshotStatus("Snapshot not in a deletable state, will wait", currentStatus), nil
+	}
+
+	err = a.deleteSnapshotCopies(s.Status.SnapshotIdentifier, s.Status.Copies)
+	if err != nil {
+		return databasesv1.NewSnapshotStatus(err.Error(), currentStatus), err
+	}
+	err = a.k8srds.DeleteSnapshot(s.Status.SnapshotIdentifier)
+	if err != nil {
+		return databasesv1.NewSnapshotStatus(err.Error(), currentStatus), err
+	}
+	return databasesv1.NewSnapshotStatus("Deleting", "deleting"), nil
+}
+
+// conflict reports a snapshot owned by someone else
+func (a *SnapshotActuator) conflict(s *databasesv1.RdsSnapshot, client *controllers.RdsSnapshotReconciler, err error) (databasesv1.RdsSnapshotStatus, error) {
+	if !k8srds.IsOwnershipError(err) {
+		return databasesv1.NewSnapshotStatus("Error Verifying Ownership", databasesv1.StateError), err
+	}
+	if client != nil && client.Recorder != nil {
+		client.Recorder.Event(s, corev1.EventTypeWarning, "Conflict", err.Error())
+	}
+	return databasesv1.NewSnapshotStatus(err.Error(), databasesv1.StateConflict), err
+}
+
+// unscoped sets the state and the conditions while the account of the snapshot is unknown
+func (a *SnapshotActuator) unscoped(s *databasesv1.RdsSnapshot, status databasesv1.RdsSnapshotStatus, err error) databasesv1.RdsSnapshotStatus {
+	observed := *s.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+	observed.Conditions = conditionsFor(observed.Conditions, observed.State, observed.Message, s, err)
+	return observed
+}
+
+// observe completes the status with the snapshot details and the conditions
+func (a *SnapshotActuator) observe(s *databasesv1.RdsSnapshot, status databasesv1.RdsSnapshotStatus, err error) databasesv1.RdsSnapshotStatus {
+	observed := *s.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+
+	if status.State != databasesv1.StateConflict && observed.SnapshotIdentifier != "" {
+		snapshot, serr := a.k8srds.DescribeSnapshot(observed.SnapshotIdentifier)
+		if serr != nil {
+			a.log.Info("unable to describe snapshot", "name", s.Name, "error", serr)
+		} else if snapshot != nil {
+			observed.ARN = aws.StringValue(snapshot.DBSnapshotArn)
+			observed.Progress = aws.Int64Value(snapshot.PercentProgress)
+			observed.AllocatedStorage = aws.Int64Value(snapshot.AllocatedStorage)
+			observed.Engine = aws.StringValue(snapshot.Engine)
+			observed.EngineVersion = aws.StringValue(snapshot.EngineVersion)
+			if snapshot.SnapshotCreateTime != nil {
+				createdAt := metav1.NewTime(*snapshot.SnapshotCreateTime)
+				observed.CreatedAt = &createdAt
+			}
+		}
+	}
+
+	observed.Conditions = conditionsFor(observed.Conditions, observed.State, observed.Message, s, err)
+	return observed
+}
@@ -0,0 +1,197 @@
+package rds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
+	"github.com/cloud104/kube-db/pkg/actuators/rds/client/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testSnapshot() *databasesv1.RdsSnapshot {
+	return &databasesv1.RdsSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default", UID: "9d8e7f6a"},
+		Spec:       databasesv1.RdsSnapshotSpec{RdsRef: corev1.LocalObjectReference{Name: "pgsql"}},
+	}
+}
+
+func testSnapshotReconciler(t *testing.T, objects ...runtime.Object) *controllers.RdsSnapshotReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, databasesv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	return &controllers.RdsSnapshotReconciler{
+		Client:   ctrlfake.NewFakeClientWithScheme(scheme, objects...),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+// testSnapshotted returns the actuator of the snapshots along an available database to snapshot
+func testSnapshotted(t *testing.T, backend *fake.Backend) (*SnapshotActuator, *databasesv1.Rds) {
+	a := testActuator(backend, testSecret())
+	db := testDatabase()
+	reconcileDatabase(t, a, backend, testReconciler(t), db)
+	return a.Snapshot(), db
+}
+
+// reconcileSnapshot reconciles the snapshot until it is available, advancing the backends in between
+func reconcileSnapshot(t *testing.T, a *SnapshotActuator, client *controllers.RdsSnapshotReconciler, s *databasesv1.RdsSnapshot, backends ...*fake.Backend) {
+	key := types.NamespacedName{Namespace: s.Namespace, Name: s.Name}
+	for i := 0; i < 10; i++ {
+		status, err := a.Reconcile(s, client, context.Background(), key)
+		assert.NoError(t, err)
+		s.Status = status
+		if status.Message == "Snapshot available" {
+			return
+		}
+		for _, b := range backends {
+			b.Advance()
+		}
+	}
+	t.Fatalf("snapshot not available: %v", s.Status.Message)
+}
+
+// deleteSnapshot deletes the snapshot until it is deleted, advancing the backends in between
+func deleteSnapshot(t *testing.T, a *SnapshotActuator, client *controllers.RdsSnapshotReconciler, s *databasesv1.RdsSnapshot, backends ...*fake.Backend) {
+	key := types.NamespacedName{Namespace: s.Namespace, Name: s.Name}
+	for i := 0; i < 10; i++ {
+		status, err := a.Delete(s, client, context.Background(), key)
+		assert.NoError(t, err)
+		s.Status = status
+		if status.State == databasesv1.StateDeleted {
+			return
+		}
+		for _, b := range backends {
+			b.Advance()
+		}
+	}
+	t.Fatalf("snapshot not deleted: %v", s.Status.Message)
+}
+
+func TestSnapshotCreateAndDelete(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a, db := testSnapshotted(t, backend)
+	client := testSnapshotReconciler(t, db)
+	s := testSnapshot()
+	key := types.NamespacedName{Namespace: s.Namespace, Name: s.Name}
+
+	status, err := a.Reconcile(s, client, context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "creating", status.State)
+	assert.Equal(t, "pgsql-nightly", status.SnapshotIdentifier)
+	assert.Equal(t, "pgsql", status.SourceIdentifier)
+	assert.Contains(t, backend.Operations(), "CreateDBSnapshot")
+	s.Status = status
+	backend.Advance()
+
+	reconcileSnapshot(t, a, client, s, backend)
+	assert.Equal(t, databasesv1.StateAvailable, s.Status.State)
+	assert.Equal(t, "arn:aws:rds:us-east-1:123456789012:snapshot:pgsql-nightly", s.Status.ARN)
+	assert.Equal(t, int64(100), s.Status.Progress)
+	assert.NotNil(t, s.Status.CreatedAt)
+	assert.NoError(t, checkTags(backend, s.Status.ARN, "databases.tks.sh/uid", "9d8e7f6a"))
+
+	deleteSnapshot(t, a, client, s, backend)
+	assert.Contains(t, backend.Operations(), "DeleteDBSnapshot")
+	assert.Empty(t, backend.Snapshots)
+	assert.Contains(t, backend.Instances, "pgsql")
+}
+
+func TestSnapshotWaitsForRds(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a := testActuator(backend).Snapshot()
+	s := testSnapshot()
+	key := types.NamespacedName{Namespace: s.Namespace, Name: s.Name}
+
+	status, err := a.Reconcile(s, testSnapshotReconciler(t), context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StatePending, status.State)
+	assert.Empty(t, status.SnapshotIdentifier)
+
+	// Nothing was taken, the deletion has nothing to do
+	s.Status = status
+	status, err = a.Delete(s, testSnapshotReconciler(t), context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, databasesv1.StateDeleted, status.State)
+	assert.NotContains(t, backend.Operations(), "CreateDBSnapshot")
+}
+
+func TestSnapshotConflict(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a, db := testSnapshotted(t, backend)
+	client := testSnapshotReconciler(t, db)
+	backend.Snapshots["pgsql-nightly"] = &rds.DBSnapshot{
+		DBInstanceIdentifier: aws.String("pgsql"),
+		DBSnapshotArn:        aws.String("arn:aws:rds:us-east-1:123456789012:snapshot:pgsql-nightly"),
+		DBSnapshotIdentifier: aws.String("pgsql-nightly"),
+		SnapshotType:         aws.String("manual"),
+		Status:               aws.String("available"),
+	}
+	s := testSnapshot()
+	key := types.NamespacedName{Namespace: s.Namespace, Name: s.Name}
+
+	// A snapshot taken by someone else is never adopted nor deleted
+	status, err := a.Reconcile(s, client, context.Background(), key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+
+	s.Status = status
+	status, err = a.Delete(s, client, context.Background(), key)
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateConflict, status.State)
+	assert.NotContains(t, backend.Operations(), "DeleteDBSnapshot")
+	assert.Contains(t, backend.Snapshots, "pgsql-nightly")
+}
+
+func TestSnapshotRetain(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	a, db := testSnapshotted(t, backend)
+	client := testSnapshotReconciler(t, db)
+	s := testSnapshot()
+	s.Spec.Retain = true
+	reconcileSnapshot(t, a, client, s, backend)
+
+	// A retained snapshot outlives the object
+	deleteSnapshot(t, a, client, s, backend)
+	assert.NotContains(t, backend.Operations(), "DeleteDBSnapshot")
+	assert.Equal(t, "available", aws.StringValue(backend.Snapshots["pgsql-nightly"].Status))
+}
+
+func TestSnapshotCopies(t *testing.T) {
+	backend := fake.NewBackend("us-east-1")
+	offsite := fake.NewBackend("us-west-2")
+	a, db := testSnapshotted(t, backend)
+	a.k8srds.NewRDS = func(cfg aws.Config) k8srds.RDSAPI {
+		return offsite
+	}
+	client := testSnapshotReconciler(t, db)
+	s := testSnapshot()
+	s.Spec.Copies = []databasesv1.SnapshotCopy{
+		{AccountIDs: []string{"210987654321"}},
+		{Region: "us-west-2", AccountIDs: []string{"210987654321"}},
+	}
+
+	reconcileSnapshot(t, a, client, s, backend, offsite)
+	assert.Equal(t, []databasesv1.SnapshotCopyStatus{
+		{Region: "us-east-1", SnapshotIdentifier: "pgsql-nightly", ARN: "arn:aws:rds:us-east-1:123456789012:snapshot:pgsql-nightly", State: "available"},
+		{Region: "us-west-2", SnapshotIdentifier: "pgsql-nightly", ARN: "arn:aws:rds:us-west-2:123456789012:snapshot:pgsql-nightly", State: "available"},
+	}, s.Status.Copies)
+	assert.Equal(t, []string{"210987654321"}, backend.SharedWith["pgsql-nightly"])
+	assert.Equal(t, []string{"210987654321"}, offsite.SharedWith["pgsql-nightly"])
+	assert.NoError(t, checkTags(offsite, "arn:aws:rds:us-west-2:123456789012:snapshot:pgsql-nightly", "databases.tks.sh/uid", "9d8e7f6a"))
+
+	// The copies go along the snapshot, the one only shared is the snapshot itself
+	deleteSnapshot(t, a, client, s, backend, offsite)
+	assert.Empty(t, backend.Snapshots)
+	assert.Empty(t, offsite.Snapshots)
+}
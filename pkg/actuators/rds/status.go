@@ -0,0 +1,53 @@
+package rds
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	"github.com/cloud104/kube-db/pkg/actuators"
+)
+
+// observe completes the status returned by the actions with the instance details and the
+// conditions, carrying over the fields and transition times already stored in the object
+func (a *Actuator) observe(db *databasesv1.Rds, status databasesv1.RdsStatus, err error) databasesv1.RdsStatus {
+	observed := *db.Status.DeepCopy()
+	observed.State = status.State
+	observed.Message = status.Message
+	if len(status.Modifications) > 0 {
+		observed.Modifications = status.Modifications
+	}
+
+	// The details of an instance owned by someone else are none of our business
+	if status.State == databasesv1.StateConflict {
+		setConditions(&observed, db, true, err)
+		return observed
+	}
+
+	instance, ierr := a.k8srds.DescribeDatabase(db)
+	if ierr != nil {
+		a.log.Info("unable to describe database", "name", db.Name, "error", ierr)
+	} else if instance == nil {
+		observed.Address = ""
+		observed.Port = 0
+	} else {
+		if instance.Endpoint != nil {
+			observed.Address = aws.StringValue(instance.Endpoint.Address)
+			observed.Port = aws.Int64Value(instance.Endpoint.Port)
+		}
+		observed.ARN = aws.StringValue(instance.DBInstanceArn)
+		observed.DbiResourceID = aws.StringValue(instance.DbiResourceId)
+		observed.EngineVersion = aws.StringValue(instance.EngineVersion)
+		observed.AllocatedStorage = aws.Int64Value(instance.AllocatedStorage)
+	}
+
+	setConditions(&observed, db, a.kubeClient.HasService(db.Namespace, db.Name, db.UID), err)
+	return observed
+}
+
+// setConditions derives the conditions from the state, every condition type is always set
+// so clients can wait on any of them. The database is not Ready until its service exists
+func setConditions(status *databasesv1.RdsStatus, db *databasesv1.Rds, hasService bool, err error) {
+	status.Conditions = actuators.DatabaseConditions(status.Conditions, status.State, status.Message, db, err, hasService)
+}
+
+// conditionsFor updates the conditions of any object reporting the provider states
+var conditionsFor = actuators.Conditions
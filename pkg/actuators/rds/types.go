@@ -3,8 +3,7 @@ package rds
 import (
 	"context"
 
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	databasesv1 "github.com/cloud104/kube-db/api/v1"
 	controllers "github.com/cloud104/kube-db/controllers"
 	k8srds "github.com/cloud104/kube-db/pkg/actuators/rds/client"
@@ -15,14 +14,28 @@ import (
 
 type Actuator struct {
 	log        logr.Logger
-	ec2client  *ec2.Client
-	rdsclient  *rds.Client
 	kubeClient *Kube
 	k8srds     *k8srds.AWS
+	awsConfig  aws.Config
+	providers  *providers
+}
+
+// Options configures the actuator
+type Options struct {
+	// ClusterID tells apart the clusters sharing an AWS account
+	ClusterID string
+	// IdentifierTemplate renders the DBInstanceIdentifier of new instances
+	IdentifierTemplate string
+	// Region of the AWS clients, resolved from the AWS configuration or the nodes when empty
+	Region string
+	// Profile of the shared AWS configuration holding the credentials, the default chain when empty
+	Profile string
+	// Network selects the subnets and the security groups of new instances
+	Network Network
 }
 
 type Kube struct {
-	Client *kubernetes.Clientset
+	Client kubernetes.Interface
 }
 
 type Params struct {
@@ -0,0 +1,99 @@
+package actuators
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Registry is the actuator of the Rds objects, it hands each object to the actuator of its provider
+type Registry struct {
+	defaultProvider string
+	actuators       map[string]controllers.Actuator
+}
+
+// NewRegistry returns an empty registry, objects without spec.provider go to the default provider
+func NewRegistry(defaultProvider string) *Registry {
+	return &Registry{defaultProvider: defaultProvider, actuators: map[string]controllers.Actuator{}}
+}
+
+// Register adds the actuator of a provider
+func (r *Registry) Register(provider string, actuator controllers.Actuator) {
+	r.actuators[provider] = actuator
+}
+
+// Providers returns the registered providers, sorted
+func (r *Registry) Providers() []string {
+	var providers []string
+	for provider := range r.actuators {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// Provider returns the provider of the object. The provider recorded in the status wins, the
+// database can't move once created. Objects reconciled before the provider was recorded, which
+// have a status without conditions, belong to the default provider
+func (r *Registry) Provider(db *databasesv1.Rds) string {
+	if db.Status.Provider != "" {
+		return db.Status.Provider
+	}
+	if db.Status.ARN != "" || (db.Status.State != "" && len(db.Status.Conditions) == 0) {
+		return r.defaultProvider
+	}
+	if db.Spec.Provider != "" {
+		return db.Spec.Provider
+	}
+	return r.defaultProvider
+}
+
+func (r *Registry) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (databasesv1.RdsStatus, error) {
+	provider := r.Provider(db)
+	if db.Spec.Provider != "" && db.Spec.Provider != provider {
+		err := fmt.Errorf("provider can't change from %v to %v", provider, db.Spec.Provider)
+		return r.failure(db, err), err
+	}
+	actuator, err := r.actuator(provider)
+	if err != nil {
+		return r.failure(db, err), err
+	}
+
+	db.Status.Provider = provider
+	status, err := actuator.Reconcile(db, client, ctx, namespacedName)
+	status.Provider = provider
+	return status, err
+}
+
+func (r *Registry) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (databasesv1.RdsStatus, error) {
+	provider := r.Provider(db)
+	actuator, err := r.actuator(provider)
+	if err != nil {
+		return r.failure(db, err), err
+	}
+
+	status, err := actuator.Delete(db, client, ctx, namespacedName)
+	status.Provider = provider
+	return status, err
+}
+
+func (r *Registry) actuator(provider string) (controllers.Actuator, error) {
+	actuator, ok := r.actuators[provider]
+	if !ok {
+		return nil, fmt.Errorf("provider %v is not enabled, the enabled providers are %v", provider, r.Providers())
+	}
+	return actuator, nil
+}
+
+// failure reports an object no provider can handle, keeping the observed details
+func (r *Registry) failure(db *databasesv1.Rds, err error) databasesv1.RdsStatus {
+	status := *db.Status.DeepCopy()
+	status.State = databasesv1.StateError
+	status.Message = err.Error()
+	status.Conditions = Conditions(status.Conditions, status.State, status.Message, db, err)
+	return status
+}
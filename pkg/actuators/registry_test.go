@@ -0,0 +1,102 @@
+package actuators
+
+import (
+	"context"
+	"testing"
+
+	databasesv1 "github.com/cloud104/kube-db/api/v1"
+	controllers "github.com/cloud104/kube-db/controllers"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// stubActuator reports its own name as the state
+type stubActuator string
+
+func (s stubActuator) Reconcile(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (databasesv1.RdsStatus, error) {
+	return databasesv1.NewStatus("reconciled", string(s)), nil
+}
+
+func (s stubActuator) Delete(db *databasesv1.Rds, client *controllers.RdsReconciler, ctx context.Context, namespacedName types.NamespacedName) (databasesv1.RdsStatus, error) {
+	return databasesv1.NewStatus("deleted", string(s)), nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry("aws")
+	r.Register("aws", stubActuator("aws"))
+	r.Register("local", stubActuator("local"))
+	assert.Equal(t, []string{"aws", "local"}, r.Providers())
+
+	// Objects without provider go to the default one, which is recorded
+	db := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default"}}
+	status, err := r.Reconcile(db, nil, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, "aws", status.State)
+	assert.Equal(t, "aws", status.Provider)
+
+	db.Spec.Provider = "local"
+	db.Status = databasesv1.RdsStatus{}
+	status, err = r.Reconcile(db, nil, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, "local", status.State)
+	assert.Equal(t, "local", status.Provider)
+
+	// The provider can't change once recorded
+	db.Status.Provider = "aws"
+	status, err = r.Reconcile(db, nil, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+	assert.True(t, databasesv1.IsConditionTrue(status.Conditions, databasesv1.ConditionDegraded))
+
+	status, err = r.Delete(db, nil, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, "aws", status.State)
+
+	// Unknown providers fail, deletion included since the provider can't tell what was created
+	db.Spec.Provider = "gcloud"
+	db.Status = databasesv1.RdsStatus{}
+	status, err = r.Reconcile(db, nil, context.Background(), types.NamespacedName{})
+	assert.EqualError(t, err, "provider gcloud is not enabled, the enabled providers are [aws local]")
+
+	db.Status = status
+	status, err = r.Delete(db, nil, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+	assert.Equal(t, databasesv1.StateError, status.State)
+
+	db.Status.Provider = "gcloud"
+	_, err = r.Delete(db, nil, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+
+	// Once fixed the object goes to the named provider, the failure recorded no provider
+	db.Spec.Provider = "local"
+	db.Status.Provider = ""
+	status, err = r.Delete(db, nil, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, "local", status.State)
+}
+
+func TestRegistryBackfill(t *testing.T) {
+	r := NewRegistry("aws")
+	r.Register("aws", stubActuator("aws"))
+	r.Register("local", stubActuator("local"))
+
+	// Objects reconciled before the provider was recorded belong to the default provider
+	db := &databasesv1.Rds{ObjectMeta: metav1.ObjectMeta{Name: "pgsql", Namespace: "default"}}
+	db.Status.State = databasesv1.StateAvailable
+	status, err := r.Reconcile(db, nil, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, "aws", status.Provider)
+
+	db.Status = databasesv1.RdsStatus{ARN: "arn:aws:rds:us-east-1:123456789012:db:pgsql", State: databasesv1.StateError}
+	db.Status.Conditions = Conditions(nil, db.Status.State, "failed", db, nil)
+	status, err = r.Delete(db, nil, context.Background(), types.NamespacedName{})
+	assert.NoError(t, err)
+	assert.Equal(t, "aws", status.State)
+	assert.Equal(t, "aws", status.Provider)
+
+	// so they can't move to another one
+	db.Spec.Provider = "local"
+	_, err = r.Reconcile(db, nil, context.Background(), types.NamespacedName{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "0 3 * * *", "*/15 0-6,22 1 1-12/3 1-5", "@daily", "0 0 * * 7"} {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2019, time.July, 10, 14, 7, 30, 0, time.UTC) // a wednesday
+	cases := map[string]time.Time{
+		"* * * * *":     time.Date(2019, time.July, 10, 14, 8, 0, 0, time.UTC),
+		"*/15 * * * *":  time.Date(2019, time.July, 10, 14, 15, 0, 0, time.UTC),
+		"0 3 * * *":     time.Date(2019, time.July, 11, 3, 0, 0, 0, time.UTC),
+		"@weekly":       time.Date(2019, time.July, 14, 0, 0, 0, 0, time.UTC),
+		"0 0 * * 7":     time.Date(2019, time.July, 14, 0, 0, 0, 0, time.UTC),
+		"0 0 1 */3 *":   time.Date(2019, time.October, 1, 0, 0, 0, 0, time.UTC),
+		"30 2 1 * 5":    time.Date(2019, time.July, 12, 2, 30, 0, 0, time.UTC),
+		"0 0 29 2 *":    time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"10 14 10 7 *":  time.Date(2019, time.July, 10, 14, 10, 0, 0, time.UTC),
+		"7 14 10 7 3":   time.Date(2019, time.July, 17, 14, 7, 0, 0, time.UTC),
+		"0 12 * * 1-5":  time.Date(2019, time.July, 11, 12, 0, 0, 0, time.UTC),
+		"0 0 31 4 *":    {},
+		"5/20 10 * * *": time.Date(2019, time.July, 11, 10, 5, 0, 0, time.UTC),
+	}
+	for expr, expected := range cases {
+		c, err := Parse(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, c.Next(from), expr)
+	}
+}
@@ -0,0 +1,65 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Retention keeps the last snapshots plus the newest snapshot of the last days and weeks.
+// A snapshot kept by any rule is kept, nothing expires when no rule is set
+type Retention struct {
+	Last   int
+	Daily  int
+	Weekly int
+}
+
+// Expired returns the indexes of the times no rule keeps
+func (r Retention) Expired(times []time.Time) []int {
+	if r.Last <= 0 && r.Daily <= 0 && r.Weekly <= 0 {
+		return nil
+	}
+
+	// Newest first
+	order := make([]int, len(times))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return times[order[i]].After(times[order[j]]) })
+
+	keep := map[int]bool{}
+	for n, i := range order {
+		if n < r.Last {
+			keep[i] = true
+		}
+	}
+	r.keepPeriods(times, order, r.Daily, func(t time.Time) string { return t.Format("2006-01-02") }, keep)
+	r.keepPeriods(times, order, r.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%v-%v", year, week)
+	}, keep)
+
+	var expired []int
+	for _, i := range order {
+		if !keep[i] {
+			expired = append(expired, i)
+		}
+	}
+	sort.Ints(expired)
+	return expired
+}
+
+// keepPeriods keeps the newest time of the count most recent periods
+func (r Retention) keepPeriods(times []time.Time, order []int, count int, period func(time.Time) string, keep map[int]bool) {
+	seen := map[string]bool{}
+	for _, i := range order {
+		if len(seen) >= count {
+			return
+		}
+		p := period(times[i].UTC())
+		if !seen[p] {
+			seen[p] = true
+			keep[i] = true
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionExpired(t *testing.T) {
+	// Two snapshots a day for four weeks, oldest first
+	start := time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC) // a monday
+	var times []time.Time
+	for i := 0; i < 56; i++ {
+		times = append(times, start.Add(time.Duration(i)*12*time.Hour))
+	}
+
+	assert.Empty(t, Retention{}.Expired(times))
+	assert.Len(t, Retention{Last: 3}.Expired(times), 53)
+	assert.Empty(t, Retention{Last: 100}.Expired(times))
+
+	// The newest of each of the last 2 days
+	expired := Retention{Daily: 2}.Expired(times)
+	assert.Len(t, expired, 54)
+	assert.NotContains(t, expired, 55)
+	assert.NotContains(t, expired, 53)
+	assert.Contains(t, expired, 54)
+
+	// The newest of each of the 4 weeks, the last one overlapping with the last snapshot
+	expired = Retention{Last: 1, Weekly: 4}.Expired(times)
+	assert.Len(t, expired, 52)
+	for _, kept := range []int{13, 27, 41, 55} {
+		assert.NotContains(t, expired, kept)
+	}
+
+	// Order of the input does not matter
+	reversed := make([]time.Time, len(times))
+	for i, t := range times {
+		reversed[len(times)-1-i] = t
+	}
+	expired = Retention{Weekly: 4}.Expired(reversed)
+	for _, kept := range []int{0, 14, 28, 42} {
+		assert.NotContains(t, expired, kept)
+	}
+}